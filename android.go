@@ -0,0 +1,60 @@
+package machineid
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"strings"
+)
+
+// androidSerialProps lists the system properties holding the device serial on
+// Android, in order of preference.
+var androidSerialProps = []string{"ro.serialno", "ro.boot.serialno"}
+
+// isAndroid reports whether fsys is the root filesystem of an Android device,
+// which runs with GOOS=linux for Go mobile libraries but exposes no DMI.
+func isAndroid(fsys fs.FS) bool {
+	_, err := fs.Stat(fsys, "system/build.prop")
+
+	return err == nil
+}
+
+// androidSerialFallback substitutes the Android device serial for a system
+// UUID that could not be read from DMI with err. On other systems, err is
+// returned unchanged. The source reported is the property the serial came from.
+func (p *Provider) androidSerialFallback(ctx context.Context, err error, logger *slog.Logger) (string, string, error) {
+	if !isAndroid(p.filesystem()) {
+		return "", "", err
+	}
+
+	if logger != nil {
+		logger.Info("DMI UUID unavailable on Android, using device serial", "error", err)
+	}
+
+	return androidSerial(ctx, p.commandExecutor, logger)
+}
+
+// androidSerial retrieves the device serial with getprop, returning it with
+// the property it was read from. Restricted properties read as empty or
+// "unknown" and are skipped.
+func androidSerial(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, string, error) {
+	var lastErr error
+	for _, prop := range androidSerialProps {
+		output, err := executeCommand(ctx, executor, logger, "getprop", prop)
+		if err != nil {
+			lastErr = err
+
+			continue
+		}
+
+		if serial := strings.TrimSpace(output); serial != "" && serial != "unknown" {
+			return serial, prop, nil
+		}
+	}
+
+	if lastErr != nil {
+		return "", "", lastErr
+	}
+
+	return "", "", &ParseError{Source: "getprop ro.serialno", Err: ErrNotFound}
+}
@@ -0,0 +1,97 @@
+package machineid
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+// androidFS is the root filesystem of an Android device, which has no DMI.
+var androidFS = fstest.MapFS{
+	"system/build.prop": {Data: []byte("ro.build.version.sdk=34\n")},
+}
+
+// TestAndroidSerialFallback tests that a failed DMI UUID is replaced with the
+// first usable getprop serial on Android only.
+func TestAndroidSerialFallback(t *testing.T) {
+	dmiErr := errors.New("product_uuid: permission denied")
+
+	tests := []struct {
+		name       string
+		rootFS     fstest.MapFS
+		props      map[string]string
+		want       string
+		wantSource string
+		wantErr    error
+		wantCalls  int
+	}{
+		{
+			name:       "ro.serialno",
+			rootFS:     androidFS,
+			props:      map[string]string{"ro.serialno": "R58M123ABCD\n", "ro.boot.serialno": "OTHER"},
+			want:       "R58M123ABCD",
+			wantSource: "ro.serialno",
+			wantCalls:  1,
+		},
+		{
+			name:       "restricted ro.serialno",
+			rootFS:     androidFS,
+			props:      map[string]string{"ro.serialno": "unknown", "ro.boot.serialno": "R58M123ABCD"},
+			want:       "R58M123ABCD",
+			wantSource: "ro.boot.serialno",
+			wantCalls:  2,
+		},
+		{
+			name:      "no serial",
+			rootFS:    androidFS,
+			props:     map[string]string{"ro.serialno": "", "ro.boot.serialno": "\n"},
+			wantErr:   ErrNotFound,
+			wantCalls: 2,
+		},
+		{
+			name:      "not Android",
+			rootFS:    fstest.MapFS{},
+			props:     map[string]string{"ro.serialno": "R58M123ABCD"},
+			wantErr:   dmiErr,
+			wantCalls: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockExecutor()
+			for prop, value := range tt.props {
+				mock.setOutputForArgs("getprop", []string{prop}, value)
+			}
+			p := New().WithExecutor(mock)
+			p.rootFS = tt.rootFS
+
+			got, source, err := p.androidSerialFallback(context.Background(), dmiErr, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("androidSerialFallback() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("androidSerialFallback() error = %v", err)
+			}
+			if got != tt.want || source != tt.wantSource {
+				t.Errorf("androidSerialFallback() = %q, %q, want %q, %q", got, source, tt.want, tt.wantSource)
+			}
+			if mock.callCount["getprop"] != tt.wantCalls {
+				t.Errorf("getprop calls = %d, want %d", mock.callCount["getprop"], tt.wantCalls)
+			}
+		})
+	}
+}
+
+// TestAndroidSerialCommandError tests that a getprop failure is reported when
+// no property could be read.
+func TestAndroidSerialCommandError(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setError("getprop", ErrNotFound)
+
+	if _, _, err := androidSerial(context.Background(), mock, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("androidSerial() error = %v, want ErrNotFound", err)
+	}
+}
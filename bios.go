@@ -1,5 +1,76 @@
-//go:build linux || windows
-
 package machineid
 
+import "strings"
+
 const biosFirmwareMessage string = "To be filled by O.E.M."
+
+// oemPlaceholders lists, in lowercase, the values that firmware vendors leave
+// in SMBIOS fields they never filled in. Such values are shared by every
+// machine from the same board template and identify nothing.
+var oemPlaceholders = map[string]struct{}{
+	strings.ToLower(biosFirmwareMessage): {},
+	"to be filled by o.e.m":              {},
+	"system serial number":               {},
+	"base board serial number":           {},
+	"chassis serial number":              {},
+	"system product name":                {},
+	"default string":                     {},
+	"not specified":                      {},
+	"not applicable":                     {},
+	"not available":                      {},
+	"not settable":                       {},
+	"none":                               {},
+	"n/a":                                {},
+	"o.e.m.":                             {},
+	"oem":                                {},
+	"0":                                  {},
+	"123456789":                          {},
+	"0123456789":                         {},
+}
+
+// isOEMPlaceholder reports whether value, ignoring case and surrounding
+// whitespace, is a known OEM placeholder.
+func isOEMPlaceholder(value string) bool {
+	_, ok := oemPlaceholders[strings.ToLower(strings.TrimSpace(value))]
+
+	return ok
+}
+
+// isReliableUUID reports whether a firmware-reported UUID is usable as a unique
+// identifier. Unset SMBIOS UUIDs are commonly reported as all zeros or all F's,
+// particularly in virtual machines.
+func isReliableUUID(uuid string) bool {
+	if isOEMPlaceholder(uuid) {
+		return false
+	}
+
+	hexDigits := strings.ReplaceAll(strings.ToLower(uuid), "-", "")
+	if hexDigits == "" {
+		return false
+	}
+
+	return strings.Trim(hexDigits, "0") != "" && strings.Trim(hexDigits, "f") != ""
+}
+
+// parseDmidecodeString returns the value printed by `dmidecode -s keyword`:
+// the first line that is neither empty nor a "#" comment, which dmidecode
+// prints when it cannot find an SMBIOS entry point. Values failing validator
+// are rejected like invalid sysfs values.
+func parseDmidecodeString(output string, validator func(string) bool) (string, error) {
+	for line := range strings.SplitSeq(output, "\n") {
+		value := strings.TrimSpace(line)
+		if value == "" || strings.HasPrefix(value, "#") {
+			continue
+		}
+		if validator(value) {
+			return value, nil
+		}
+		if isOEMPlaceholder(value) {
+			return "", &ParseError{Source: "dmidecode output", Err: ErrOEMPlaceholder}
+		}
+
+		break
+	}
+
+	return "", &ParseError{Source: "dmidecode output", Err: ErrNotFound}
+}
@@ -0,0 +1,70 @@
+package machineid
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestIsOEMPlaceholder tests the OEM placeholder set, which ignores case and
+// surrounding whitespace but matches whole values only.
+func TestIsOEMPlaceholder(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"To be filled by O.E.M.", true},
+		{"TO BE FILLED BY O.E.M.", true},
+		{"System Serial Number", true},
+		{"Base Board Serial Number", true},
+		{"Chassis Serial Number", true},
+		{"Default string", true},
+		{"Not Specified", true},
+		{"Not Applicable", true},
+		{"None", true},
+		{"N/A", true},
+		{"0", true},
+		{"123456789", true},
+		{"  default string\n", true},
+		{"", false},
+		{"00", false},
+		{"C02TEST123", false},
+		{"System Serial Number 42", false},
+		{"4C4C4544-0042-3510-8052-B4C04F384833", false},
+	}
+
+	for _, tt := range tests {
+		if got := isOEMPlaceholder(tt.value); got != tt.want {
+			t.Errorf("isOEMPlaceholder(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestParseDmidecodeString tests extraction of `dmidecode -s` values, skipping
+// comment lines and rejecting invalid values.
+func TestParseDmidecodeString(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    string
+		wantErr error
+	}{
+		{"uuid", "4c4c4544-0042-3510-8052-b4c04f384833\n", "4c4c4544-0042-3510-8052-b4c04f384833", nil},
+		{"comment", "# SMBIOS entry point at 0x000f0000\n4C4C4544-0042-3510-8052-B4C04F384833\n", "4C4C4544-0042-3510-8052-B4C04F384833", nil},
+		{"no entry point", "# No SMBIOS nor DMI entry point found, sorry.\n", "", ErrNotFound},
+		{"zeros", "00000000-0000-0000-0000-000000000000\n", "", ErrNotFound},
+		{"placeholder", "Not Specified\n", "", ErrOEMPlaceholder},
+		{"empty", "", "", ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDmidecodeString(tt.output, isReliableUUID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseDmidecodeString() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseDmidecodeString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package machineid
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// collectFreeBSDIdentifiers gathers FreeBSD hardware identifiers based on
+// provider config. It only runs commands through the provider's
+// [CommandExecutor] and reads /etc/hostid through the provider's filesystem,
+// so tests can exercise it on any host; see [collectIdentifiersFor].
+func collectFreeBSDIdentifiers(ctx context.Context, p *Provider, diag *DiagnosticInfo) ([]string, error) {
+	var identifiers []string
+	logger := p.logger
+
+	if p.includeCPU {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			return freeBSDCPU(ctx, p.commandExecutor, logger)
+		}, "cpu:", diag, ComponentCPU)
+	}
+
+	if p.includeSystemUUID {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			return freeBSDSystemUUID(ctx, p.commandExecutor, logger)
+		}, "uuid:", diag, ComponentSystemUUID)
+		if !p.excludeMachineID {
+			identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+				return freeBSDHostID(p.filesystem(), logger)
+			}, "machine:", diag, ComponentMachineID)
+		}
+	}
+
+	if p.includeMotherboard {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			return freeBSDMotherboardSerial(ctx, p.commandExecutor, logger)
+		}, "mb:", diag, ComponentMotherboard)
+	}
+
+	if p.includeMAC {
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(ctx context.Context) ([]string, error) {
+			return p.macAddresses(isVirtualNetInterface, isWirelessNetInterface, logger)
+		}, "mac:", diag, ComponentMAC)
+	}
+
+	if p.includeDisk {
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(ctx context.Context) ([]string, error) {
+			return freeBSDDiskSerials(ctx, p.commandExecutor, logger)
+		}, "disk:", diag, ComponentDisk)
+	}
+
+	return identifiers, nil
+}
+
+// freeBSDCPU retrieves the CPU model from sysctl.
+func freeBSDCPU(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "sysctl", "-n", "hw.model")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(output), nil
+}
+
+// freeBSDSystemUUID retrieves the SMBIOS system UUID from the kernel environment.
+func freeBSDSystemUUID(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "kenv", "-q", "smbios.system.uuid")
+	if err != nil {
+		return "", err
+	}
+
+	uuid := strings.TrimSpace(output)
+	if !isReliableUUID(uuid) {
+		return "", &ParseError{Source: "kenv smbios.system.uuid", Err: ErrNotFound}
+	}
+
+	return uuid, nil
+}
+
+// freeBSDMotherboardSerial retrieves the SMBIOS baseboard serial from the
+// kernel environment.
+func freeBSDMotherboardSerial(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "kenv", "-q", "smbios.planar.serial")
+	if err != nil {
+		return "", err
+	}
+
+	serial := strings.TrimSpace(output)
+	if isOEMPlaceholder(serial) {
+		return "", &ParseError{Source: "kenv smbios.planar.serial", Err: ErrOEMPlaceholder}
+	}
+
+	return serial, nil
+}
+
+// freeBSDHostID reads the host UUID that FreeBSD generates at first boot.
+func freeBSDHostID(fsys fs.FS, logger *slog.Logger) (string, error) {
+	data, err := fs.ReadFile(fsys, "etc/hostid")
+	if err != nil {
+		if logger != nil {
+			logger.Debug("failed to read file", "path", "/etc/hostid", "error", err)
+		}
+
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// freeBSDDiskSerials retrieves the serials of the disks listed in
+// kern.disks using camcontrol. Optical and memory disks are skipped, as are
+// disks camcontrol cannot identify, such as NVMe namespaces.
+func freeBSDDiskSerials(ctx context.Context, executor CommandExecutor, logger *slog.Logger) ([]string, error) {
+	output, err := executeCommand(ctx, executor, logger, "sysctl", "-n", "kern.disks")
+	if err != nil {
+		return nil, err
+	}
+
+	var serials []string
+	for _, disk := range strings.Fields(output) {
+		if strings.HasPrefix(disk, "cd") || strings.HasPrefix(disk, "md") {
+			continue
+		}
+
+		identify, err := executeCommand(ctx, executor, logger, "camcontrol", "identify", disk)
+		if err != nil {
+			continue
+		}
+		if serial := parseCamcontrolSerial(identify); serial != "" && !slices.Contains(serials, serial) {
+			serials = append(serials, serial)
+		}
+	}
+
+	return serials, nil
+}
+
+// parseCamcontrolSerial extracts the serial number from `camcontrol identify` output.
+func parseCamcontrolSerial(output string) string {
+	for line := range strings.Lines(output) {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "serial number"); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,279 @@
+package machineid
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// camcontrolIdentifyOutput is captured `camcontrol identify ada0` output of a
+// SATA SSD.
+const camcontrolIdentifyOutput = `pass0: <Samsung SSD 860 EVO 500GB RVT04B6Q> ACS-4 ATA SATA 3.x device
+pass0: 600.000MB/s transfers (SATA 3.x, UDMA6, PIO 512bytes)
+
+protocol              ACS-4 ATA SATA 3.x
+device model          Samsung SSD 860 EVO 500GB
+firmware revision     RVT04B6Q
+serial number         S3Z9NB0K123456A
+WWN                   5002538e40123456
+additional product id
+cylinders             16383
+heads                 16
+sectors/track         63
+`
+
+// newFreeBSDMock returns a mock executor answering the FreeBSD collector's
+// commands for a machine with one SATA disk, one NVMe disk and a CD drive.
+func newFreeBSDMock() *mockExecutor {
+	mock := newMockExecutor()
+	mock.setOutputForArgs("sysctl", []string{"-n", "hw.model"}, "Intel(R) Xeon(R) E-2236 CPU @ 3.40GHz")
+	mock.setOutputForArgs("kenv", []string{"-q", "smbios.system.uuid"}, "4c4c4544-0042-3510-8052-b4c04f384833")
+	mock.setOutputForArgs("kenv", []string{"-q", "smbios.planar.serial"}, ".7XYZ123.CN1296")
+	mock.setOutputForArgs("sysctl", []string{"-n", "kern.disks"}, "cd0 nvd0 ada0")
+	mock.setOutputForArgs("camcontrol", []string{"identify", "ada0"}, camcontrolIdentifyOutput)
+
+	return mock
+}
+
+// TestCollectFreeBSDIdentifiers tests the FreeBSD collector with captured
+// command output and a hostid file.
+func TestCollectFreeBSDIdentifiers(t *testing.T) {
+	p := New().WithExecutor(newFreeBSDMock()).WithCPU().WithSystemUUID().WithMotherboard().WithDisk()
+	p.rootFS = fstest.MapFS{
+		"etc/hostid": {Data: []byte("8d3c2a1b-5e4f-11ee-9a8b-0cc47a123456\n")},
+	}
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	identifiers, err := collectIdentifiersFor(context.Background(), "freebsd", p, diag)
+	if err != nil {
+		t.Fatalf("collectIdentifiersFor(freebsd) error = %v", err)
+	}
+
+	want := []string{
+		"cpu:Intel(R) Xeon(R) E-2236 CPU @ 3.40GHz",
+		"uuid:4c4c4544-0042-3510-8052-b4c04f384833",
+		"machine:8d3c2a1b-5e4f-11ee-9a8b-0cc47a123456",
+		"mb:.7XYZ123.CN1296",
+		"disk:S3Z9NB0K123456A",
+	}
+	if !slices.Equal(identifiers, want) {
+		t.Errorf("identifiers = %v, want %v", identifiers, want)
+	}
+	if len(diag.Errors) != 0 {
+		t.Errorf("diag.Errors = %v, want none", diag.Errors)
+	}
+}
+
+// TestFreeBSDUnreliableValues tests that unset SMBIOS values and a missing
+// hostid are reported as component errors.
+func TestFreeBSDUnreliableValues(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutputForArgs("kenv", []string{"-q", "smbios.system.uuid"}, "00000000-0000-0000-0000-000000000000")
+	mock.setOutputForArgs("kenv", []string{"-q", "smbios.planar.serial"}, biosFirmwareMessage)
+	p := New().WithExecutor(mock).WithSystemUUID().WithMotherboard()
+	p.rootFS = fstest.MapFS{}
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	identifiers, err := collectIdentifiersFor(context.Background(), "freebsd", p, diag)
+	if err != nil {
+		t.Fatalf("collectIdentifiersFor(freebsd) error = %v", err)
+	}
+	if len(identifiers) != 0 {
+		t.Errorf("identifiers = %v, want none", identifiers)
+	}
+	if !errors.Is(diag.Errors[ComponentSystemUUID], ErrNotFound) {
+		t.Errorf("uuid error = %v, want ErrNotFound", diag.Errors[ComponentSystemUUID])
+	}
+	if !errors.Is(diag.Errors[ComponentMotherboard], ErrOEMPlaceholder) {
+		t.Errorf("motherboard error = %v, want ErrOEMPlaceholder", diag.Errors[ComponentMotherboard])
+	}
+	if diag.Errors[ComponentMachineID] == nil {
+		t.Error("machine-id should fail without /etc/hostid")
+	}
+}
+
+// TestFreeBSDWithoutMachineID tests that the hostid is not read when the
+// machine-id is suppressed.
+func TestFreeBSDWithoutMachineID(t *testing.T) {
+	p := New().WithExecutor(newFreeBSDMock()).WithSystemUUID().WithoutMachineID()
+	p.rootFS = fstest.MapFS{
+		"etc/hostid": {Data: []byte("8d3c2a1b-5e4f-11ee-9a8b-0cc47a123456\n")},
+	}
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	identifiers, err := collectIdentifiersFor(context.Background(), "freebsd", p, diag)
+	if err != nil {
+		t.Fatalf("collectIdentifiersFor(freebsd) error = %v", err)
+	}
+
+	want := []string{"uuid:4c4c4544-0042-3510-8052-b4c04f384833"}
+	if !slices.Equal(identifiers, want) {
+		t.Errorf("identifiers = %v, want %v", identifiers, want)
+	}
+	if slices.Contains(diag.Collected, ComponentMachineID) {
+		t.Errorf("diag.Collected = %v, want no %s", diag.Collected, ComponentMachineID)
+	}
+}
+
+// cancelingExecutor is a mock executor that cancels the collection context
+// once its first command has completed.
+type cancelingExecutor struct {
+	*mockExecutor
+	cancel context.CancelFunc
+}
+
+// Execute runs the command on the mock executor, then cancels.
+func (e *cancelingExecutor) Execute(ctx context.Context, name string, args ...string) (string, error) {
+	defer e.cancel()
+
+	return e.mockExecutor.Execute(ctx, name, args...)
+}
+
+// TestCollectStopsOnCancel tests that components following a cancellation are
+// not collected.
+func TestCollectStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mock := newFreeBSDMock()
+	p := New().WithExecutor(&cancelingExecutor{mockExecutor: mock, cancel: cancel}).
+		WithCPU().WithSystemUUID().WithMotherboard().WithDisk()
+	p.rootFS = fstest.MapFS{}
+
+	identifiers, err := collectIdentifiersFor(ctx, "freebsd", p, &DiagnosticInfo{Errors: make(map[string]error)})
+	if err != nil {
+		t.Fatalf("collectIdentifiersFor(freebsd) error = %v", err)
+	}
+
+	if want := []string{"cpu:Intel(R) Xeon(R) E-2236 CPU @ 3.40GHz"}; !slices.Equal(identifiers, want) {
+		t.Errorf("identifiers = %v, want %v", identifiers, want)
+	}
+	if mock.callCount["sysctl"] != 1 || mock.callCount["kenv"] != 0 || mock.callCount["camcontrol"] != 0 {
+		t.Errorf("callCount = %v, want only the CPU sysctl", mock.callCount)
+	}
+}
+
+// blockingExecutor is a mock executor on which one command line hangs until
+// its context is done.
+type blockingExecutor struct {
+	*mockExecutor
+	commandLine string
+}
+
+// Execute blocks on the hanging command line, and otherwise runs the mock executor.
+func (e *blockingExecutor) Execute(ctx context.Context, name string, args ...string) (string, error) {
+	if commandLine(name, args) == e.commandLine {
+		<-ctx.Done()
+
+		return "", &CommandError{Command: name, Err: ctx.Err()}
+	}
+
+	return e.mockExecutor.Execute(ctx, name, args...)
+}
+
+// TestWithComponentTimeout tests that a hanging disk enumeration times out
+// on its own while the other components are collected.
+func TestWithComponentTimeout(t *testing.T) {
+	p := New().WithExecutor(&blockingExecutor{mockExecutor: newFreeBSDMock(), commandLine: "sysctl -n kern.disks"}).
+		WithCPU().WithSystemUUID().WithMotherboard().WithDisk().
+		WithComponentTimeout(50 * time.Millisecond)
+	p.rootFS = fstest.MapFS{}
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	identifiers, err := collectIdentifiersFor(context.Background(), "freebsd", p, diag)
+	if err != nil {
+		t.Fatalf("collectIdentifiersFor(freebsd) error = %v", err)
+	}
+
+	want := []string{
+		"cpu:Intel(R) Xeon(R) E-2236 CPU @ 3.40GHz",
+		"uuid:4c4c4544-0042-3510-8052-b4c04f384833",
+		"mb:.7XYZ123.CN1296",
+	}
+	if !slices.Equal(identifiers, want) {
+		t.Errorf("identifiers = %v, want %v", identifiers, want)
+	}
+
+	diskErr := diag.Errors[ComponentDisk]
+	if !errors.Is(diskErr, ErrComponentTimeout) {
+		t.Errorf("disk error = %v, want ErrComponentTimeout", diskErr)
+	}
+	var compErr *ComponentError
+	if !errors.As(diskErr, &compErr) || compErr.Component != ComponentDisk {
+		t.Errorf("disk error = %v, want ComponentError for %q", diskErr, ComponentDisk)
+	}
+	var cmdErr *CommandError
+	if !errors.As(diskErr, &cmdErr) || cmdErr.Command != "sysctl" {
+		t.Errorf("disk error = %v, want CommandError for sysctl", diskErr)
+	}
+}
+
+// TestParseCamcontrolSerial tests serial extraction from camcontrol output.
+func TestParseCamcontrolSerial(t *testing.T) {
+	if got := parseCamcontrolSerial(camcontrolIdentifyOutput); got != "S3Z9NB0K123456A" {
+		t.Errorf("parseCamcontrolSerial() = %q, want %q", got, "S3Z9NB0K123456A")
+	}
+	if got := parseCamcontrolSerial("protocol NVMe\n"); got != "" {
+		t.Errorf("parseCamcontrolSerial(no serial) = %q, want empty", got)
+	}
+}
+
+// TestMultiValueOrderIndependent is a regression test that the order in which
+// disks and interfaces are enumerated changes neither the identifiers nor the
+// hash.
+func TestMultiValueOrderIndependent(t *testing.T) {
+	disks := []string{"ada0", "ada1", "ada2", "da0", "da1"}
+	var interfaces []net.Interface
+	for i := range 5 {
+		interfaces = append(interfaces, net.Interface{
+			Index:        i + 1,
+			Name:         fmt.Sprintf("em%d", i),
+			Flags:        net.FlagUp,
+			HardwareAddr: net.HardwareAddr{0x00, 0x1b, 0x21, 0, 0, byte(i)},
+		})
+	}
+
+	collect := func(rng *rand.Rand) ([]string, string) {
+		t.Helper()
+
+		rng.Shuffle(len(disks), func(i, j int) { disks[i], disks[j] = disks[j], disks[i] })
+		rng.Shuffle(len(interfaces), func(i, j int) { interfaces[i], interfaces[j] = interfaces[j], interfaces[i] })
+
+		mock := newMockExecutor()
+		mock.setOutputForArgs("sysctl", []string{"-n", "kern.disks"}, strings.Join(disks, " "))
+		for _, disk := range disks {
+			mock.setOutputForArgs("camcontrol", []string{"identify", disk}, "serial number         SN-"+strings.ToUpper(disk)+"\n")
+		}
+		p := New().WithExecutor(mock).WithDisk().WithMAC()
+		p.listInterfaces = func() ([]net.Interface, error) { return slices.Clone(interfaces), nil }
+
+		identifiers, err := collectIdentifiersFor(context.Background(), "freebsd", p, nil)
+		if err != nil {
+			t.Fatalf("collectIdentifiersFor(freebsd) error = %v", err)
+		}
+
+		return identifiers, hashIdentifiers(sha256.New(), slices.Clone(identifiers), "")
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	wantIdentifiers, wantHash := collect(rng)
+	if len(wantIdentifiers) != 10 {
+		t.Fatalf("identifiers = %v, want 5 disks and 5 MACs", wantIdentifiers)
+	}
+	for range 20 {
+		identifiers, hash := collect(rng)
+		if !slices.Equal(identifiers, wantIdentifiers) {
+			t.Errorf("identifiers = %v, want %v", identifiers, wantIdentifiers)
+		}
+		if hash != wantHash {
+			t.Errorf("hash = %s, want %s", hash, wantHash)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package machineid
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// fingerprintBundle is the JSON document written by [Provider.WriteFingerprintBundle].
+type fingerprintBundle struct {
+	Platform   string              `json:"platform"`
+	Arch       string              `json:"arch"`
+	Version    string              `json:"version"`
+	ID         string              `json:"id"`
+	Format     FormatMode          `json:"format"`
+	Redacted   bool                `json:"redacted"`
+	DurationMS float64             `json:"duration_ms"`
+	Components []bundleComponent   `json:"components"`
+	UUIDSource string              `json:"uuid_source,omitempty"`
+	Duplicates map[string][]string `json:"duplicates,omitempty"`
+	Notes      []string            `json:"notes,omitempty"`
+}
+
+// bundleComponent describes the collection result of a single component.
+type bundleComponent struct {
+	Name        string   `json:"name"`
+	DisplayName string   `json:"display_name,omitempty"`
+	Collected   bool     `json:"collected"`
+	Values      []string `json:"values,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	DurationMS  float64  `json:"duration_ms"`
+}
+
+// WithUnredactedBundle makes [Provider.WriteFingerprintBundle] include raw
+// component values such as serial numbers. Enable it only when the user has
+// consented to sharing them.
+func (p *Provider) WithUnredactedBundle() *Provider {
+	p.unredactedBundle = true
+
+	return p
+}
+
+// WithWriter makes [Provider.ID] write the raw value of every collected
+// component to w, one "component=value" line per value, in canonical
+// component order, whenever it collects the hardware. Unlike the logger, which
+// shows raw values only at debug level, and [Provider.WriteFingerprintBundle],
+// which redacts them by default, the output is never redacted: it contains
+// serial numbers and other sensitive identifiers, so w must be protected
+// accordingly. Write errors are logged and do not fail the generation. A nil
+// w disables the output.
+func (p *Provider) WithWriter(w io.Writer) *Provider {
+	p.identifierWriter = w
+
+	return p
+}
+
+// dumpIdentifiers writes the values of the components collected in diag to
+// the [Provider.WithWriter] writer, if any. The caller must hold p.mu.
+func (p *Provider) dumpIdentifiers(diag *DiagnosticInfo) {
+	if p.identifierWriter == nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	for _, component := range diag.Collected {
+		for _, value := range p.componentValues[component] {
+			fmt.Fprintf(&buf, "%s=%s\n", component, value)
+		}
+	}
+
+	if _, err := p.identifierWriter.Write(buf.Bytes()); err != nil {
+		p.logWarn("failed to write collected identifiers", "error", err)
+	}
+}
+
+// WriteFingerprintBundle generates the machine ID and writes a JSON support
+// bundle describing the fingerprint to path: platform, library version, ID,
+// format, per-component results and timings, and fallback notes. Components
+// carry their [Provider.WithDisplayNames] name, if any, as display_name.
+//
+// Component values are redacted by default: each is replaced by a short
+// SHA-256 digest, which still shows whether two bundles saw the same value.
+// [Provider.WithRedactor] replaces the digest with a custom masking, and
+// [Provider.WithUnredactedBundle] includes raw values. The file is
+// created with mode 0600.
+func (p *Provider) WriteFingerprintBundle(ctx context.Context, path string) error {
+	p.mu.Lock()
+	id, err := p.id(ctx, nil)
+	if err != nil {
+		p.mu.Unlock()
+
+		return err
+	}
+	bundle := p.fingerprintBundle(id)
+	p.mu.Unlock()
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// fingerprintBundle assembles the bundle from the last generation. The caller must hold p.mu.
+func (p *Provider) fingerprintBundle(id string) fingerprintBundle {
+	diag := p.diagnostics
+	bundle := fingerprintBundle{
+		Platform:   runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Version:    Version(),
+		ID:         id,
+		Format:     p.formatMode,
+		Redacted:   !p.unredactedBundle,
+		DurationMS: durationMS(p.lastDuration),
+		UUIDSource: diag.UUIDSource,
+		Duplicates: diag.Duplicates,
+		Notes:      diag.Notes,
+	}
+
+	names := slices.Collect(maps.Keys(diag.Errors))
+	names = append(names, diag.Collected...)
+	slices.Sort(names)
+
+	for _, name := range slices.Compact(names) {
+		component := bundleComponent{
+			Name:        name,
+			DisplayName: p.displayNames[name],
+			Collected:   slices.Contains(diag.Collected, name),
+			DurationMS:  durationMS(p.componentDurations[name]),
+		}
+		if err, ok := diag.Errors[name]; ok {
+			component.Error = err.Error()
+		}
+		for _, value := range p.componentValues[name] {
+			if bundle.Redacted {
+				value = p.bundleRedactor()(name, value)
+			}
+			component.Values = append(component.Values, value)
+		}
+
+		bundle.Components = append(bundle.Components, component)
+	}
+
+	return bundle
+}
+
+// bundleRedactor returns the [Provider.WithRedactor] function, or the
+// built-in digest redaction if none is set.
+func (p *Provider) bundleRedactor() func(component, value string) string {
+	if p.redactor != nil {
+		return p.redactor
+	}
+
+	return func(_, value string) string {
+		return redactValue(value)
+	}
+}
+
+// redactValue replaces a component value with a short, comparable digest.
+func redactValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// durationMS converts d to fractional milliseconds.
+func durationMS(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
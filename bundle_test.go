@@ -0,0 +1,165 @@
+package machineid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// readBundle writes a fingerprint bundle for p and reads it back.
+func readBundle(t *testing.T, p *Provider) fingerprintBundle {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "fingerprint.json")
+	if err := p.WriteFingerprintBundle(context.Background(), path); err != nil {
+		t.Fatalf("WriteFingerprintBundle() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read bundle: %v", err)
+	}
+
+	var bundle fingerprintBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		t.Fatalf("bundle is not valid JSON: %v", err)
+	}
+
+	return bundle
+}
+
+// TestWriteFingerprintBundle tests a round trip of a redacted bundle.
+func TestWriteFingerprintBundle(t *testing.T) {
+	p := New().WithCPU().WithFormat(Format32)
+	bundle := readBundle(t, p)
+
+	id, err := p.ID(context.Background())
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	if bundle.ID != id {
+		t.Errorf("bundle ID = %q, want %q", bundle.ID, id)
+	}
+	if bundle.Platform != runtime.GOOS {
+		t.Errorf("bundle platform = %q, want %q", bundle.Platform, runtime.GOOS)
+	}
+	if bundle.Format != Format32 {
+		t.Errorf("bundle format = %v, want %v", bundle.Format, Format32)
+	}
+	if bundle.Version == "" {
+		t.Error("bundle version should not be empty")
+	}
+	if !bundle.Redacted {
+		t.Error("bundle should be redacted by default")
+	}
+
+	if len(bundle.Components) != 1 || bundle.Components[0].Name != ComponentCPU {
+		t.Fatalf("bundle components = %+v, want only %q", bundle.Components, ComponentCPU)
+	}
+	cpu := bundle.Components[0]
+	if !cpu.Collected || len(cpu.Values) != 1 {
+		t.Fatalf("cpu component = %+v, want one collected value", cpu)
+	}
+	if cpu.Values[0] != redactValue(p.componentValues[ComponentCPU][0]) {
+		t.Errorf("cpu value = %q, want redacted digest", cpu.Values[0])
+	}
+}
+
+// TestWriteFingerprintBundleUnredacted tests that raw values are included on opt-in.
+func TestWriteFingerprintBundleUnredacted(t *testing.T) {
+	p := New().WithCPU().WithUnredactedBundle()
+	bundle := readBundle(t, p)
+
+	if bundle.Redacted {
+		t.Error("bundle should not be redacted")
+	}
+	if len(bundle.Components) != 1 || len(bundle.Components[0].Values) != 1 {
+		t.Fatalf("bundle components = %+v, want one cpu value", bundle.Components)
+	}
+	if got := bundle.Components[0].Values[0]; strings.HasPrefix(got, "sha256:") || got != p.componentValues[ComponentCPU][0] {
+		t.Errorf("cpu value = %q, want raw value", got)
+	}
+}
+
+// TestWriteFingerprintBundleRedactor tests that a custom redactor replaces
+// the built-in digest.
+func TestWriteFingerprintBundleRedactor(t *testing.T) {
+	p := New().WithCPU().WithRedactor(func(component, value string) string {
+		return component + ":" + value[len(value)-4:]
+	})
+	bundle := readBundle(t, p)
+
+	raw := p.componentValues[ComponentCPU][0]
+	if got, want := bundle.Components[0].Values[0], "cpu:"+raw[len(raw)-4:]; got != want {
+		t.Errorf("cpu value = %q, want %q", got, want)
+	}
+}
+
+// TestWriteFingerprintBundleDisplayNames tests that display names appear in
+// the bundle while diagnostics keep the canonical component keys.
+func TestWriteFingerprintBundleDisplayNames(t *testing.T) {
+	p := New().WithCPU().WithDisplayNames(map[string]string{ComponentCPU: "Processor"})
+	bundle := readBundle(t, p)
+
+	cpu := bundle.Components[0]
+	if cpu.Name != ComponentCPU || cpu.DisplayName != "Processor" {
+		t.Errorf("cpu component name = %q, display name = %q; want %q, %q", cpu.Name, cpu.DisplayName, ComponentCPU, "Processor")
+	}
+	if got := p.Diagnostics().Collected; len(got) != 1 || got[0] != ComponentCPU {
+		t.Errorf("Diagnostics().Collected = %v, want [%s]", got, ComponentCPU)
+	}
+	if got := p.DisplayName(ComponentDisk); got != ComponentDisk {
+		t.Errorf("DisplayName(disk) = %q, want the short name", got)
+	}
+}
+
+// TestWriteFingerprintBundleError tests that generation failures are returned
+// without writing a bundle.
+func TestWriteFingerprintBundleError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fingerprint.json")
+
+	if err := New().WriteFingerprintBundle(context.Background(), path); err == nil {
+		t.Fatal("WriteFingerprintBundle() expected error with no components")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("bundle should not be written when ID generation fails")
+	}
+}
+
+// TestWithWriter tests that the raw identifiers are written as component=value
+// lines on collection, and not again for a cached ID.
+func TestWithWriter(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().WithMAC().WithWriter(&buf)
+	p.listInterfaces = physicalOnlyInterfaces
+
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	if got, want := buf.String(), "mac=3c:7c:3f:1a:2b:3c\n"; got != want {
+		t.Errorf("written identifiers = %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("cached ID() wrote %q, want nothing", buf.String())
+	}
+
+	cpu := New().WithCPU().WithMAC().WithWriter(&buf)
+	cpu.listInterfaces = physicalOnlyInterfaces
+	if _, err := cpu.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], ComponentCPU+"=") || lines[1] != "mac=3c:7c:3f:1a:2b:3c" {
+		t.Errorf("written identifiers = %q, want a cpu line then the mac line", lines)
+	}
+}
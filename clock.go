@@ -0,0 +1,44 @@
+package machineid
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the current time. [Provider.WithClock] replaces the system
+// clock with one, so tests can control the durations the provider measures.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the [Clock] backed by [time.Now].
+type realClock struct{}
+
+// Now implements [Clock].
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// clockKey is the context key under which [Provider.WithClock] passes the
+// clock to executeCommand.
+type clockKey struct{}
+
+// WithClock sets the [Clock] used to measure command durations, which are
+// reported in debug logs, and to place [Provider.WithTimeWindow] windows when
+// that has no clock of its own. Tests can inject a fake clock to assert timing
+// behavior without real sleeps. Timeouts still use real time. A nil clock
+// restores the system clock.
+func (p *Provider) WithClock(clock Clock) *Provider {
+	p.timeSource = clock
+
+	return p
+}
+
+// clockFrom returns the clock carried by ctx, or the system clock.
+func clockFrom(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(clockKey{}).(Clock); ok {
+		return clock
+	}
+
+	return realClock{}
+}
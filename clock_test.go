@@ -0,0 +1,59 @@
+package machineid
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stepClock is a fake [Clock] that advances by step on every reading.
+type stepClock struct {
+	now  time.Time
+	step time.Duration
+}
+
+// Now implements [Clock].
+func (c *stepClock) Now() time.Time {
+	now := c.now
+	c.now = c.now.Add(c.step)
+
+	return now
+}
+
+// TestWithClockCommandDuration tests that command durations are measured
+// with the injected clock.
+func TestWithClockCommandDuration(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutput("sysctl", "ok")
+	p := New().WithExecutor(mock).WithClock(&stepClock{now: time.Unix(0, 0), step: 2 * time.Second})
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	if _, err := executeCommand(p.commandContext(context.Background()), p.commandExecutor, logger, "sysctl"); err != nil {
+		t.Fatalf("executeCommand() error = %v", err)
+	}
+
+	if want := `"duration":2000000000`; !strings.Contains(buf.String(), want) {
+		t.Errorf("log = %s, want %s", buf.String(), want)
+	}
+}
+
+// TestWithClockTimeWindow tests that the injected clock places time windows
+// when WithTimeWindow has no clock of its own.
+func TestWithClockTimeWindow(t *testing.T) {
+	clock := &stepClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	p := New().WithTimeWindow(24*time.Hour, nil).WithClock(clock)
+
+	if got, want := p.currentWindow(), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).Unix(); got != want {
+		t.Errorf("currentWindow() = %d, want %d", got, want)
+	}
+
+	clock.now = clock.now.Add(24 * time.Hour)
+	if got, want := p.currentWindow(), time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC).Unix(); got != want {
+		t.Errorf("currentWindow() after a day = %d, want %d", got, want)
+	}
+}
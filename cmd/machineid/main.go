@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"os"
 	"runtime/debug"
+	"slices"
 	"strings"
 
 	"github.com/slashdevops/machineid"
@@ -22,13 +23,20 @@ func main() {
 	motherboard := flag.Bool("motherboard", false, "Include motherboard serial number")
 	uuid := flag.Bool("uuid", false, "Include system UUID")
 	mac := flag.Bool("mac", false, "Include network MAC addresses")
-	macFilterFlag := flag.String("mac-filter", "physical", "MAC filter: physical, all, virtual")
+	macFilterFlag := flag.String("mac-filter", "physical", "MAC filter: physical, all, virtual, wired")
 	disk := flag.Bool("disk", false, "Include disk serial numbers")
+	diskFilterFlag := flag.String("disk-filter", "internal", "Disk filter: internal, all, external")
+	thunderbolt := flag.Bool("thunderbolt", false, "Include Thunderbolt host controller UUIDs (macOS)")
+	model := flag.Bool("model", false, "Include the hardware model and board-id (macOS)")
+	tpm := flag.Bool("tpm", false, "Include the TPM Endorsement Key digest (Linux, Windows)")
+	gpu := flag.Bool("gpu", false, "Include graphics adapter identifiers")
+	memory := flag.Bool("memory", false, "Include memory module serials or total RAM")
 	all := flag.Bool("all", false, "Include all hardware identifiers")
 	vm := flag.Bool("vm", false, "Use VM-friendly mode (CPU + UUID only)")
 
 	// Output options
 	format := flag.Int("format", 64, "Output format length: 32, 64, 128, or 256 characters")
+	encodingFlag := flag.String("encoding", "hex", "Output encoding: hex, base32, base64url")
 	salt := flag.String("salt", "", "Custom salt for application-specific IDs")
 
 	// Actions
@@ -51,6 +59,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  machineid -cpu -uuid                          Generate ID from CPU + UUID\n")
 		fmt.Fprintf(os.Stderr, "  machineid -all -format 32                     All hardware, compact format\n")
+		fmt.Fprintf(os.Stderr, "  machineid -all -encoding base64url            All hardware, URL-safe base64\n")
 		fmt.Fprintf(os.Stderr, "  machineid -vm -salt \"my-app\"                   VM-friendly with salt\n")
 		fmt.Fprintf(os.Stderr, "  machineid -cpu -uuid -diagnostics             Show collected components\n")
 		fmt.Fprintf(os.Stderr, "  machineid -cpu -uuid -validate <id>           Validate an existing ID\n")
@@ -115,6 +124,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	encoding, err := parseEncoding(*encodingFlag)
+	if err != nil {
+		slog.Error("invalid encoding", "error", err)
+		flag.Usage()
+		os.Exit(1)
+	}
+
 	// Configure logger
 	if *debugFlag {
 		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
@@ -125,7 +141,7 @@ func main() {
 	}
 
 	// Build provider
-	provider := machineid.New().WithFormat(formatMode)
+	provider := machineid.New().WithFormat(formatMode).WithEncoding(encoding)
 
 	if *verbose || *debugFlag {
 		provider.WithLogger(slog.Default())
@@ -142,34 +158,76 @@ func main() {
 		os.Exit(1)
 	}
 
+	dFilter, err := parseDiskFilter(*diskFilterFlag)
+	if err != nil {
+		slog.Error("invalid disk-filter", "error", err)
+		flag.Usage()
+		os.Exit(1)
+	}
+	provider.WithDiskFilter(dFilter)
+
 	switch {
 	case *vm:
 		provider.VMFriendly()
 	case *all:
 		provider.WithCPU().WithMotherboard().WithSystemUUID().WithMAC(mFilter).WithDisk()
 	default:
-		if !*cpu && !*motherboard && !*uuid && !*mac && !*disk {
+		if !*cpu && !*motherboard && !*uuid && !*mac && !*disk && !*thunderbolt && !*model && !*tpm && !*gpu && !*memory {
 			// Default: CPU + Motherboard + System UUID
 			provider.WithCPU().WithMotherboard().WithSystemUUID()
 		} else {
+			var selected []string
 			if *cpu {
 				provider.WithCPU()
+				selected = append(selected, machineid.ComponentCPU)
 			}
 			if *motherboard {
 				provider.WithMotherboard()
+				selected = append(selected, machineid.ComponentMotherboard)
 			}
 			if *uuid {
 				provider.WithSystemUUID()
+				selected = append(selected, machineid.ComponentSystemUUID)
 			}
 			if *mac {
 				provider.WithMAC(mFilter)
+				selected = append(selected, machineid.ComponentMAC)
 			}
 			if *disk {
 				provider.WithDisk()
+				selected = append(selected, machineid.ComponentDisk)
+			}
+			if *thunderbolt {
+				provider.WithThunderbolt()
+				selected = append(selected, machineid.ComponentThunderbolt)
+			}
+			if *model {
+				provider.WithMacModel()
+				selected = append(selected, machineid.ComponentModel)
+			}
+			if *tpm {
+				provider.WithTPM()
+				selected = append(selected, machineid.ComponentTPM)
+			}
+			if *gpu {
+				provider.WithGPU()
+				selected = append(selected, machineid.ComponentGPU)
+			}
+			if *memory {
+				provider.WithMemory()
+				selected = append(selected, machineid.ComponentMemory)
+			}
+
+			for _, component := range unsupportedComponents(selected) {
+				slog.Warn("component is not supported on this platform and will not contribute to the ID", "component", component)
 			}
 		}
 	}
 
+	if *diagnostics {
+		provider.WithOSVersionProbe()
+	}
+
 	// Generate machine ID
 	ctx := context.Background()
 
@@ -221,6 +279,19 @@ func parseFormatMode(format int) (machineid.FormatMode, error) {
 	}
 }
 
+func parseEncoding(value string) (machineid.Encoding, error) {
+	switch strings.ToLower(value) {
+	case "hex":
+		return machineid.EncodingHex, nil
+	case "base32":
+		return machineid.EncodingBase32, nil
+	case "base64url":
+		return machineid.EncodingBase64URL, nil
+	default:
+		return 0, fmt.Errorf("unsupported encoding %q; valid values are hex, base32, base64url", value)
+	}
+}
+
 func parseMACFilter(value string) (machineid.MACFilter, error) {
 	switch strings.ToLower(value) {
 	case "physical":
@@ -229,11 +300,41 @@ func parseMACFilter(value string) (machineid.MACFilter, error) {
 		return machineid.MACFilterAll, nil
 	case "virtual":
 		return machineid.MACFilterVirtual, nil
+	case "wired":
+		return machineid.MACFilterWired, nil
+	default:
+		return 0, fmt.Errorf("unsupported mac-filter %q; valid values are physical, all, virtual, wired", value)
+	}
+}
+
+func parseDiskFilter(value string) (machineid.DiskFilter, error) {
+	switch strings.ToLower(value) {
+	case "internal":
+		return machineid.DiskFilterInternal, nil
+	case "all":
+		return machineid.DiskFilterAll, nil
+	case "external":
+		return machineid.DiskFilterExternal, nil
 	default:
-		return 0, fmt.Errorf("unsupported mac-filter %q; valid values are physical, all, virtual", value)
+		return 0, fmt.Errorf("unsupported disk-filter %q; valid values are internal, all, external", value)
 	}
 }
 
+// unsupportedComponents returns the selected components that have no
+// collector on the current platform.
+func unsupportedComponents(selected []string) []string {
+	supported := machineid.SupportedComponents()
+
+	var unsupported []string
+	for _, component := range selected {
+		if !slices.Contains(supported, component) {
+			unsupported = append(unsupported, component)
+		}
+	}
+
+	return unsupported
+}
+
 func handleValidate(ctx context.Context, provider *machineid.Provider, expectedID string, jsonOut bool) {
 	valid, err := provider.Validate(ctx, expectedID)
 	if err != nil {
@@ -268,13 +369,21 @@ func printDiagnostics(provider *machineid.Provider) {
 	}
 
 	fmt.Fprintln(os.Stderr, "\nDiagnostics:")
+	fmt.Fprintf(os.Stderr, "  Platform: %s/%s\n", diag.Platform, diag.Arch)
+	if diag.OSVersion != "" {
+		fmt.Fprintf(os.Stderr, "  OS version: %s\n", diag.OSVersion)
+	}
 	if len(diag.Collected) > 0 {
-		fmt.Fprintf(os.Stderr, "  Collected: %s\n", strings.Join(diag.Collected, ", "))
+		names := make([]string, len(diag.Collected))
+		for i, component := range diag.Collected {
+			names[i] = provider.DisplayName(component)
+		}
+		fmt.Fprintf(os.Stderr, "  Collected: %s\n", strings.Join(names, ", "))
 	}
 	if len(diag.Errors) > 0 {
 		fmt.Fprintln(os.Stderr, "  Errors:")
 		for component, err := range diag.Errors {
-			fmt.Fprintf(os.Stderr, "    %s: %v\n", component, err)
+			fmt.Fprintf(os.Stderr, "    %s: %v\n", provider.DisplayName(component), err)
 		}
 	}
 }
@@ -287,6 +396,12 @@ func formatDiagnostics(provider *machineid.Provider) map[string]any {
 
 	result := map[string]any{
 		"collected": diag.Collected,
+		"platform":  diag.Platform,
+		"arch":      diag.Arch,
+	}
+
+	if diag.OSVersion != "" {
+		result["os_version"] = diag.OSVersion
 	}
 
 	if len(diag.Errors) > 0 {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io"
 	"os"
+	"runtime"
 	"testing"
 
 	"github.com/slashdevops/machineid"
@@ -38,6 +39,66 @@ func TestParseFormatMode(t *testing.T) {
 	}
 }
 
+func TestParseEncoding(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    machineid.Encoding
+		wantErr bool
+	}{
+		{"hex", machineid.EncodingHex, false},
+		{"base32", machineid.EncodingBase32, false},
+		{"Base64URL", machineid.EncodingBase64URL, false},
+		{"base64", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseEncoding(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseEncoding(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseEncoding(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseDiskFilter(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    machineid.DiskFilter
+		wantErr bool
+	}{
+		{"internal", machineid.DiskFilterInternal, false},
+		{"All", machineid.DiskFilterAll, false},
+		{"external", machineid.DiskFilterExternal, false},
+		{"usb", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDiskFilter(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDiskFilter(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseDiskFilter(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestUnsupportedComponents(t *testing.T) {
+	if got := unsupportedComponents(machineid.SupportedComponents()); len(got) != 0 {
+		t.Errorf("unsupportedComponents(supported) = %v, want none", got)
+	}
+
+	got := unsupportedComponents([]string{machineid.ComponentCPU, "bogus"})
+	if len(got) != 1 || got[0] != "bogus" {
+		t.Errorf("unsupportedComponents() = %v, want [bogus]", got)
+	}
+}
+
 func TestFormatDiagnosticsNil(t *testing.T) {
 	provider := machineid.New()
 	// Before ID() call, Diagnostics() is nil
@@ -63,6 +124,10 @@ func TestFormatDiagnosticsWithData(t *testing.T) {
 	if _, ok := result["collected"]; !ok {
 		t.Error("Expected 'collected' key in diagnostics")
 	}
+
+	if result["platform"] != runtime.GOOS || result["arch"] != runtime.GOARCH {
+		t.Errorf("Expected platform %s/%s, got %v/%v", runtime.GOOS, runtime.GOARCH, result["platform"], result["arch"])
+	}
 }
 
 func TestPrintDiagnosticsNil(t *testing.T) {
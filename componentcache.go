@@ -0,0 +1,98 @@
+package machineid
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// WithComponentCache persists the last successfully collected value of each
+// component in dir. When a later collection of a component fails, for example
+// because of an intermittent ioreg or wmic failure, the cached value is used
+// instead and the component is listed in [DiagnosticInfo.Cached].
+//
+// This trades freshness for robustness: a cached value may be stale after a
+// hardware change that also broke its probe. The cache holds raw component
+// values such as serial numbers, so dir should only be readable by the
+// owning user; files are written with mode 0600. Cache entries hold the
+// values as collected, before Unicode normalization, disk serial
+// canonicalization, [Provider.WithComponentValueTransform] and set hashing,
+// which are applied to cached values as to fresh ones. Entries are keyed by
+// component and by the options that affect collection, so changing those
+// options never reuses a value collected under different settings.
+func (p *Provider) WithComponentCache(dir string) *Provider {
+	p.componentCacheDir = dir
+
+	return p
+}
+
+// componentCachePath returns the cache file for component under the current configuration.
+func (p *Provider) componentCachePath(component string) string {
+	key := fmt.Sprintf("%s|%s|%t|%t|%d|%s|%d|%t|%t", component, runtime.GOOS,
+		p.bestUUID, p.cleanCPUFormat, p.macCPUSource, p.macFilter, p.macMaxCount, p.nvmeDiskIDs, p.suspiciousSerials != nil)
+	if component == ComponentMAC && len(p.macExclude) > 0 {
+		key += "|mac-exclude:" + strings.Join(p.macExclude, ",")
+	}
+	if component == ComponentMAC && len(p.macAllow) > 0 {
+		key += "|mac-allow:" + strings.Join(p.macAllow, ",")
+	}
+	if component == ComponentDisk && p.diskFilter != DiskFilterInternal {
+		key += fmt.Sprintf("|disk-filter:%d", p.diskFilter)
+	}
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(p.componentCacheDir, component+"-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// storeCachedValues saves the values collected for component, before any
+// processing, if the component cache is enabled. Failures are logged and otherwise ignored.
+func (p *Provider) storeCachedValues(component string, values []string) {
+	if p.componentCacheDir == "" || len(values) == 0 || slices.Contains(values, "") {
+		return
+	}
+
+	data, err := json.Marshal(values)
+	if err == nil {
+		err = os.MkdirAll(p.componentCacheDir, 0o700)
+	}
+	if err == nil {
+		err = writeFileAtomic(p.componentCachePath(component), data)
+	}
+	if err != nil {
+		p.logDebug("failed to write component cache", "component", component, "error", err)
+	}
+}
+
+// loadCachedValues returns the cached values for component, recording the
+// fallback in diag. It reports false if the cache is disabled or holds no
+// usable entry.
+func (p *Provider) loadCachedValues(component string, diag *DiagnosticInfo) ([]string, bool) {
+	if p.componentCacheDir == "" {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(p.componentCachePath(component))
+	if err != nil {
+		return nil, false
+	}
+
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil || len(values) == 0 || slices.Contains(values, "") {
+		p.logDebug("ignoring unusable component cache entry", "component", component, "error", err)
+
+		return nil, false
+	}
+
+	p.logInfo("using cached component value after collection failure", "component", component)
+	if diag != nil {
+		diag.Cached = append(diag.Cached, component)
+	}
+
+	return values, true
+}
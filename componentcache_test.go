@@ -0,0 +1,124 @@
+package machineid
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestWithComponentCacheFallback tests that a failed component falls back to
+// the value cached by an earlier successful collection.
+func TestWithComponentCacheFallback(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	probeErr := errors.New("ioreg failed")
+
+	good := New().WithComponentCache(dir)
+	goodDiag := &DiagnosticInfo{Errors: make(map[string]error)}
+	good.appendIdentifier(ctx, nil, func(context.Context) (string, error) {
+		return "UUID-1234", nil
+	}, "uuid:", goodDiag, ComponentSystemUUID)
+	good.appendIdentifiers(ctx, nil, func(context.Context) ([]string, error) {
+		return []string{"disk-a", "disk-b"}, nil
+	}, "disk:", goodDiag, ComponentDisk)
+	if len(goodDiag.Cached) != 0 {
+		t.Errorf("Cached = %v after successful collection, want none", goodDiag.Cached)
+	}
+
+	flaky := New().WithComponentCache(dir)
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+	identifiers := flaky.appendIdentifier(ctx, nil, func(context.Context) (string, error) {
+		return "", probeErr
+	}, "uuid:", diag, ComponentSystemUUID)
+	identifiers = flaky.appendIdentifiers(ctx, identifiers, func(context.Context) ([]string, error) {
+		return nil, probeErr
+	}, "disk:", diag, ComponentDisk)
+
+	want := []string{"uuid:UUID-1234", "disk:disk-a", "disk:disk-b"}
+	if !slices.Equal(identifiers, want) {
+		t.Errorf("identifiers = %v, want %v", identifiers, want)
+	}
+	if len(diag.Errors) != 0 {
+		t.Errorf("Errors = %v, want none", diag.Errors)
+	}
+	if !slices.Equal(diag.Cached, []string{ComponentSystemUUID, ComponentDisk}) {
+		t.Errorf("Cached = %v, want [%s %s]", diag.Cached, ComponentSystemUUID, ComponentDisk)
+	}
+}
+
+// TestWithComponentCacheMiss tests that failures are reported when no value
+// was cached, or when it was cached under a different configuration.
+func TestWithComponentCacheMiss(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	New().WithComponentCache(dir).appendIdentifier(ctx, nil, func(context.Context) (string, error) {
+		return "Apple M1 Pro:", nil
+	}, "cpu:", nil, ComponentCPU)
+
+	tests := []struct {
+		name      string
+		p         *Provider
+		component string
+	}{
+		{"empty cache", New().WithComponentCache(t.TempDir()), ComponentCPU},
+		{"other component", New().WithComponentCache(dir), ComponentMotherboard},
+		{"other configuration", New().WithComponentCache(dir).WithCleanCPUFormat(), ComponentCPU},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diag := &DiagnosticInfo{Errors: make(map[string]error)}
+			identifiers := tt.p.appendIdentifier(ctx, nil, func(context.Context) (string, error) {
+				return "", errors.New("probe failed")
+			}, "x:", diag, tt.component)
+
+			if len(identifiers) != 0 {
+				t.Errorf("identifiers = %v, want none", identifiers)
+			}
+			if diag.Errors[tt.component] == nil {
+				t.Errorf("Errors[%s] = nil, want an error", tt.component)
+			}
+			if len(diag.Cached) != 0 {
+				t.Errorf("Cached = %v, want none", diag.Cached)
+			}
+		})
+	}
+}
+
+// TestWithComponentCacheTransform tests that cached values are stored before
+// processing, so a changed value transform applies to a cached fallback.
+func TestWithComponentCacheTransform(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	New().WithComponentCache(dir).WithComponentValueTransform(ComponentSystemUUID, strings.ToLower).
+		appendIdentifier(ctx, nil, func(context.Context) (string, error) {
+			return "UUID-1234", nil
+		}, "uuid:", nil, ComponentSystemUUID)
+
+	for _, tt := range []struct {
+		name      string
+		transform func(string) string
+		want      string
+	}{
+		{"none", nil, "uuid:UUID-1234"},
+		{"changed", func(value string) string { return "x-" + value }, "uuid:x-UUID-1234"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New().WithComponentCache(dir)
+			if tt.transform != nil {
+				p.WithComponentValueTransform(ComponentSystemUUID, tt.transform)
+			}
+			identifiers := p.appendIdentifier(ctx, nil, func(context.Context) (string, error) {
+				return "", errors.New("ioreg failed")
+			}, "uuid:", nil, ComponentSystemUUID)
+
+			if !slices.Equal(identifiers, []string{tt.want}) {
+				t.Errorf("identifiers = %v, want [%s]", identifiers, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package machineid
+
+import (
+	"context"
+	"maps"
+)
+
+// defaultComponentWeights holds the default uniqueness weight of each component.
+// The five portable components sum to 1.
+var defaultComponentWeights = map[string]float64{
+	ComponentCPU:         0.05, // shared by every machine of the same model
+	ComponentMotherboard: 0.20,
+	ComponentSystemUUID:  0.35,
+	ComponentMAC:         0.15, // may be virtual or change with network hardware
+	ComponentDisk:        0.25,
+	ComponentMachineID:   0.20, // Linux and FreeBSD, collected alongside the system UUID
+	ComponentThunderbolt: 0.20, // macOS-only host controller UUID
+	ComponentModel:       0.05, // shared by every Mac of the same model
+	ComponentTPM:         0.30, // Endorsement Key, unique per TPM
+	ComponentGPU:         0.05, // shared by every card of the same model
+	ComponentMemory:      0.10, // DIMM serials where reported, else total RAM
+	ComponentOSMachineID: 0.10, // OS-generated, rotates on reinstall
+}
+
+// DefaultComponentWeights returns a copy of the default weight table used by
+// [Provider.IDWithConfidence]. Higher weights mark components that are more
+// likely to be unique to one machine.
+func DefaultComponentWeights() map[string]float64 {
+	return maps.Clone(defaultComponentWeights)
+}
+
+// WithComponentWeights overrides the weights used by [Provider.IDWithConfidence].
+// Components missing from weights keep their default weight; a weight of 0
+// excludes a component from the confidence.
+func (p *Provider) WithComponentWeights(weights map[string]float64) *Provider {
+	if p.componentWeights == nil {
+		p.componentWeights = DefaultComponentWeights()
+	}
+	maps.Copy(p.componentWeights, weights)
+
+	return p
+}
+
+// IDWithConfidence returns the machine ID together with a confidence in [0, 1]
+// that it is a strong, unique fingerprint. The confidence is the sum of the
+// weights of the components that were actually collected, capped at 1:
+//
+//	confidence = min(1, Σ weight(c) for c in Diagnostics().Collected)
+//
+// With the default weights, an ID built only from the CPU scores 0.05, while
+// one built from UUID, disk and MAC scores 0.75. Anti-fraud systems can treat
+// low-confidence IDs cautiously. Weights are set with [Provider.WithComponentWeights].
+func (p *Provider) IDWithConfidence(ctx context.Context) (string, float64, error) {
+	id, err := p.ID(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return id, p.confidence(p.diagnostics), nil
+}
+
+// confidence computes the weighted confidence of the components collected in diag.
+func (p *Provider) confidence(diag *DiagnosticInfo) float64 {
+	if diag == nil {
+		return 0
+	}
+
+	weights := p.componentWeights
+	if weights == nil {
+		weights = defaultComponentWeights
+	}
+
+	var total float64
+	for _, component := range diag.Collected {
+		total += weights[component]
+	}
+
+	return min(total, 1)
+}
@@ -0,0 +1,68 @@
+package machineid
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+// TestConfidence tests the weighted confidence for different sets of collected components.
+func TestConfidence(t *testing.T) {
+	tests := []struct {
+		name      string
+		collected []string
+		want      float64
+	}{
+		{"nothing", nil, 0},
+		{"cpu only", []string{ComponentCPU}, 0.05},
+		{"uuid disk mac", []string{ComponentSystemUUID, ComponentMAC, ComponentDisk}, 0.75},
+		{"everything", []string{ComponentCPU, ComponentMotherboard, ComponentSystemUUID, ComponentMAC, ComponentDisk, ComponentMachineID}, 1},
+		{"unknown component", []string{"chassis"}, 0},
+	}
+
+	p := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.confidence(&DiagnosticInfo{Collected: tt.collected})
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("confidence(%v) = %v, want %v", tt.collected, got, tt.want)
+			}
+		})
+	}
+
+	if got := p.confidence(nil); got != 0 {
+		t.Errorf("confidence(nil) = %v, want 0", got)
+	}
+}
+
+// TestWithComponentWeights tests that overridden weights replace the defaults
+// for the given components only.
+func TestWithComponentWeights(t *testing.T) {
+	p := New().WithComponentWeights(map[string]float64{ComponentCPU: 0.5})
+	diag := &DiagnosticInfo{Collected: []string{ComponentCPU, ComponentSystemUUID}}
+
+	if got := p.confidence(diag); math.Abs(got-0.85) > 1e-9 {
+		t.Errorf("confidence() = %v, want 0.85", got)
+	}
+	if DefaultComponentWeights()[ComponentCPU] != 0.05 {
+		t.Error("WithComponentWeights() modified the default weights")
+	}
+}
+
+// TestIDWithConfidence tests that the confidence matches the collected components.
+func TestIDWithConfidence(t *testing.T) {
+	p := New().WithCPU().WithSystemUUID()
+
+	id, confidence, err := p.IDWithConfidence(context.Background())
+	if err != nil {
+		t.Fatalf("IDWithConfidence() error = %v", err)
+	}
+
+	wantID, _ := p.ID(context.Background())
+	if id != wantID {
+		t.Errorf("IDWithConfidence() id = %q, want %q", id, wantID)
+	}
+	if want := p.confidence(p.Diagnostics()); confidence != want || confidence <= 0 {
+		t.Errorf("IDWithConfidence() confidence = %v, want %v", confidence, want)
+	}
+}
@@ -0,0 +1,73 @@
+package machineid
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// minHexSerialLength is the shortest hex string treated as a hex-encoded
+// serial by [canonicalDiskSerial]. Real serials are at least 8 characters.
+const minHexSerialLength = 16
+
+// canonicalDiskSerial reduces a disk serial to its bare alphanumeric form, so
+// that the same physical disk yields the same value on every OS:
+//
+//   - hex-encoded serials (as reported for ATA disks by wmic before Windows 8)
+//     are decoded, provided they decode to printable ASCII, and their bytes
+//     are swapped back in pairs;
+//   - padding, separators and trailing dots are removed, keeping only letters
+//     and digits;
+//   - letters are uppercased.
+func canonicalDiskSerial(serial string) string {
+	serial = strings.TrimSpace(serial)
+	if decoded, ok := decodeHexSerial(serial); ok {
+		serial = decoded
+	}
+
+	var b strings.Builder
+	b.Grow(len(serial))
+	for _, r := range serial {
+		switch {
+		case r >= 'a' && r <= 'z':
+			b.WriteRune(r - 'a' + 'A')
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// decodeHexSerial decodes a hex-encoded serial. It reports false unless
+// serial is long enough and decodes to printable ASCII containing at least
+// one letter or digit, so genuine serials made only of hex digits are kept.
+//
+// The hex form is the raw ATA IDENTIFY serial field, which stores the serial
+// as 16-bit little-endian words, so every pair of decoded bytes is swapped to
+// restore the order that Linux and newer Windows versions report.
+func decodeHexSerial(serial string) (string, bool) {
+	if len(serial) < minHexSerialLength || len(serial)%4 != 0 {
+		return "", false
+	}
+
+	decoded, err := hex.DecodeString(serial)
+	if err != nil {
+		return "", false
+	}
+
+	alnum := false
+	for _, c := range decoded {
+		if c < 0x20 || c > 0x7e {
+			return "", false
+		}
+		if c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' {
+			alnum = true
+		}
+	}
+
+	for i := 0; i+1 < len(decoded); i += 2 {
+		decoded[i], decoded[i+1] = decoded[i+1], decoded[i]
+	}
+
+	return string(decoded), alnum
+}
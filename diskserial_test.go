@@ -0,0 +1,56 @@
+package machineid
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// TestCanonicalDiskSerial tests that per-OS forms of the same serial
+// canonicalize to one value.
+func TestCanonicalDiskSerial(t *testing.T) {
+	tests := []struct {
+		name   string
+		serial string
+		want   string
+	}{
+		{"linux sysfs", "WD-WCC4N1234567\n", "WDWCC4N1234567"},
+		{"linux lsblk lowercase", "wd-wcc4n1234567", "WDWCC4N1234567"},
+		{"windows wmic padded", "     WD-WCC4N1234567", "WDWCC4N1234567"},
+		{"windows 7 wmic hex", "2020202057202d4443573443314e333235343736", "WDWCC4N1234567"},
+		{"linux lsblk ata", "S3Z9NB0K123456A", "S3Z9NB0K123456A"},
+		{"windows 7 wmic hex ata", "2020202053205a334e393042314b333235344136", "S3Z9NB0K123456A"},
+		{"windows 10 wmic ata", "     S3Z9NB0K123456A", "S3Z9NB0K123456A"},
+		{"windows nvme separators", "S4EV_NF0M_1234_56.", "S4EVNF0M123456"},
+		{"linux nvme", "S4EVNF0M123456", "S4EVNF0M123456"},
+		{"hex-only genuine serial", "50026B7782A1B2C3", "50026B7782A1B2C3"},
+		{"short hex serial", "4A4B4C", "4A4B4C"},
+		{"empty", "  ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canonicalDiskSerial(tt.serial); got != tt.want {
+				t.Errorf("canonicalDiskSerial(%q) = %q, want %q", tt.serial, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWithCanonicalDiskSerials tests that only disk values are canonicalized.
+func TestWithCanonicalDiskSerials(t *testing.T) {
+	p := New().WithCanonicalDiskSerials()
+	ctx := context.Background()
+
+	identifiers := p.appendIdentifiers(ctx, nil, func(context.Context) ([]string, error) {
+		return []string{"  wd-wcc4n1234567", "S4EV_NF0M_1234_56."}, nil
+	}, "disk:", nil, ComponentDisk)
+	identifiers = p.appendIdentifier(ctx, identifiers, func(context.Context) (string, error) {
+		return "Board-Serial 01", nil
+	}, "mb:", nil, ComponentMotherboard)
+
+	want := []string{"disk:S4EVNF0M123456", "disk:WDWCC4N1234567", "mb:Board-Serial 01"}
+	if !slices.Equal(identifiers, want) {
+		t.Errorf("identifiers = %v, want %v", identifiers, want)
+	}
+}
@@ -30,10 +30,65 @@
 //   - [Provider.WithMotherboard] — motherboard / baseboard serial number
 //   - [Provider.WithSystemUUID] — BIOS / UEFI system UUID
 //   - [Provider.WithMAC] — MAC addresses of network interfaces (filterable)
-//   - [Provider.WithDisk] — serial numbers of internal disks
-//
-// Or use [Provider.VMFriendly] to select a minimal, virtual-machine-safe
-// subset (CPU + System UUID).
+//   - [Provider.WithDisk] — serial numbers of internal disks (filterable)
+//   - [Provider.WithThunderbolt] — Thunderbolt host controller UUIDs (macOS only)
+//   - [Provider.WithMacModel] — hardware model and board-id (macOS only)
+//   - [Provider.WithTPM] — TPM Endorsement Key digest (Linux and Windows)
+//   - [Provider.WithGPU] — graphics adapter PCI IDs or models (Linux, macOS and Windows)
+//   - [Provider.WithMemory] — memory module serials or total RAM (Linux, macOS and Windows)
+//
+// Or use [Provider.WithAll] to enable every component, including those added
+// in later releases, or [Provider.VMFriendly] to select a minimal,
+// virtual-machine-safe subset (CPU + System UUID). [SupportedComponents] lists the components that
+// have a collector on the current platform.
+//
+// [Provider.WithFastMode] skips the components that require slow subprocesses
+// (disk everywhere, plus motherboard on macOS and Windows and the TPM on Linux
+// and Windows) for high-frequency callers that can accept a slightly less
+// unique ID.
+// [Provider.UnprivilegedComponents] reports which enabled components can be
+// collected without elevated privileges, such as the root-only DMI files on
+// Linux, and [Provider.WithUnprivilegedOnly] skips the others.
+// [Provider.WithMaxTotalDuration] caps total collection time independently of
+// the caller's context, whose cancellation aborts collection before the next
+// component with the context's error. Each component is also bounded by its own timeout,
+// short for file sources and generous for tools such as system_profiler
+// ([DefaultComponentTimeouts]), replaceable with a single bound via
+// [Provider.WithComponentTimeout] and overridable per component with
+// [Provider.WithComponentTimeouts].
+// [Provider.WithTimeout] replaces the 5 second bound on each system command.
+// [Provider.WithParallelCollection] runs up to four component probes at once
+// without changing the ID.
+//
+// [Provider.WithBestUUID] replaces the UUID sources with a single per-platform
+// choice: the DMI product_uuid or systemd machine-id on Linux, IOPlatformUUID on
+// macOS, and the SMBIOS UUID or registry MachineGuid on Windows. All-zero and
+// all-F firmware UUIDs are skipped; the chosen source is reported in
+// [DiagnosticInfo].UUIDSource. On Android, where DMI is not exposed to apps, the
+// system UUID falls back to the device serial from getprop ro.serialno or
+// ro.boot.serialno; unprivileged apps usually read these as empty since
+// Android 8. [Provider.WithoutMachineID] drops the Linux
+// machine-id and FreeBSD hostid, which container images often share.
+//
+// [Provider.WithFallbackChain] substitutes other components for one that
+// fails, for example a motherboard serial for a missing system UUID. The
+// alternates are collected only when needed and at most one of them
+// contributes to the ID.
+//
+// Rare OEM fields contain non-ASCII characters that tools may report in
+// different Unicode normal forms. [Provider.WithUnicodeNormalization] composes
+// such values to NFC before hashing so the same physical value always produces
+// the same ID.
+//
+// [Provider.WithCanonicalDiskSerials] reduces disk serials to their bare
+// uppercase alphanumeric form, decoding hex-encoded serials, so dual-boot
+// machines report the same disk value on every OS that exposes the serial.
+// On Linux, [Provider.WithNVMeDiskIDs] prefers the NGUID or EUI-64 that NVMe
+// drives report through nvme-cli over their vendor serial.
+//
+// [Provider.WithComponentValueTransform] rewrites the values of one component
+// before hashing, for example to reproduce the normalization of a previous
+// fingerprinting tool when migrating existing IDs.
 //
 // # MAC Address Filtering
 //
@@ -43,6 +98,32 @@
 //   - [MACFilterPhysical] — only physical interfaces (default)
 //   - [MACFilterAll] — all non-loopback, up interfaces (physical + virtual)
 //   - [MACFilterVirtual] — only virtual interfaces (VPN, bridge, container)
+//   - [MACFilterWired] — only physical interfaces that are not wireless
+//
+// [MACMaxCount] caps the MAC contribution to the n lexicographically smallest
+// addresses remaining after filtering, keeping IDs stable on hosts with many
+// ephemeral interfaces:
+//
+//	provider.WithMAC(machineid.MACFilterAll, machineid.MACMaxCount(4))
+//
+// [Provider.WithMACExclude] drops addresses in hypervisor OUI ranges, such as
+// VMware's 00:50:56, after filtering and before the cap.
+// [Provider.WithMACAllow] restricts collection to the named interfaces, such
+// as the onboard "eno1", before filtering.
+//
+// [Provider.WithSetHashing] makes MAC or disk contribute one set hash of their
+// sorted values instead of one entry per value.
+//
+// [Provider.WithOptionalComponents] lets a component such as MAC under
+// [MACFilterVirtual] be legitimately empty: it is then listed in
+// [DiagnosticInfo].Absent instead of [DiagnosticInfo].Errors.
+//
+// Interfaces are classified by name, except on Windows, where the adapter
+// properties reported by Get-NetAdapter are used when PowerShell is available.
+// Wireless interfaces are detected through sysfs on Linux and networksetup on
+// macOS, and by name elsewhere.
+// [Provider.NetworkInterfaces] reports each interface's classification and
+// whether its address is included, for auditing.
 //
 // Examples:
 //
@@ -64,7 +145,19 @@
 //   - [Format128] — 128 hex characters (512 bits, double SHA-256)
 //   - [Format256] — 256 hex characters (1024 bits, quadruple SHA-256)
 //
-// All formats produce pure hexadecimal strings without dashes.
+// All formats produce pure hexadecimal strings without dashes by default.
+// [Provider.WithEncoding] renders the same digest bytes as shorter, URL-safe
+// [EncodingBase32] or [EncodingBase64URL] text instead; a [Format256] ID then
+// takes 205 or 171 characters. Output is lowercase by default;
+// [Provider.WithUppercase] switches hex and base32 to uppercase for
+// systems that store and match IDs in uppercase. [Provider.IDBytes] returns
+// the underlying digest bytes for callers that feed the ID into an HMAC or KDF.
+// [Provider.IDPair] returns a [Format32] display ID and a [Format64] storage ID
+// from one collection; the short ID is always the prefix of the long one.
+// [Provider.UUID] returns the ID as an RFC 4122 version-5 UUID for systems
+// that expect one.
+// [Provider.DeriveID] derives related but distinct sub-IDs, one per label,
+// with HKDF-Expand from the cached base ID instead of re-probing hardware.
 //
 // # Salt
 //
@@ -77,6 +170,21 @@
 //		WithSalt("my-app-v1").
 //		ID(ctx)
 //
+// [Provider.WithTimeWindow] also mixes in the start of the current time window,
+// producing IDs that rotate every window. This deliberately breaks stability
+// across reboots and is intended only for ephemeral device tokens.
+//
+// [Provider.WithHMAC] keys the hash with a secret using HMAC-SHA256 instead of
+// only prepending the salt; the salt is still mixed into the message.
+// [Provider.WithHasher] replaces SHA-256 with another hash function, such as
+// SHA-512; IDs keep the configured format length.
+//
+// [Provider.WithPersonalization] prepends a fixed domain tag to the hash input
+// so that IDs never collide with digests other tools compute over similar
+// values. The salt separates applications; the personalization identifies the
+// ID scheme itself. Neither is secret. [Provider.WithConfigBinding] folds a
+// digest of the configuration into the ID, so any configuration change rotates it.
+//
 // # Validation
 //
 // [Provider.Validate] regenerates the ID and compares it to a previously
@@ -84,6 +192,10 @@
 //
 //	valid, err := provider.Validate(ctx, storedID)
 //
+// [Provider.WithStrictValidationInput] makes Validate reject input that cannot
+// be an ID of the configured format with [ErrMalformedID]. Storing [Version]
+// alongside an ID records which library version generated it.
+//
 // # Diagnostics
 //
 // After calling [Provider.ID], call [Provider.Diagnostics] to inspect which
@@ -93,6 +205,37 @@
 //	fmt.Println("Collected:", diag.Collected)
 //	fmt.Println("Errors:", diag.Errors)
 //
+// Diagnostics also record the platform and architecture, and, with
+// [Provider.WithOSVersionProbe], a best-effort OS version.
+//
+// [Provider.Generate] returns the ID with its format, the contributing
+// components and a copy of the diagnostics in one JSON-serializable [Result].
+//
+// [Provider.Fingerprint] returns the sorted identifier string that was hashed,
+// with each raw value redacted to a short digest, for comparing the inputs of
+// two IDs without exposing serial numbers.
+//
+// [Provider.WithComponentCache] persists each component's last good value on
+// disk and falls back to it when a probe fails; such components are listed in
+// diag.Cached. Cached values may be stale and contain raw serial numbers.
+// [Provider.WithPersistentCache] stores the whole identifier set in a file and
+// uses it when a collection yields no identifiers at all. Failing that,
+// [Provider.WithFallbackToMachineID] uses the OS machine identifier, recorded
+// as [ComponentOSMachineID].
+//
+// diag.Duplicates lists components whose value merely repeats another
+// component's value and so adds no entropy. [Provider.WithDeduplicateComponents]
+// also excludes such values from the hash.
+//
+// [Provider.IDWithConfidence] also returns a confidence in [0, 1]: the sum of
+// the weights of the collected components, capped at 1. The default weights
+// ([DefaultComponentWeights]) can be overridden with [Provider.WithComponentWeights].
+//
+// [Provider.WriteFingerprintBundle] persists the same information, plus
+// timings and redacted component values, as a JSON file for support workflows.
+// For drift detection, [Provider.Snapshot] captures raw component values and
+// [Snapshot.Diff] reports which components changed between two snapshots.
+//
 // # Logging
 //
 // [Provider.WithLogger] accepts a [*log/slog.Logger] for optional observability.
@@ -107,11 +250,36 @@
 //		WithLogger(logger).
 //		ID(ctx)
 //
+// [Provider.WithLazyLogger] defers obtaining the logger until [Provider.ID]
+// runs, for applications that build their logger after configuring the provider.
+// [Provider.WithDefaultLogger] logs to [slog.Default], re-resolved on every
+// call so that later [slog.SetDefault] calls are honored.
+//
 // Log levels:
 //   - Info: component collected, fallback triggered, ID generation lifecycle
 //   - Warn: component failed or returned empty value
 //   - Debug: command execution details, raw hardware values, timing
 //
+// [Provider.WithRedactor] masks hardware values in debug logs and fingerprint
+// bundles with a custom function, for example keeping only the last four
+// characters of a serial; the hash always uses the raw values.
+//
+// For machine-readable telemetry, [Provider.WithEventSink] receives one
+// compact JSON document per successful generation, independent of the
+// logger's handler format.
+//
+// For performance tuning, [Provider.WithProfile] records a timeline of
+// component and command spans, retrieved with [Provider.Profile].
+//
+// For progress reporting, [Provider.IDStream] sends a [CollectionEvent] as each
+// component starts and finishes, followed by the final [Result]:
+//
+//	events, results := provider.IDStream(ctx)
+//	for event := range events {
+//		fmt.Println(event.Component, event.Kind)
+//	}
+//	result := <-results
+//
 // # Errors
 //
 // The package provides sentinel errors for programmatic error handling:
@@ -122,6 +290,11 @@
 //   - [ErrNotFound] — a value was not found in command output or system files
 //   - [ErrOEMPlaceholder] — a value matches a BIOS/UEFI OEM placeholder
 //   - [ErrAllMethodsFailed] — all collection methods for a component were exhausted
+//   - [ErrComponentTimeout] — a component exceeded its own collection deadline
+//   - [ErrLowEntropy] — a serial was rejected as templated or predictable
+//   - [ErrMalformedID] — strict Validate input has the wrong length or characters
+//   - [ErrInvalidFormat] — WithFormat was given an undefined FormatMode
+//   - [ErrPartialCollection] — with [Provider.WithStrict], some components failed
 //
 // Typed errors provide structured context for [errors.As]:
 //
@@ -138,11 +311,18 @@
 //		fmt.Println("cause:", compErr.Err)
 //	}
 //
+// To react to a failure the moment it happens rather than after [Provider.ID]
+// returns, register [Provider.WithErrorCallback]; it receives the same
+// [ComponentError]. The callback runs under the provider lock and must not
+// block or call back into the provider.
+//
 // # Thread Safety
 //
 // A [Provider] is safe for concurrent use after configuration is complete.
 // The first successful call to [Provider.ID] freezes the configuration and
 // caches the result; subsequent calls return the cached value.
+// [Provider.Clone] copies the configuration without the cache, so a base
+// provider can be branched, for example with different salts.
 //
 // # Testing
 //
@@ -153,11 +333,19 @@
 //		WithExecutor(myMock).
 //		WithCPU()
 //
+// [Provider.WithExecutorFunc] accepts a closure through the [ExecutorFunc]
+// adapter, for doubles that are not worth a type of their own.
+//
+// Custom executors that need application-provided configuration (an endpoint,
+// a device path) can read it from the context passed to Execute; register it
+// with [Provider.WithSourceConfig] using an unexported key type.
+// [Provider.WithClock] injects a [Clock] that measures command durations.
+//
 // # Platform Support
 //
-// Supported operating systems: macOS (darwin), Linux, and Windows. Each
-// platform uses native tools (system_profiler / ioreg, /sys / lsblk, wmic /
-// PowerShell) to collect hardware data.
+// Supported operating systems: macOS (darwin), Linux, Windows, and FreeBSD.
+// Each platform uses native tools (system_profiler / ioreg, /sys / lsblk, wmic /
+// PowerShell, kenv / sysctl / camcontrol) to collect hardware data.
 //
 // # Installation
 //
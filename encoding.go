@@ -0,0 +1,126 @@
+package machineid
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// Encoding defines the text encoding of the machine ID.
+type Encoding int
+
+const (
+	// EncodingHex encodes the ID as hexadecimal digits (default).
+	EncodingHex Encoding = iota
+	// EncodingBase32 encodes the ID as unpadded, lowercase RFC 4648 base32.
+	EncodingBase32
+	// EncodingBase64URL encodes the ID as unpadded RFC 4648 base64url.
+	EncodingBase64URL
+)
+
+// base32NoPadding is the RFC 4648 base32 alphabet without padding.
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// WithEncoding sets the text encoding of the ID. The [FormatMode] selects the
+// number of digest bytes, as returned by [Provider.IDBytes], and the encoding
+// renders them, so shorter encodings carry the same bits in fewer characters:
+//
+//	Format     bytes  hex  base32  base64url
+//	Format32      16   32      26         22
+//	Format64      32   64      52         43
+//	Format128     64  128     103         86
+//	Format256    128  256     205        171
+//
+// All encodings are URL-safe and unpadded. [Provider.WithUppercase] applies to
+// hex and base32, but not to base64url, which is case-sensitive. IDs in
+// different encodings are different strings, so stored IDs must be compared in
+// the encoding they were generated with. [Provider.DeriveID] always returns hex.
+func (p *Provider) WithEncoding(encoding Encoding) *Provider {
+	p.encoding = encoding
+
+	return p
+}
+
+// presentID encodes hexID, a [formatHash] result, with the configured encoding
+// and case.
+func (p *Provider) presentID(hexID string) string {
+	id := encodeID(hexID, p.encoding)
+	if p.uppercase && p.encoding != EncodingBase64URL {
+		id = strings.ToUpper(id)
+	}
+
+	return id
+}
+
+// encodeID re-encodes hexID with encoding. Unknown encodings, and strings that
+// are not hex, are returned unchanged.
+func encodeID(hexID string, encoding Encoding) string {
+	switch encoding {
+	case EncodingBase32, EncodingBase64URL:
+	default:
+		return hexID
+	}
+
+	digest, err := hex.DecodeString(hexID)
+	if err != nil {
+		return hexID
+	}
+
+	if encoding == EncodingBase32 {
+		return strings.ToLower(base32NoPadding.EncodeToString(digest))
+	}
+
+	return base64.RawURLEncoding.EncodeToString(digest)
+}
+
+// decodeID returns the digest bytes of id, an ID in encoding of either case.
+func decodeID(id string, encoding Encoding) ([]byte, error) {
+	switch encoding {
+	case EncodingBase32:
+		return base32NoPadding.DecodeString(strings.ToUpper(id))
+	case EncodingBase64URL:
+		return base64.RawURLEncoding.DecodeString(id)
+	default:
+		return hex.DecodeString(strings.ToLower(id))
+	}
+}
+
+// encodedLength returns the number of characters of an ID of mode in encoding.
+func encodedLength(mode FormatMode, encoding Encoding) int {
+	n := formatLength(mode) / 2
+
+	switch encoding {
+	case EncodingBase32:
+		return base32NoPadding.EncodedLen(n)
+	case EncodingBase64URL:
+		return base64.RawURLEncoding.EncodedLen(n)
+	default:
+		return 2 * n
+	}
+}
+
+// isEncodingChar reports whether c belongs to the alphabet of encoding in the
+// given case. base64url is case-sensitive and ignores upper.
+func isEncodingChar(c rune, encoding Encoding, upper bool) bool {
+	isDigit := c >= '0' && c <= '9'
+	isLower := c >= 'a' && c <= 'z'
+	isUpper := c >= 'A' && c <= 'Z'
+
+	switch encoding {
+	case EncodingBase32:
+		if upper {
+			return isUpper || c >= '2' && c <= '7'
+		}
+
+		return isLower || c >= '2' && c <= '7'
+	case EncodingBase64URL:
+		return isDigit || isLower || isUpper || c == '-' || c == '_'
+	default:
+		if upper {
+			return isDigit || c >= 'A' && c <= 'F'
+		}
+
+		return isDigit || c >= 'a' && c <= 'f'
+	}
+}
@@ -0,0 +1,95 @@
+package machineid
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+// TestWithEncoding tests that every encoding of every format is URL-safe,
+// deterministic, of the documented length, and decodes to the digest bytes.
+func TestWithEncoding(t *testing.T) {
+	ctx := context.Background()
+	lengths := map[FormatMode][3]int{
+		Format32:  {32, 26, 22},
+		Format64:  {64, 52, 43},
+		Format128: {128, 103, 86},
+		Format256: {256, 205, 171},
+	}
+	newProvider := func(mode FormatMode, encoding Encoding) *Provider {
+		p := New().WithMAC().WithFormat(mode).WithEncoding(encoding)
+		p.listInterfaces = physicalOnlyInterfaces
+
+		return p
+	}
+
+	for mode, want := range lengths {
+		digest, err := newProvider(mode, EncodingHex).IDBytes(ctx)
+		if err != nil {
+			t.Fatalf("IDBytes() error = %v", err)
+		}
+
+		for encoding, wantLen := range want {
+			p := newProvider(mode, Encoding(encoding))
+			id, err := p.ID(ctx)
+			if err != nil {
+				t.Fatalf("ID() error = %v", err)
+			}
+
+			if len(id) != wantLen {
+				t.Errorf("format %d encoding %d: len(ID) = %d, want %d", mode, encoding, len(id), wantLen)
+			}
+			if url.PathEscape(id) != id || url.QueryEscape(id) != id {
+				t.Errorf("format %d encoding %d: ID %q is not URL-safe", mode, encoding, id)
+			}
+			if again, _ := newProvider(mode, Encoding(encoding)).ID(ctx); again != id {
+				t.Errorf("format %d encoding %d: ID = %q, then %q", mode, encoding, id, again)
+			}
+			if got, err := p.IDBytes(ctx); err != nil || !bytes.Equal(got, digest) {
+				t.Errorf("format %d encoding %d: IDBytes() = %x, %v, want %x", mode, encoding, got, err, digest)
+			}
+			if err := p.checkIDFormat(id); err != nil {
+				t.Errorf("format %d encoding %d: checkIDFormat(%q) error = %v", mode, encoding, id, err)
+			}
+		}
+	}
+}
+
+// TestWithEncodingCase tests that uppercase applies to base32 but not to the
+// case-sensitive base64url, and that strict validation checks the alphabet.
+func TestWithEncodingCase(t *testing.T) {
+	ctx := context.Background()
+	newProvider := func(encoding Encoding) *Provider {
+		p := New().WithMAC().WithEncoding(encoding).WithUppercase().WithStrictValidationInput()
+		p.listInterfaces = physicalOnlyInterfaces
+
+		return p
+	}
+
+	base32ID, err := newProvider(EncodingBase32).ID(ctx)
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	lower := New().WithMAC().WithEncoding(EncodingBase32)
+	lower.listInterfaces = physicalOnlyInterfaces
+	lowerID, _ := lower.ID(ctx)
+	if base32ID == lowerID || !bytes.Equal([]byte(base32ID), bytes.ToUpper([]byte(lowerID))) {
+		t.Errorf("uppercase base32 ID = %q, want %q uppercased", base32ID, lowerID)
+	}
+
+	base64ID, _ := newProvider(EncodingBase64URL).ID(ctx)
+	plain := New().WithMAC().WithEncoding(EncodingBase64URL)
+	plain.listInterfaces = physicalOnlyInterfaces
+	if plainID, _ := plain.ID(ctx); base64ID != plainID {
+		t.Errorf("uppercase base64url ID = %q, want unchanged %q", base64ID, plainID)
+	}
+
+	if _, err := newProvider(EncodingBase32).Validate(ctx, lowerID); !errors.Is(err, ErrMalformedID) {
+		t.Errorf("Validate(lowercase base32) error = %v, want ErrMalformedID", err)
+	}
+	if _, err := newProvider(EncodingBase64URL).Validate(ctx, base64ID[:42]+"+"); !errors.Is(err, ErrMalformedID) {
+		t.Errorf("Validate(standard base64) error = %v, want ErrMalformedID", err)
+	}
+}
@@ -27,9 +27,45 @@ var (
 	// BIOS/UEFI OEM placeholder such as "To be filled by O.E.M.".
 	ErrOEMPlaceholder = errors.New("value is OEM placeholder")
 
+	// ErrPermissionDenied is recorded in [DiagnosticInfo.Errors] when a
+	// hardware value exists but the process lacks the privileges to read it,
+	// such as the root-only DMI product_uuid on Linux.
+	ErrPermissionDenied = errors.New("permission denied reading value")
+
 	// ErrAllMethodsFailed is returned when all collection methods for a
 	// hardware component have been exhausted without success.
 	ErrAllMethodsFailed = errors.New("all collection methods failed")
+
+	// ErrComponentTimeout is recorded in [DiagnosticInfo.Errors] when a
+	// component's own collection deadline expired before it produced a value,
+	// as opposed to the component being absent or the caller's context ending.
+	// It is wrapped in a [ComponentError], so the same error matches both
+	// errors.Is(err, ErrComponentTimeout) and errors.As(err, &componentErr).
+	ErrComponentTimeout = errors.New("component collection timed out")
+
+	// ErrLowEntropy is recorded in [DiagnosticInfo.Errors] when
+	// [Provider.WithSuspiciousSerialDetection] rejects a serial that looks
+	// valid but is shared or predictable, such as a templated cloud serial.
+	ErrLowEntropy = errors.New("value has low entropy")
+
+	// ErrPartialCollection is returned by [Provider.ID] with
+	// [Provider.WithStrict] when an ID was produced but some enabled
+	// components failed to contribute. It wraps the [ComponentError] of each
+	// failed component.
+	ErrPartialCollection = errors.New("some hardware components could not be collected")
+
+	// ErrInsufficientComponents is returned by [Provider.ID] with
+	// [Provider.WithMinimumComponents] when an ID was produced from fewer
+	// components than the required minimum.
+	ErrInsufficientComponents = errors.New("too few hardware components collected")
+
+	// ErrMalformedID is returned by [Provider.Validate] when strict input
+	// validation is enabled and the provided ID cannot be a valid machine ID.
+	ErrMalformedID = errors.New("malformed machine ID")
+
+	// ErrInvalidFormat is returned by [Provider.ID] when [Provider.WithFormat]
+	// was given a value that is not one of the defined [FormatMode] constants.
+	ErrInvalidFormat = errors.New("invalid format mode")
 )
 
 // CommandError records a failed system command execution.
@@ -123,6 +123,7 @@ func TestSentinelErrorsWrapping(t *testing.T) {
 	}{
 		{"ErrNotFound wrapped", fmt.Errorf("UUID not found: %w", ErrNotFound), ErrNotFound},
 		{"ErrOEMPlaceholder wrapped", fmt.Errorf("serial is placeholder: %w", ErrOEMPlaceholder), ErrOEMPlaceholder},
+		{"ErrPermissionDenied parse", &ParseError{Source: "/sys/class/dmi/id/product_uuid", Err: ErrPermissionDenied}, ErrPermissionDenied},
 		{"ErrAllMethodsFailed wrapped", fmt.Errorf("CPU failed: %w", ErrAllMethodsFailed), ErrAllMethodsFailed},
 		{"ErrEmptyValue wrapped", fmt.Errorf("empty: %w", ErrEmptyValue), ErrEmptyValue},
 		{"ErrNoValues wrapped", fmt.Errorf("no values: %w", ErrNoValues), ErrNoValues},
@@ -1,8 +1,11 @@
 package machineid_test
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/slashdevops/machineid"
 )
@@ -132,6 +135,62 @@ func ExampleProvider_ID_allComponents() {
 	// Is hex: true
 }
 
+// ExampleExecutorFunc defines a map-based command mock inline, as a one-off
+// test would, and installs it with [machineid.Provider.WithExecutorFunc].
+func ExampleExecutorFunc() {
+	outputs := map[string]string{
+		"sysctl -n machdep.cpu.brand_string": "Apple M1 Pro",
+	}
+	mock := machineid.ExecutorFunc(func(_ context.Context, name string, args ...string) (string, error) {
+		command := strings.Join(append([]string{name}, args...), " ")
+		if output, ok := outputs[command]; ok {
+			return output, nil
+		}
+
+		return "", fmt.Errorf("unexpected command %q", command)
+	})
+
+	provider := machineid.New().WithCPU().WithExecutorFunc(mock)
+	_ = provider // provider.ID now runs every command through mock
+
+	output, _ := mock.Execute(context.Background(), "sysctl", "-n", "machdep.cpu.brand_string")
+	fmt.Println(output)
+
+	_, err := mock.Execute(context.Background(), "wmic", "cpu", "get", "ProcessorId", "/value")
+	fmt.Println(err)
+	// Output:
+	// Apple M1 Pro
+	// unexpected command "wmic cpu get ProcessorId /value"
+}
+
+// ExampleProvider_WithEventSink writes one JSON document per generation to a file.
+func ExampleProvider_WithEventSink() {
+	f, err := os.CreateTemp("", "machineid-events-*.jsonl")
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	provider := machineid.New().
+		WithCPU().
+		WithSystemUUID().
+		WithEventSink(func(event []byte) {
+			_, _ = f.Write(append(event, '\n'))
+		})
+
+	if _, err := provider.ID(context.Background()); err != nil {
+		fmt.Printf("error: %v\n", err)
+		return
+	}
+
+	data, _ := os.ReadFile(f.Name())
+	fmt.Printf("Events written: %d\n", bytes.Count(data, []byte("\n")))
+	// Output:
+	// Events written: 1
+}
+
 func isAllHex(s string) bool {
 	for _, c := range s {
 		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')) {
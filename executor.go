@@ -13,10 +13,29 @@ type defaultCommandExecutor struct {
 	Timeout time.Duration
 }
 
+// ExecutorFunc adapts an ordinary function to the [CommandExecutor] interface,
+// like [net/http.HandlerFunc], so a test double can be written as a closure.
+type ExecutorFunc func(ctx context.Context, name string, args ...string) (string, error)
+
+// Execute calls f(ctx, name, args...).
+func (f ExecutorFunc) Execute(ctx context.Context, name string, args ...string) (string, error) {
+	return f(ctx, name, args...)
+}
+
+// commandWaitDelay bounds how long a cancelled command may keep its output
+// open, for example through a child process that inherited it.
+const commandWaitDelay = 100 * time.Millisecond
+
 // Execute runs a system command with a timeout and returns the output.
 // It uses context.WithTimeout to prevent commands from hanging indefinitely.
+// A [Provider.WithTimeout] timeout carried by ctx replaces e.Timeout.
+// Cancelling ctx kills the command and returns promptly with an error wrapping
+// the context error, even if the command left child processes running.
 func (e *defaultCommandExecutor) Execute(ctx context.Context, name string, args ...string) (string, error) {
 	timeout := e.Timeout
+	if d, ok := ctx.Value(commandTimeoutKey{}).(time.Duration); ok {
+		timeout = d
+	}
 	if timeout <= 0 {
 		timeout = defaultTimeout
 	}
@@ -25,20 +44,56 @@ func (e *defaultCommandExecutor) Execute(ctx context.Context, name string, args
 	defer cancel()
 
 	cmd := exec.CommandContext(timeoutCtx, name, args...)
+	cmd.WaitDelay = commandWaitDelay
 	output, err := cmd.Output()
 	if err != nil {
+		if ctxErr := timeoutCtx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+
 		return "", &CommandError{Command: name, Err: err}
 	}
 
 	return strings.TrimSpace(string(output)), nil
 }
 
+// commandTimeoutKey is the context key under which [Provider.WithTimeout]
+// passes the command timeout to executeCommand and the default executor.
+type commandTimeoutKey struct{}
+
+// commandContext returns ctx carrying the provider's source configuration,
+// command timeout and clock, if any.
+func (p *Provider) commandContext(ctx context.Context) context.Context {
+	for _, entry := range p.sourceConfig {
+		ctx = context.WithValue(ctx, entry.key, entry.value)
+	}
+	if p.commandTimeout > 0 {
+		ctx = context.WithValue(ctx, commandTimeoutKey{}, p.commandTimeout)
+	}
+	if p.timeSource != nil {
+		ctx = context.WithValue(ctx, clockKey{}, p.timeSource)
+	}
+
+	return ctx
+}
+
 // executeCommand is a convenience wrapper that calls Execute with the given context.
 // This function is used by platform-specific collectors that need the Provider's executor.
+// A command timeout carried by ctx bounds the command, whatever the executor,
+// and a clock carried by ctx measures its duration.
 func executeCommand(ctx context.Context, executor CommandExecutor, logger *slog.Logger, name string, args ...string) (string, error) {
+	timeout := defaultTimeout
+	if d, ok := ctx.Value(commandTimeoutKey{}).(time.Duration); ok {
+		timeout = d
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	if executor == nil {
 		executor = &defaultCommandExecutor{
-			Timeout: defaultTimeout,
+			Timeout: timeout,
 		}
 	}
 
@@ -46,9 +101,10 @@ func executeCommand(ctx context.Context, executor CommandExecutor, logger *slog.
 		logger.Debug("executing command", "command", name, "args", args)
 	}
 
-	start := time.Now()
+	clock := clockFrom(ctx)
+	start := clock.Now()
 	result, err := executor.Execute(ctx, name, args...)
-	duration := time.Since(start)
+	duration := clock.Now().Sub(start)
 
 	if logger != nil {
 		if err != nil {
@@ -2,7 +2,11 @@ package machineid
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -15,14 +19,18 @@ type mockExecutor struct {
 	errors map[string]error
 	// callCount tracks how many times each command was called
 	callCount map[string]int
+	// argOutputs maps a full command line to expected output, taking
+	// precedence over outputs
+	argOutputs map[string]string
 }
 
 // newMockExecutor creates a new mock executor for testing.
 func newMockExecutor() *mockExecutor {
 	return &mockExecutor{
-		outputs:   make(map[string]string),
-		errors:    make(map[string]error),
-		callCount: make(map[string]int),
+		outputs:    make(map[string]string),
+		errors:     make(map[string]error),
+		callCount:  make(map[string]int),
+		argOutputs: make(map[string]string),
 	}
 }
 
@@ -34,6 +42,10 @@ func (m *mockExecutor) Execute(ctx context.Context, name string, args ...string)
 		return "", err
 	}
 
+	if output, exists := m.argOutputs[commandLine(name, args)]; exists {
+		return output, nil
+	}
+
 	if output, exists := m.outputs[name]; exists {
 		return output, nil
 	}
@@ -46,11 +58,39 @@ func (m *mockExecutor) setOutput(command, output string) {
 	m.outputs[command] = output
 }
 
+// setOutputForArgs configures the mock to return the given output for a
+// command invoked with exactly the given arguments.
+func (m *mockExecutor) setOutputForArgs(command string, args []string, output string) {
+	m.argOutputs[commandLine(command, args)] = output
+}
+
+// commandLine joins a command name and its arguments into a lookup key.
+func commandLine(name string, args []string) string {
+	return strings.Join(append([]string{name}, args...), " ")
+}
+
 // setError configures the mock to return an error for a command.
 func (m *mockExecutor) setError(command string, err error) {
 	m.errors[command] = err
 }
 
+// slowExecutor is a test double whose commands take delay to complete,
+// returning early with the context error if the context ends first.
+type slowExecutor struct {
+	delay  time.Duration
+	output string
+}
+
+// Execute implements CommandExecutor interface.
+func (s *slowExecutor) Execute(ctx context.Context, name string, args ...string) (string, error) {
+	select {
+	case <-time.After(s.delay):
+		return s.output, nil
+	case <-ctx.Done():
+		return "", &CommandError{Command: name, Err: ctx.Err()}
+	}
+}
+
 // TestExecuteTimeout tests that command execution respects timeout.
 func TestExecuteTimeout(t *testing.T) {
 	executor := &defaultCommandExecutor{}
@@ -65,6 +105,113 @@ func TestExecuteTimeout(t *testing.T) {
 	}
 }
 
+// TestExecuteParentCancellation tests that cancelling the caller's context
+// aborts a running command promptly, even if it left a child process holding
+// its output, and reports the cancellation.
+func TestExecuteParentCancellation(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	executor := &defaultCommandExecutor{Timeout: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := executor.Execute(ctx, "sh", "-c", "sleep 5 | cat")
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Execute() returned after %v, want prompt return on cancellation", elapsed)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Execute() error = %v, want context.Canceled", err)
+	}
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Command != "sh" {
+		t.Errorf("Execute() error = %v, want CommandError for sh", err)
+	}
+}
+
+// TestProviderWithTimeout tests that the provider's command timeout bounds
+// slow commands, extends beyond the default, and yields to a sooner context
+// deadline.
+func TestProviderWithTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		delay       time.Duration
+		timeout     time.Duration
+		ctxDeadline time.Duration
+		wantErr     error
+	}{
+		{"provider timeout expires", time.Second, 20 * time.Millisecond, 0, context.DeadlineExceeded},
+		{"command within timeout", 50 * time.Millisecond, time.Second, 0, nil},
+		{"context deadline sooner", time.Second, time.Minute, 20 * time.Millisecond, context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New().WithExecutor(&slowExecutor{delay: tt.delay, output: "ok"}).WithTimeout(tt.timeout)
+
+			ctx := context.Background()
+			if tt.ctxDeadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxDeadline)
+				defer cancel()
+			}
+
+			start := time.Now()
+			output, err := executeCommand(p.commandContext(ctx), p.commandExecutor, nil, "wmic")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("executeCommand() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && time.Since(start) > 500*time.Millisecond {
+				t.Errorf("executeCommand() returned after %v, want prompt timeout", time.Since(start))
+			}
+			if tt.wantErr == nil && output != "ok" {
+				t.Errorf("executeCommand() = %q, want %q", output, "ok")
+			}
+		})
+	}
+}
+
+// TestWithTimeoutDefaultExecutor tests that the default executor takes the
+// WithTimeout timeout from the context instead of its own.
+func TestWithTimeoutDefaultExecutor(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+
+	p := New().WithTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	_, err := p.commandExecutor.Execute(p.commandContext(context.Background()), "sleep", "5")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Execute() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Execute() returned after %v, want the provider timeout", elapsed)
+	}
+}
+
+// TestWithTimeoutClone tests that WithTimeout on a clone leaves the command
+// timeout of the original unchanged.
+func TestWithTimeoutClone(t *testing.T) {
+	base := New()
+	clone := base.Clone().WithTimeout(30 * time.Second)
+
+	if base.commandTimeout != 0 {
+		t.Errorf("base commandTimeout = %v, want 0", base.commandTimeout)
+	}
+	if executor := base.commandExecutor.(*defaultCommandExecutor); executor.Timeout != defaultTimeout {
+		t.Errorf("base executor Timeout = %v, want %v", executor.Timeout, defaultTimeout)
+	}
+	if clone.commandTimeout != 30*time.Second {
+		t.Errorf("clone commandTimeout = %v, want 30s", clone.commandTimeout)
+	}
+	if clone.commandExecutor == base.commandExecutor {
+		t.Error("Clone() shares the default executor with the original")
+	}
+}
+
 // TestExecuteCommandWithNilExecutor tests executeCommand with nil executor.
 func TestExecuteCommandWithNilExecutor(t *testing.T) {
 	// This should use the default realExecutor
@@ -75,3 +222,25 @@ func TestExecuteCommandWithNilExecutor(t *testing.T) {
 		t.Logf("Command execution with nil executor: %v", err)
 	}
 }
+
+// TestWithExecutorFunc tests that commands are run through the function set
+// with WithExecutorFunc.
+func TestWithExecutorFunc(t *testing.T) {
+	var got []string
+	p := New().WithExecutorFunc(func(_ context.Context, name string, args ...string) (string, error) {
+		got = append([]string{name}, args...)
+
+		return "Apple M1 Pro", nil
+	})
+
+	output, err := executeCommand(context.Background(), p.commandExecutor, nil, "sysctl", "-n", "machdep.cpu.brand_string")
+	if err != nil {
+		t.Fatalf("executeCommand() error = %v", err)
+	}
+	if output != "Apple M1 Pro" {
+		t.Errorf("executeCommand() = %q, want %q", output, "Apple M1 Pro")
+	}
+	if !slices.Equal(got, []string{"sysctl", "-n", "machdep.cpu.brand_string"}) {
+		t.Errorf("executed %v, want the sysctl command line", got)
+	}
+}
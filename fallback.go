@@ -0,0 +1,163 @@
+package machineid
+
+import (
+	"context"
+	"slices"
+)
+
+// fallbackChain is a chain registered with [Provider.WithFallbackChain].
+type fallbackChain struct {
+	primary    string
+	alternates []string
+}
+
+// collectorFunc gathers the identifiers of the enabled components; see [collectIdentifiersFor].
+type collectorFunc func(ctx context.Context, p *Provider, diag *DiagnosticInfo) ([]string, error)
+
+// WithFallbackChain substitutes other components for primary when it fails:
+// the alternates are collected in order, only if primary failed, and the first
+// one that succeeds contributes its value to the ID. Alternates that are
+// already enabled and collected are skipped, as they contribute anyway. For
+// example, a valid motherboard serial can stand in for a missing system UUID:
+//
+//	provider.WithSystemUUID().WithFallbackChain(machineid.ComponentSystemUUID, machineid.ComponentMotherboard)
+//
+// The substitute is recorded in diagnostics under its own name, with a note
+// naming the primary; [Provider.WithFallbackAsPrimary] records it under the
+// primary's name instead. Either way, a chain never contributes more than one
+// collected component, so policies that count collected components treat a
+// successful fallback exactly like a successful primary. The ID only depends
+// on the substitute's value, not on how it is recorded.
+//
+// Registering a chain for the same primary again replaces the earlier chain,
+// like [Provider.WithSourceConfig] and [Provider.WithComponentValueTransform],
+// so repeated configuration never adds a second substitute for one primary.
+func (p *Provider) WithFallbackChain(primary string, alternates ...string) *Provider {
+	chain := fallbackChain{primary: primary, alternates: alternates}
+	if i := slices.IndexFunc(p.fallbackChains, func(c fallbackChain) bool { return c.primary == primary }); i >= 0 {
+		p.fallbackChains[i] = chain
+
+		return p
+	}
+
+	p.fallbackChains = append(p.fallbackChains, chain)
+
+	return p
+}
+
+// WithFallbackAsPrimary records a component substituted by
+// [Provider.WithFallbackChain] under the primary's name in
+// [DiagnosticInfo].Collected, clearing the primary's error, instead of under
+// its own name.
+func (p *Provider) WithFallbackAsPrimary() *Provider {
+	p.fallbackAsPrimary = true
+
+	return p
+}
+
+// applyFallbacks collects the alternates of each failed primary with collector
+// and appends the first successful substitute's identifiers. The caller must hold p.mu.
+func (p *Provider) applyFallbacks(ctx context.Context, identifiers []string, diag *DiagnosticInfo, collector collectorFunc) []string {
+	for _, chain := range p.fallbackChains {
+		if _, failed := diag.Errors[chain.primary]; !failed {
+			continue
+		}
+
+		for _, alternate := range chain.alternates {
+			if slices.Contains(diag.Collected, alternate) {
+				continue
+			}
+
+			alternateDiag := &DiagnosticInfo{Errors: make(map[string]error)}
+			values := p.collectOnly(ctx, alternate, alternateDiag, collector)
+			if !slices.Contains(alternateDiag.Collected, alternate) {
+				p.logDebug("fallback component failed", "component", chain.primary, "fallback", alternate, "error", alternateDiag.Errors[alternate])
+
+				continue
+			}
+
+			p.logInfo("using fallback component", "component", chain.primary, "fallback", alternate)
+			identifiers = append(identifiers, values...)
+			diag.Notes = append(diag.Notes, chain.primary+" failed, using "+alternate)
+
+			if p.fallbackAsPrimary {
+				delete(diag.Errors, chain.primary)
+				diag.Collected = append(diag.Collected, chain.primary)
+				p.componentValues[chain.primary] = p.componentValues[alternate]
+				delete(p.componentValues, alternate)
+			} else {
+				diag.Collected = append(diag.Collected, alternate)
+			}
+
+			break
+		}
+	}
+
+	return identifiers
+}
+
+// collectOnly runs collector with only component enabled, restoring the
+// provider's component selection afterwards. The caller must hold p.mu.
+func (p *Provider) collectOnly(ctx context.Context, component string, diag *DiagnosticInfo, collector collectorFunc) []string {
+	flags := p.componentFlags()
+	if flags[component] == nil {
+		return nil
+	}
+
+	saved := make(map[string]bool, len(flags))
+	for name, flag := range flags {
+		saved[name] = *flag
+		*flag = name == component
+	}
+	defer func() {
+		for name, flag := range flags {
+			*flag = saved[name]
+		}
+	}()
+
+	identifiers, err := collector(ctx, p, diag)
+	if err != nil {
+		return nil
+	}
+
+	return identifiers
+}
+
+// WithFallbackToMachineID makes [Provider.ID] fall back to the operating
+// system's machine identifier when collection yields no identifiers at all,
+// instead of failing with [ErrNoIdentifiers], for example on bare VMs and
+// locked-down containers. The identifier is the systemd machine-id on Linux,
+// the IOPlatformUUID on macOS, the registry MachineGuid on Windows and the
+// hostid on FreeBSD. A [Provider.WithPersistentCache] entry takes precedence.
+//
+// The fallback is logged and recorded in [DiagnosticInfo].Collected as
+// [ComponentOSMachineID]. The identifier is generated when the OS is
+// installed, so the fallback ID differs from the machine's hardware ID,
+// changes on reinstall, and may be shared by machines cloned from one image.
+func (p *Provider) WithFallbackToMachineID() *Provider {
+	p.fallbackToMachineID = true
+
+	return p
+}
+
+// loadMachineIDFallback collects [ComponentOSMachineID], recording the
+// fallback in diag. It reports false if the fallback is disabled or failed.
+// The caller must hold p.mu.
+func (p *Provider) loadMachineIDFallback(ctx context.Context, diag *DiagnosticInfo) ([]string, bool) {
+	if !p.fallbackToMachineID {
+		return nil, false
+	}
+
+	p.logInfo("no identifiers collected, falling back to OS machine identifier")
+
+	identifiers := p.appendIdentifier(p.commandContext(ctx), nil, func(ctx context.Context) (string, error) {
+		return osMachineID(ctx, p, p.logger)
+	}, "os-machine-id:", diag, ComponentOSMachineID)
+	if len(identifiers) == 0 {
+		return nil, false
+	}
+
+	diag.Notes = append(diag.Notes, "no identifiers collected, using "+ComponentOSMachineID)
+
+	return identifiers, true
+}
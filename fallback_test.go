@@ -0,0 +1,116 @@
+package machineid
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// newUUIDlessMock returns a macOS mock executor that reports a serial number
+// but no hardware UUID.
+func newUUIDlessMock() *mockExecutor {
+	mock := newMockExecutor()
+	mock.setOutput("system_profiler", `{"SPHardwareDataType": [{"serial_number": "C02TEST123"}]}`)
+	mock.setOutput("ioreg", "no platform properties")
+
+	return mock
+}
+
+// TestWithFallbackChain tests that the motherboard serial fills in when the
+// UUID fails, recorded under its own name.
+func TestWithFallbackChain(t *testing.T) {
+	ctx := context.Background()
+	p := New().WithExecutor(newUUIDlessMock()).WithSystemUUID().
+		WithFallbackChain(ComponentSystemUUID, ComponentCPU, ComponentMotherboard)
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	identifiers, err := collectDarwinIdentifiers(ctx, p, diag)
+	if err != nil {
+		t.Fatalf("collectDarwinIdentifiers() error = %v", err)
+	}
+	identifiers = p.applyFallbacks(ctx, identifiers, diag, collectDarwinIdentifiers)
+
+	if !slices.Equal(identifiers, []string{"mb:C02TEST123"}) {
+		t.Errorf("identifiers = %v, want the motherboard serial", identifiers)
+	}
+	if !slices.Equal(diag.Collected, []string{ComponentMotherboard}) {
+		t.Errorf("Collected = %v, want [%s]", diag.Collected, ComponentMotherboard)
+	}
+	if diag.Errors[ComponentSystemUUID] == nil {
+		t.Error("primary error should be kept when the fallback is recorded under its own name")
+	}
+	if !slices.Contains(diag.Notes, "uuid failed, using motherboard") {
+		t.Errorf("Notes = %v, want a fallback note", diag.Notes)
+	}
+	if p.includeMotherboard || p.includeCPU || !p.includeSystemUUID {
+		t.Error("component selection not restored after fallback collection")
+	}
+}
+
+// TestWithFallbackAsPrimary tests that the substitute can be recorded under
+// the primary's name.
+func TestWithFallbackAsPrimary(t *testing.T) {
+	ctx := context.Background()
+	p := New().WithExecutor(newUUIDlessMock()).WithSystemUUID().
+		WithFallbackChain(ComponentSystemUUID, ComponentMotherboard).WithFallbackAsPrimary()
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	identifiers, _ := collectDarwinIdentifiers(ctx, p, diag)
+	identifiers = p.applyFallbacks(ctx, identifiers, diag, collectDarwinIdentifiers)
+
+	if !slices.Equal(identifiers, []string{"mb:C02TEST123"}) {
+		t.Errorf("identifiers = %v, want the motherboard serial", identifiers)
+	}
+	if !slices.Equal(diag.Collected, []string{ComponentSystemUUID}) {
+		t.Errorf("Collected = %v, want [%s]", diag.Collected, ComponentSystemUUID)
+	}
+	if len(diag.Errors) != 0 {
+		t.Errorf("Errors = %v, want none", diag.Errors)
+	}
+	if !slices.Equal(p.componentValues[ComponentSystemUUID], []string{"C02TEST123"}) {
+		t.Errorf("componentValues[uuid] = %v, want the serial", p.componentValues[ComponentSystemUUID])
+	}
+}
+
+// TestWithFallbackChainPrimarySucceeds tests that alternates are not
+// collected when the primary succeeds.
+func TestWithFallbackChainPrimarySucceeds(t *testing.T) {
+	ctx := context.Background()
+	p := New().WithSystemUUID().WithFallbackChain(ComponentSystemUUID, ComponentMotherboard)
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	identifiers := p.appendIdentifier(ctx, nil, func(context.Context) (string, error) {
+		return "UUID-1234", nil
+	}, "uuid:", diag, ComponentSystemUUID)
+	identifiers = p.applyFallbacks(ctx, identifiers, diag, func(context.Context, *Provider, *DiagnosticInfo) ([]string, error) {
+		t.Error("alternate collected although the primary succeeded")
+		return nil, errors.New("unexpected")
+	})
+
+	if !slices.Equal(identifiers, []string{"uuid:UUID-1234"}) {
+		t.Errorf("identifiers = %v, want only the UUID", identifiers)
+	}
+}
+
+// TestWithFallbackChainReplaced tests that registering a chain for the same
+// primary twice yields a single substitute contribution.
+func TestWithFallbackChainReplaced(t *testing.T) {
+	ctx := context.Background()
+	mock := newUUIDlessMock()
+	mock.setOutput("sysctl", "Apple M1 Pro")
+	p := New().WithExecutor(mock).WithSystemUUID().
+		WithFallbackChain(ComponentSystemUUID, ComponentMotherboard).
+		WithFallbackChain(ComponentSystemUUID, ComponentMotherboard, ComponentCPU)
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	identifiers, _ := collectDarwinIdentifiers(ctx, p, diag)
+	identifiers = p.applyFallbacks(ctx, identifiers, diag, collectDarwinIdentifiers)
+
+	if !slices.Equal(identifiers, []string{"mb:C02TEST123"}) {
+		t.Errorf("identifiers = %v, want a single substitute", identifiers)
+	}
+	if len(p.fallbackChains) != 1 {
+		t.Errorf("registered chains = %d, want 1", len(p.fallbackChains))
+	}
+}
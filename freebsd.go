@@ -0,0 +1,69 @@
+//go:build freebsd
+
+package machineid
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"time"
+)
+
+// fastModeSkipped lists the components skipped by [Provider.WithFastMode] on FreeBSD.
+var fastModeSkipped = map[string]bool{
+	ComponentDisk: true,
+}
+
+// supportedComponents lists the components with a collector on FreeBSD.
+var supportedComponents = []string{
+	ComponentCPU,
+	ComponentMotherboard,
+	ComponentSystemUUID,
+	ComponentMAC,
+	ComponentDisk,
+	ComponentMachineID,
+}
+
+// defaultComponentTimeouts bounds each component on FreeBSD. kenv and sysctl
+// return immediately; disk runs camcontrol once per disk.
+var defaultComponentTimeouts = map[string]time.Duration{
+	ComponentCPU:         time.Second,
+	ComponentMotherboard: time.Second,
+	ComponentSystemUUID:  time.Second,
+	ComponentMachineID:   time.Second,
+	ComponentMAC:         time.Second,
+	ComponentDisk:        10 * time.Second,
+}
+
+// collectIdentifiers gathers FreeBSD-specific hardware identifiers based on provider config.
+func collectIdentifiers(ctx context.Context, p *Provider, diag *DiagnosticInfo) ([]string, error) {
+	return collectFreeBSDIdentifiers(ctx, p, diag)
+}
+
+// canCollectUnprivileged reports whether component can be collected without
+// root. camcontrol needs access to the CAM pass-through devices.
+func canCollectUnprivileged(_ *Provider, component string) bool {
+	return component != ComponentDisk || os.Geteuid() == 0
+}
+
+// macClassifiers returns the name-based virtual and wireless interface
+// classifiers used for the MAC component on FreeBSD.
+func macClassifiers(context.Context, *Provider, *slog.Logger) (isVirtual, isWireless func(net.Interface) bool) {
+	return isVirtualNetInterface, isWirelessNetInterface
+}
+
+// osMachineID returns the hostid for [Provider.WithFallbackToMachineID].
+func osMachineID(_ context.Context, p *Provider, logger *slog.Logger) (string, error) {
+	return freeBSDHostID(p.filesystem(), logger)
+}
+
+// platformOSVersion returns the FreeBSD userland version from freebsd-version.
+func platformOSVersion(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "freebsd-version", "-u")
+	if err != nil {
+		return "", err
+	}
+
+	return "FreeBSD " + output, nil
+}
@@ -0,0 +1,18 @@
+//go:build freebsd
+
+package machineid
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestSupportedComponents tests that FreeBSD reports every component,
+// including the hostid-based machine-id.
+func TestSupportedComponents(t *testing.T) {
+	got := SupportedComponents()
+	want := []string{ComponentCPU, ComponentMotherboard, ComponentSystemUUID, ComponentMAC, ComponentDisk, ComponentMachineID}
+	if !slices.Equal(got, want) {
+		t.Errorf("SupportedComponents() = %v, want %v", got, want)
+	}
+}
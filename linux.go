@@ -4,54 +4,235 @@ package machineid
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"maps"
+	"net"
 	"os"
-	"path/filepath"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// fastModeSkipped lists the components skipped by [Provider.WithFastMode] on Linux.
+var fastModeSkipped = map[string]bool{
+	ComponentDisk:   true,
+	ComponentTPM:    true,
+	ComponentMemory: true,
+}
+
+// supportedComponents lists the components with a collector on Linux.
+var supportedComponents = []string{
+	ComponentCPU,
+	ComponentMotherboard,
+	ComponentSystemUUID,
+	ComponentMAC,
+	ComponentDisk,
+	ComponentMachineID,
+	ComponentTPM,
+	ComponentGPU,
+	ComponentMemory,
+}
+
+// defaultComponentTimeouts bounds each component on Linux. File sources read
+// sysfs or procfs in microseconds; disk may run lsblk, the TPM tpm2-tools, and
+// the system UUID, motherboard and memory dmidecode.
+var defaultComponentTimeouts = map[string]time.Duration{
+	ComponentCPU:         time.Second,
+	ComponentMotherboard: 10 * time.Second,
+	ComponentSystemUUID:  10 * time.Second,
+	ComponentMachineID:   time.Second,
+	ComponentMAC:         time.Second,
+	ComponentDisk:        10 * time.Second,
+	ComponentTPM:         10 * time.Second,
+	ComponentGPU:         time.Second,
+	ComponentMemory:      10 * time.Second,
+}
+
 // collectIdentifiers gathers Linux-specific hardware identifiers based on provider config.
 func collectIdentifiers(ctx context.Context, p *Provider, diag *DiagnosticInfo) ([]string, error) {
 	var identifiers []string
 	logger := p.logger
 
 	if p.includeCPU {
-		identifiers = appendIdentifierIfValid(identifiers, func() (string, error) {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
 			return linuxCPUID(logger)
-		}, "cpu:", diag, ComponentCPU, logger)
+		}, "cpu:", diag, ComponentCPU)
 	}
 
-	if p.includeSystemUUID {
-		identifiers = appendIdentifierIfValid(identifiers, func() (string, error) {
-			return linuxSystemUUID(logger)
-		}, "uuid:", diag, ComponentSystemUUID, logger)
-		identifiers = appendIdentifierIfValid(identifiers, func() (string, error) {
-			return linuxMachineID(logger)
-		}, "machine:", diag, ComponentMachineID, logger)
+	if p.includeSystemUUID && p.bestUUID {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			value, source, err := linuxBestUUID(!p.excludeMachineID, logger)
+			if err != nil {
+				value, source, err = p.androidSerialFallback(ctx, err, logger)
+			}
+			if err == nil {
+				setUUIDSource(diag, source)
+			}
+
+			return value, err
+		}, "uuid:", diag, ComponentSystemUUID)
+	} else if p.includeSystemUUID {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			value, err := linuxSystemUUID(ctx, p.commandExecutor, logger)
+			if err != nil {
+				value, _, err = p.androidSerialFallback(ctx, err, logger)
+			}
+
+			return value, err
+		}, "uuid:", diag, ComponentSystemUUID)
+		if !p.excludeMachineID {
+			identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+				return linuxMachineID(logger)
+			}, "machine:", diag, ComponentMachineID)
+		}
 	}
 
 	if p.includeMotherboard {
-		identifiers = appendIdentifierIfValid(identifiers, func() (string, error) {
-			return linuxMotherboardSerial(logger)
-		}, "mb:", diag, ComponentMotherboard, logger)
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			return linuxMotherboardSerial(ctx, p.commandExecutor, logger)
+		}, "mb:", diag, ComponentMotherboard)
 	}
 
 	if p.includeMAC {
-		identifiers = appendIdentifiersIfValid(identifiers, func() ([]string, error) {
-			return collectMACAddresses(p.macFilter, logger)
-		}, "mac:", diag, ComponentMAC, logger)
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(ctx context.Context) ([]string, error) {
+			isVirtual, isWireless := macClassifiers(ctx, p, logger)
+
+			return p.macAddresses(isVirtual, isWireless, logger)
+		}, "mac:", diag, ComponentMAC)
 	}
 
 	if p.includeDisk {
-		identifiers = appendIdentifiersIfValid(identifiers, func() ([]string, error) {
-			return linuxDiskSerials(ctx, p.commandExecutor, logger)
-		}, "disk:", diag, ComponentDisk, logger)
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(ctx context.Context) ([]string, error) {
+			serials, err := linuxDiskSerials(ctx, p.commandExecutor, p.filesystem(), p.diskFilter, logger)
+			if err == nil && p.nvmeDiskIDs {
+				serials = preferNVMeIDs(ctx, p.commandExecutor, serials, diag, logger)
+			}
+
+			return serials, err
+		}, "disk:", diag, ComponentDisk)
+	}
+
+	if p.includeTPM {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			return linuxTPMEKHash(ctx, p.filesystem(), p.commandExecutor, logger)
+		}, "tpm:", diag, ComponentTPM)
+	}
+
+	if p.includeGPU {
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(context.Context) ([]string, error) {
+			return linuxGPUIDs(p.filesystem(), logger)
+		}, "gpu:", diag, ComponentGPU)
+	}
+
+	if p.includeMemory {
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(ctx context.Context) ([]string, error) {
+			return linuxMemory(ctx, p.commandExecutor, p.filesystem(), logger)
+		}, "memory:", diag, ComponentMemory)
 	}
 
 	return identifiers, nil
 }
 
+// canCollectUnprivileged reports whether the files backing component are
+// readable. The DMI product_uuid and board_serial are usually root-only. On
+// Android, the system UUID is read with getprop instead. tpm2-tools needs
+// access to the TPM resource manager, usually granted to the tss group.
+// dmidecode needs root for the memory modules, but the total memory fallback
+// in sysfs is world-readable.
+func canCollectUnprivileged(p *Provider, component string) bool {
+	switch component {
+	case ComponentCPU:
+		return p.canReadAny("/proc/cpuinfo")
+	case ComponentSystemUUID:
+		if p.bestUUID && !p.excludeMachineID && p.canReadAny("/etc/machine-id", "/var/lib/dbus/machine-id") {
+			return true
+		}
+
+		return p.canReadAny("/sys/class/dmi/id/product_uuid", "/sys/devices/virtual/dmi/id/product_uuid") ||
+			isAndroid(p.filesystem())
+	case ComponentMachineID:
+		return p.canReadAny("/etc/machine-id", "/var/lib/dbus/machine-id")
+	case ComponentMotherboard:
+		return p.canReadAny("/sys/class/dmi/id/board_serial", "/sys/devices/virtual/dmi/id/board_serial")
+	case ComponentTPM:
+		return p.canReadAny("/dev/tpmrm0", "/dev/tpm0")
+	case ComponentMemory:
+		return os.Geteuid() == 0 || p.canReadAny("/sys/devices/system/memory/block_size_bytes")
+	default:
+		return true
+	}
+}
+
+// osMachineID returns the systemd machine-id for [Provider.WithFallbackToMachineID].
+func osMachineID(_ context.Context, p *Provider, logger *slog.Logger) (string, error) {
+	fsys := p.filesystem()
+	for _, name := range []string{"etc/machine-id", "var/lib/dbus/machine-id"} {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			if logger != nil {
+				logger.Debug("failed to read file", "path", "/"+name, "error", err)
+			}
+
+			continue
+		}
+
+		if value := strings.TrimSpace(string(data)); value != "" {
+			return value, nil
+		}
+	}
+
+	return "", &ParseError{Source: "/etc/machine-id", Err: ErrNotFound}
+}
+
+// macClassifiers returns the virtual and wireless interface classifiers used
+// for the MAC component on Linux, where wireless devices are detected in sysfs.
+func macClassifiers(_ context.Context, p *Provider, _ *slog.Logger) (isVirtual, isWireless func(net.Interface) bool) {
+	return isVirtualNetInterface, p.isLinuxWireless
+}
+
+// platformOSVersion returns the distribution name and version from os-release.
+func platformOSVersion(_ context.Context, _ CommandExecutor, logger *slog.Logger) (string, error) {
+	for _, path := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if logger != nil {
+				logger.Debug("failed to read os-release", "path", path, "error", err)
+			}
+
+			continue
+		}
+
+		if version := parseOSRelease(string(data)); version != "" {
+			return version, nil
+		}
+	}
+
+	return "", ErrNotFound
+}
+
+// parseOSRelease extracts PRETTY_NAME, or NAME and VERSION_ID, from os-release content.
+func parseOSRelease(content string) string {
+	fields := make(map[string]string)
+	for line := range strings.SplitSeq(content, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok {
+			fields[key] = strings.Trim(value, `"'`)
+		}
+	}
+
+	if fields["PRETTY_NAME"] != "" {
+		return fields["PRETTY_NAME"]
+	}
+
+	return strings.TrimSpace(fields["NAME"] + " " + fields["VERSION_ID"])
+}
+
 // linuxCPUID retrieves CPU information from /proc/cpuinfo.
 func linuxCPUID(logger *slog.Logger) (string, error) {
 	const path = "/proc/cpuinfo"
@@ -73,52 +254,110 @@ func linuxCPUID(logger *slog.Logger) (string, error) {
 }
 
 // parseCPUInfo extracts CPU information from /proc/cpuinfo content.
+// The per-core processor index is ignored, and values are aggregated across
+// all processor blocks independently of their order, so heterogeneous
+// (big.LITTLE or multi-socket) systems yield a stable identifier: distinct
+// vendors and model names are sorted and joined with commas, and flags are
+// the sorted union of every block's flags.
 func parseCPUInfo(content string) string {
-	lines := strings.Split(content, "\n")
-	var processor, vendorID, modelName, flags string
+	vendors := make(map[string]bool)
+	models := make(map[string]bool)
+	flags := make(map[string]bool)
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		parts := strings.SplitN(line, ":", 2)
-		if len(parts) != 2 {
+	for line := range strings.Lines(content) {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
 			continue
 		}
 
-		switch {
-		case strings.HasPrefix(line, "processor"):
-			processor = strings.TrimSpace(parts[1])
-		case strings.HasPrefix(line, "vendor_id"):
-			vendorID = strings.TrimSpace(parts[1])
-		case strings.HasPrefix(line, "model name"):
-			modelName = strings.TrimSpace(parts[1])
-		case strings.HasPrefix(line, "flags"):
-			flags = strings.TrimSpace(parts[1])
+		switch strings.TrimSpace(key) {
+		case "vendor_id":
+			vendors[value] = true
+		case "model name":
+			models[value] = true
+		case "flags":
+			for _, flag := range strings.Fields(value) {
+				flags[flag] = true
+			}
 		}
 	}
 
 	// Combine CPU information for unique identifier
-	return fmt.Sprintf("%s:%s:%s:%s", processor, vendorID, modelName, flags)
+	return fmt.Sprintf("%s:%s:%s",
+		strings.Join(slices.Sorted(maps.Keys(vendors)), ","),
+		strings.Join(slices.Sorted(maps.Keys(models)), ","),
+		strings.Join(slices.Sorted(maps.Keys(flags)), " "),
+	)
 }
 
-// linuxSystemUUID retrieves system UUID from DMI.
-func linuxSystemUUID(logger *slog.Logger) (string, error) {
+// linuxSystemUUID retrieves system UUID from DMI, falling back to dmidecode.
+func linuxSystemUUID(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
 	// Try multiple locations for system UUID
 	locations := []string{
 		"/sys/class/dmi/id/product_uuid",
 		"/sys/devices/virtual/dmi/id/product_uuid",
 	}
 
-	return readFirstValidFromLocations(locations, isValidUUID, logger)
+	return readDMIValue(ctx, executor, locations, "system-uuid", isValidUUID, logger)
 }
 
-// linuxMotherboardSerial retrieves motherboard serial number from DMI.
-func linuxMotherboardSerial(logger *slog.Logger) (string, error) {
+// linuxMotherboardSerial retrieves motherboard serial number from DMI,
+// falling back to dmidecode.
+func linuxMotherboardSerial(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
 	locations := []string{
 		"/sys/class/dmi/id/board_serial",
 		"/sys/devices/virtual/dmi/id/board_serial",
 	}
 
-	return readFirstValidFromLocations(locations, isValidSerial, logger)
+	return readDMIValue(ctx, executor, locations, "baseboard-serial-number", isValidSerial, logger)
+}
+
+// readDMIValue reads the first valid value from the sysfs DMI locations. When
+// none holds one, as when the files are unreadable or read as all zeros
+// without root, it runs `dmidecode -s keyword` instead. If dmidecode fails too,
+// the sysfs error is returned.
+func readDMIValue(ctx context.Context, executor CommandExecutor, locations []string, keyword string, validator func(string) bool, logger *slog.Logger) (string, error) {
+	value, err := readFirstValidFromLocations(locations, validator, logger)
+	if err == nil {
+		return value, nil
+	}
+
+	if logger != nil {
+		logger.Info("sysfs DMI value unavailable, falling back to dmidecode", "keyword", keyword, "error", err)
+	}
+
+	output, dmiErr := executeCommand(ctx, executor, logger, "dmidecode", "-s", keyword)
+	if dmiErr == nil {
+		value, dmiErr = parseDmidecodeString(output, validator)
+	}
+	if dmiErr != nil {
+		if logger != nil {
+			logger.Debug("dmidecode fallback failed", "keyword", keyword, "error", dmiErr)
+		}
+
+		return "", err
+	}
+
+	return value, nil
+}
+
+// isLinuxWireless reports whether i is a wireless interface: the kernel
+// creates /sys/class/net/<iface>/wireless for them. Interfaces missing from
+// sysfs are classified by name.
+func (p *Provider) isLinuxWireless(i net.Interface) bool {
+	fsys := p.filesystem()
+	dir := "sys/class/net/" + i.Name
+	if _, err := fs.Stat(fsys, dir); err != nil {
+		return isWirelessNetInterface(i)
+	}
+
+	_, err := fs.Stat(fsys, dir+"/wireless")
+
+	return err == nil
 }
 
 // linuxMachineID retrieves systemd machine ID.
@@ -131,8 +370,45 @@ func linuxMachineID(logger *slog.Logger) (string, error) {
 	return readFirstValidFromLocations(locations, isNonEmpty, logger)
 }
 
+// linuxBestUUID returns the most reliable UUID available and the name of its
+// source, falling back to the systemd machine-id only if machineID is set.
+func linuxBestUUID(machineID bool, logger *slog.Logger) (string, string, error) {
+	var machineIDLocations []string
+	if machineID {
+		machineIDLocations = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+	}
+
+	return selectBestUUID(
+		[]string{"/sys/class/dmi/id/product_uuid", "/sys/devices/virtual/dmi/id/product_uuid"},
+		machineIDLocations,
+		logger,
+	)
+}
+
+// selectBestUUID prefers a reliable DMI UUID and falls back to the systemd machine-id.
+func selectBestUUID(uuidLocations, machineIDLocations []string, logger *slog.Logger) (string, string, error) {
+	if uuid, err := readFirstValidFromLocations(uuidLocations, isReliableUUID, logger); err == nil {
+		return uuid, "product_uuid", nil
+	}
+
+	if logger != nil && len(machineIDLocations) > 0 {
+		logger.Info("DMI UUID unavailable or unreliable, using systemd machine-id")
+	}
+
+	machineID, err := readFirstValidFromLocations(machineIDLocations, isNonEmpty, logger)
+	if err != nil {
+		return "", "", ErrAllMethodsFailed
+	}
+
+	return machineID, "machine-id", nil
+}
+
 // readFirstValidFromLocations reads from multiple locations until a valid value is found.
+// Locations that only held OEM placeholders fail with [ErrOEMPlaceholder], and
+// locations that exist but could not be read for lack of permission with
+// [ErrPermissionDenied].
 func readFirstValidFromLocations(locations []string, validator func(string) bool, logger *slog.Logger) (string, error) {
+	placeholder, denied := "", ""
 	for _, location := range locations {
 		data, err := os.ReadFile(location)
 		if err == nil {
@@ -145,25 +421,45 @@ func readFirstValidFromLocations(locations []string, validator func(string) bool
 				return value, nil
 			}
 
+			if isOEMPlaceholder(value) && placeholder == "" {
+				placeholder = location
+			}
+
 			if logger != nil {
 				logger.Debug("file value failed validation", "path", location)
 			}
-		} else if logger != nil {
+
+			continue
+		}
+
+		if errors.Is(err, fs.ErrPermission) && denied == "" {
+			denied = location
+		}
+
+		if logger != nil {
 			logger.Debug("failed to read file", "path", location, "error", err)
 		}
 	}
 
+	if placeholder != "" {
+		return "", &ParseError{Source: placeholder, Err: ErrOEMPlaceholder}
+	}
+
+	if denied != "" {
+		return "", &ParseError{Source: denied, Err: ErrPermissionDenied}
+	}
+
 	return "", ErrNotFound
 }
 
-// isValidUUID reports whether the UUID is valid (not empty or null).
+// isValidUUID reports whether the UUID is valid (not empty, null or placeholder).
 func isValidUUID(uuid string) bool {
-	return uuid != "" && uuid != "00000000-0000-0000-0000-000000000000"
+	return uuid != "" && uuid != "00000000-0000-0000-0000-000000000000" && !isOEMPlaceholder(uuid)
 }
 
 // isValidSerial reports whether the serial is valid (not empty or placeholder).
 func isValidSerial(serial string) bool {
-	return serial != "" && serial != biosFirmwareMessage
+	return serial != "" && !isOEMPlaceholder(serial)
 }
 
 // isNonEmpty reports whether the value is not empty.
@@ -173,13 +469,14 @@ func isNonEmpty(value string) bool {
 
 // linuxDiskSerials retrieves disk serial numbers using various methods.
 // Results are deduplicated across sources to prevent the same serial
-// from appearing multiple times.
-func linuxDiskSerials(ctx context.Context, executor CommandExecutor, logger *slog.Logger) ([]string, error) {
+// from appearing multiple times. Disks are classified as internal or removable
+// for filter from sysfs in fsys.
+func linuxDiskSerials(ctx context.Context, executor CommandExecutor, fsys fs.FS, filter DiskFilter, logger *slog.Logger) ([]string, error) {
 	seen := make(map[string]struct{})
 	var serials []string
 
 	// Try using lsblk command first
-	if lsblkSerials, err := linuxDiskSerialsLSBLK(ctx, executor, logger); err == nil {
+	if lsblkSerials, err := linuxDiskSerialsLSBLK(ctx, executor, fsys, filter, logger); err == nil {
 		for _, s := range lsblkSerials {
 			if _, exists := seen[s]; !exists {
 				seen[s] = struct{}{}
@@ -195,7 +492,7 @@ func linuxDiskSerials(ctx context.Context, executor CommandExecutor, logger *slo
 	}
 
 	// Try reading from /sys/block
-	if sysSerials, err := linuxDiskSerialsSys(logger); err == nil {
+	if sysSerials, err := linuxDiskSerialsSys(fsys, filter, logger); err == nil {
 		for _, s := range sysSerials {
 			if _, exists := seen[s]; !exists {
 				seen[s] = struct{}{}
@@ -213,9 +510,108 @@ func linuxDiskSerials(ctx context.Context, executor CommandExecutor, logger *slo
 	return serials, nil
 }
 
-// linuxDiskSerialsLSBLK retrieves disk serials using lsblk command.
-func linuxDiskSerialsLSBLK(ctx context.Context, executor CommandExecutor, logger *slog.Logger) ([]string, error) {
-	output, err := executeCommand(ctx, executor, logger, "lsblk", "-d", "-n", "-o", "SERIAL")
+// nvmeUnavailableNote is recorded in [DiagnosticInfo.Notes] when
+// [Provider.WithNVMeDiskIDs] is set but the nvme tool cannot list drives.
+const nvmeUnavailableNote = "nvme unavailable, using disk serials"
+
+// nvmeList represents the JSON output of `nvme list -o json`.
+type nvmeList struct {
+	Devices []struct {
+		DevicePath   string `json:"DevicePath"`
+		SerialNumber string `json:"SerialNumber"`
+	} `json:"Devices"`
+}
+
+// nvmeIDNamespace holds the namespace identifiers of `nvme id-ns -o json` output.
+type nvmeIDNamespace struct {
+	NGUID string `json:"nguid"`
+	EUI64 string `json:"eui64"`
+}
+
+// preferNVMeIDs replaces the serial of every NVMe drive in serials with its
+// namespace identifier, if it reports one. Serials are returned unchanged when
+// nvme-cli is unavailable.
+func preferNVMeIDs(ctx context.Context, executor CommandExecutor, serials []string, diag *DiagnosticInfo, logger *slog.Logger) []string {
+	ids, err := linuxNVMeIDs(ctx, executor, logger)
+	if err != nil {
+		addNote(diag, nvmeUnavailableNote)
+		if logger != nil {
+			logger.Info(nvmeUnavailableNote, "error", err)
+		}
+
+		return serials
+	}
+
+	preferred := make([]string, 0, len(serials))
+	for _, serial := range serials {
+		if id, ok := ids[serial]; ok {
+			serial = id
+		}
+		if !slices.Contains(preferred, serial) {
+			preferred = append(preferred, serial)
+		}
+	}
+
+	return preferred
+}
+
+// linuxNVMeIDs maps the serial of every NVMe drive that reports a namespace
+// identifier to that identifier, in the "eui.<hex>" form used by /dev/disk/by-id.
+func linuxNVMeIDs(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (map[string]string, error) {
+	output, err := executeCommand(ctx, executor, logger, "nvme", "list", "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	var list nvmeList
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, &ParseError{Source: "nvme list JSON", Err: err}
+	}
+
+	ids := make(map[string]string)
+	for _, device := range list.Devices {
+		serial := strings.TrimSpace(device.SerialNumber)
+		if serial == "" || device.DevicePath == "" {
+			continue
+		}
+
+		output, err := executeCommand(ctx, executor, logger, "nvme", "id-ns", device.DevicePath, "-o", "json")
+		if err != nil {
+			continue
+		}
+		if id, err := parseNVMeIDNamespace(output); err == nil {
+			ids[serial] = id
+		} else if logger != nil {
+			logger.Debug("no NVMe namespace identifier", "device", device.DevicePath, "error", err)
+		}
+	}
+
+	return ids, nil
+}
+
+// parseNVMeIDNamespace extracts the namespace identifier from `nvme id-ns -o json`
+// output, preferring the 128-bit NGUID over the EUI-64. Unset identifiers are
+// reported by the drive as all zeros.
+func parseNVMeIDNamespace(jsonOutput string) (string, error) {
+	var ns nvmeIDNamespace
+	if err := json.Unmarshal([]byte(jsonOutput), &ns); err != nil {
+		return "", &ParseError{Source: "nvme id-ns JSON", Err: err}
+	}
+
+	for _, id := range []string{ns.NGUID, ns.EUI64} {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if strings.Trim(id, "0") != "" {
+			return "eui." + id, nil
+		}
+	}
+
+	return "", &ParseError{Source: "nvme id-ns JSON", Err: ErrNotFound}
+}
+
+// linuxDiskSerialsLSBLK retrieves the serials of the disks passing filter
+// using lsblk.
+func linuxDiskSerialsLSBLK(ctx context.Context, executor CommandExecutor, fsys fs.FS, filter DiskFilter, logger *slog.Logger) ([]string, error) {
+	output, err := executeCommand(ctx, executor, logger, "lsblk", "-d", "-n", "-o", "NAME,SERIAL")
 	if err != nil {
 		return nil, err
 	}
@@ -223,36 +619,41 @@ func linuxDiskSerialsLSBLK(ctx context.Context, executor CommandExecutor, logger
 	var serials []string
 	lines := strings.SplitSeq(output, "\n")
 	for line := range lines {
-		serial := strings.TrimSpace(line)
-		if serial != "" {
-			serials = append(serials, serial)
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !filter.includes(!isRemovableBlockDevice(fsys, fields[0])) {
+			continue
 		}
+
+		serials = append(serials, strings.Join(fields[1:], " "))
 	}
 
 	return serials, nil
 }
 
-// linuxDiskSerialsSys retrieves disk serials from /sys/block.
-func linuxDiskSerialsSys(logger *slog.Logger) ([]string, error) {
+// linuxDiskSerialsSys retrieves the serials of the disks passing filter from
+// /sys/block.
+func linuxDiskSerialsSys(fsys fs.FS, filter DiskFilter, logger *slog.Logger) ([]string, error) {
 	var serials []string
 
-	blockDir := "/sys/block"
-	entries, err := os.ReadDir(blockDir)
+	blockDir := "sys/block"
+	entries, err := fs.ReadDir(fsys, blockDir)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, entry := range entries {
-		if entry.IsDir() && !strings.HasPrefix(entry.Name(), "loop") {
-			serialFile := filepath.Join(blockDir, entry.Name(), "device", "serial")
-			if data, err := os.ReadFile(serialFile); err == nil {
-				serial := strings.TrimSpace(string(data))
-				if serial != "" {
-					serials = append(serials, serial)
-
-					if logger != nil {
-						logger.Debug("read disk serial from sysfs", "disk", entry.Name(), "path", serialFile)
-					}
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), "loop") || !filter.includes(!isRemovableBlockDevice(fsys, entry.Name())) {
+			continue
+		}
+
+		serialFile := path.Join(blockDir, entry.Name(), "device", "serial")
+		if data, err := fs.ReadFile(fsys, serialFile); err == nil {
+			serial := strings.TrimSpace(string(data))
+			if serial != "" {
+				serials = append(serials, serial)
+
+				if logger != nil {
+					logger.Debug("read disk serial from sysfs", "disk", entry.Name(), "path", "/"+serialFile)
 				}
 			}
 		}
@@ -260,3 +661,138 @@ func linuxDiskSerialsSys(logger *slog.Logger) ([]string, error) {
 
 	return serials, nil
 }
+
+// linuxGPUIDs returns one "vendor:device@address" value per PCI graphics card
+// in /sys/class/drm, such as "10de:2484@0000:01:00.0". Connector entries
+// ("card0-HDMI-A-1") and cards without PCI IDs, such as virtual ones, are skipped.
+func linuxGPUIDs(fsys fs.FS, logger *slog.Logger) ([]string, error) {
+	drmDir := "sys/class/drm"
+	entries, err := fs.ReadDir(fsys, drmDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "card") || strings.Contains(name, "-") {
+			continue
+		}
+
+		device := path.Join(drmDir, name, "device")
+		vendorID, vendorErr := readPCIID(fsys, path.Join(device, "vendor"))
+		deviceID, deviceErr := readPCIID(fsys, path.Join(device, "device"))
+		if vendorErr != nil || deviceErr != nil {
+			if logger != nil {
+				logger.Debug("skipping DRM card without PCI IDs", "card", name)
+			}
+
+			continue
+		}
+
+		id := vendorID + ":" + deviceID
+		if address := pciSlotName(fsys, path.Join(device, "uevent")); address != "" {
+			id += "@" + address
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+// linuxMemory retrieves the total online memory from sysfs. Module serials
+// are not used, since dmidecode reports them only to root and the value would
+// then depend on who runs the program. When sysfs exposes no memory blocks,
+// the total installed size reported by dmidecode is used instead.
+func linuxMemory(ctx context.Context, executor CommandExecutor, fsys fs.FS, logger *slog.Logger) ([]string, error) {
+	total, err := linuxMemorySys(fsys)
+	if err == nil {
+		return memoryValues(nil, total, "/sys/devices/system/memory")
+	}
+
+	if logger != nil {
+		logger.Info("falling back to dmidecode for total memory", "error", err)
+	}
+
+	output, cmdErr := executeCommand(ctx, executor, logger, "dmidecode", "-t", "memory")
+	if cmdErr != nil {
+		return nil, err
+	}
+	_, total = parseDmidecodeMemory(output)
+
+	return memoryValues(nil, total, "dmidecode output")
+}
+
+// linuxMemorySys returns the total online memory in bytes: the memory block
+// size times the number of online memory blocks.
+func linuxMemorySys(fsys fs.FS) (uint64, error) {
+	memoryDir := "sys/devices/system/memory"
+	data, err := fs.ReadFile(fsys, path.Join(memoryDir, "block_size_bytes"))
+	if err != nil {
+		return 0, err
+	}
+
+	blockSize, err := strconv.ParseUint(strings.TrimSpace(string(data)), 16, 64)
+	if err != nil {
+		return 0, &ParseError{Source: "/sys/devices/system/memory/block_size_bytes", Err: err}
+	}
+
+	entries, err := fs.ReadDir(fsys, memoryDir)
+	if err != nil {
+		return 0, err
+	}
+
+	var online uint64
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "memory") {
+			continue
+		}
+		if state, err := fs.ReadFile(fsys, path.Join(memoryDir, entry.Name(), "online")); err == nil && strings.TrimSpace(string(state)) == "1" {
+			online++
+		}
+	}
+
+	return blockSize * online, nil
+}
+
+// readPCIID reads a sysfs PCI ID file such as "0x10de\n" as "10de".
+func readPCIID(fsys fs.FS, name string) (string, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", err
+	}
+
+	id := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(string(data))), "0x")
+	if id == "" {
+		return "", &ParseError{Source: "/" + name, Err: ErrEmptyValue}
+	}
+
+	return id, nil
+}
+
+// pciSlotName returns the PCI_SLOT_NAME of a sysfs uevent file, or "" if it
+// cannot be read.
+func pciSlotName(fsys fs.FS, name string) string {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return ""
+	}
+
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if address, ok := strings.CutPrefix(strings.TrimSpace(line), "PCI_SLOT_NAME="); ok {
+			return address
+		}
+	}
+
+	return ""
+}
+
+// isRemovableBlockDevice reports whether the kernel flags the block device
+// name as removable, as it does for USB flash drives and card readers.
+func isRemovableBlockDevice(fsys fs.FS, name string) bool {
+	data, err := fs.ReadFile(fsys, path.Join("sys/block", name, "removable"))
+
+	return err == nil && strings.TrimSpace(string(data)) == "1"
+}
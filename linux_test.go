@@ -0,0 +1,676 @@
+//go:build linux
+
+package machineid
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// TestIsReliableUUID tests rejection of unset firmware UUIDs.
+func TestIsReliableUUID(t *testing.T) {
+	tests := []struct {
+		uuid string
+		want bool
+	}{
+		{"4C4C4544-0042-3510-8052-B4C04F384833", true},
+		{"00000000-0000-0000-0000-000000000000", false},
+		{"FFFFFFFF-FFFF-FFFF-FFFF-FFFFFFFFFFFF", false},
+		{"ffffffff-ffff-ffff-ffff-ffffffffffff", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isReliableUUID(tt.uuid); got != tt.want {
+			t.Errorf("isReliableUUID(%q) = %v, want %v", tt.uuid, got, tt.want)
+		}
+	}
+}
+
+// TestSelectBestUUID tests preference of DMI UUID and fallback to machine-id.
+func TestSelectBestUUID(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	validUUID := write("valid_uuid", "4C4C4544-0042-3510-8052-B4C04F384833\n")
+	zeroUUID := write("zero_uuid", "00000000-0000-0000-0000-000000000000\n")
+	machineID := write("machine-id", "0123456789abcdef0123456789abcdef\n")
+	missing := filepath.Join(dir, "missing")
+
+	value, source, err := selectBestUUID([]string{validUUID}, []string{machineID}, nil)
+	if err != nil {
+		t.Fatalf("selectBestUUID() error = %v", err)
+	}
+	if value != "4C4C4544-0042-3510-8052-B4C04F384833" || source != "product_uuid" {
+		t.Errorf("selectBestUUID() = %q, %q; want DMI UUID from product_uuid", value, source)
+	}
+
+	value, source, err = selectBestUUID([]string{zeroUUID}, []string{machineID}, nil)
+	if err != nil {
+		t.Fatalf("selectBestUUID() error = %v", err)
+	}
+	if value != "0123456789abcdef0123456789abcdef" || source != "machine-id" {
+		t.Errorf("selectBestUUID() = %q, %q; want machine-id fallback", value, source)
+	}
+
+	if _, _, err = selectBestUUID([]string{missing}, []string{missing}, nil); err == nil {
+		t.Error("selectBestUUID() expected error when no source is available")
+	}
+}
+
+// TestReadFirstValidFromLocationsPlaceholder tests that locations holding only
+// OEM placeholders fail with ErrOEMPlaceholder rather than ErrNotFound.
+func TestReadFirstValidFromLocationsPlaceholder(t *testing.T) {
+	dir := t.TempDir()
+	placeholder := filepath.Join(dir, "board_serial")
+	if err := os.WriteFile(placeholder, []byte("Default string\n"), 0o600); err != nil {
+		t.Fatalf("failed to write board_serial: %v", err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	_, err := readFirstValidFromLocations([]string{placeholder, missing}, isValidSerial, nil)
+	if !errors.Is(err, ErrOEMPlaceholder) {
+		t.Errorf("readFirstValidFromLocations() error = %v, want ErrOEMPlaceholder", err)
+	}
+
+	_, err = readFirstValidFromLocations([]string{missing}, isValidSerial, nil)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("readFirstValidFromLocations() error = %v, want ErrNotFound", err)
+	}
+}
+
+// TestReadFirstValidFromLocationsPermission tests that unreadable locations
+// fail with ErrPermissionDenied rather than ErrNotFound.
+func TestReadFirstValidFromLocationsPermission(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read files regardless of their mode")
+	}
+
+	dir := t.TempDir()
+	unreadable := filepath.Join(dir, "product_uuid")
+	if err := os.WriteFile(unreadable, []byte("4C4C4544-0042-3510-8052-B4C04F384833\n"), 0o000); err != nil {
+		t.Fatalf("failed to write product_uuid: %v", err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	_, err := readFirstValidFromLocations([]string{missing, unreadable}, isValidUUID, nil)
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("readFirstValidFromLocations() error = %v, want ErrPermissionDenied", err)
+	}
+	if errors.Is(err, ErrNotFound) {
+		t.Error("a permission failure should not be reported as ErrNotFound")
+	}
+}
+
+// TestReadDMIValueDmidecodeFallback tests that dmidecode is used when the
+// sysfs DMI value is invalid, and that the sysfs error is kept when it fails.
+func TestReadDMIValueDmidecodeFallback(t *testing.T) {
+	ctx := context.Background()
+	const uuid = "4C4C4544-0042-3510-8052-B4C04F384833"
+	dir := t.TempDir()
+	zeroUUID := filepath.Join(dir, "product_uuid")
+	if err := os.WriteFile(zeroUUID, []byte("00000000-0000-0000-0000-000000000000\n"), 0o600); err != nil {
+		t.Fatalf("failed to write product_uuid: %v", err)
+	}
+	validUUID := filepath.Join(dir, "valid_uuid")
+	if err := os.WriteFile(validUUID, []byte(uuid+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write valid_uuid: %v", err)
+	}
+
+	executor := newMockExecutor()
+	executor.setOutputForArgs("dmidecode", []string{"-s", "system-uuid"}, uuid+"\n")
+
+	value, err := readDMIValue(ctx, executor, []string{zeroUUID}, "system-uuid", isValidUUID, nil)
+	if err != nil {
+		t.Fatalf("readDMIValue() error = %v", err)
+	}
+	if value != uuid {
+		t.Errorf("readDMIValue() = %q, want %q", value, uuid)
+	}
+
+	executor = newMockExecutor()
+	if _, err := readDMIValue(ctx, executor, []string{validUUID}, "system-uuid", isValidUUID, nil); err != nil {
+		t.Fatalf("readDMIValue() error = %v", err)
+	}
+	if executor.callCount["dmidecode"] != 0 {
+		t.Error("dmidecode should not run when sysfs holds a valid value")
+	}
+
+	executor.setError("dmidecode", errors.New("permission denied"))
+	if _, err := readDMIValue(ctx, executor, []string{zeroUUID}, "system-uuid", isValidUUID, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("readDMIValue() error = %v, want the sysfs ErrNotFound", err)
+	}
+}
+
+// TestDMITimeouts tests that components that can fall back to dmidecode get
+// a command-sized default timeout rather than the file-read one.
+func TestDMITimeouts(t *testing.T) {
+	for _, component := range []string{ComponentSystemUUID, ComponentMotherboard, ComponentMemory} {
+		if got := DefaultComponentTimeouts()[component]; got < 10*time.Second {
+			t.Errorf("default %s timeout = %v, want at least 10s", component, got)
+		}
+	}
+}
+
+// TestParseOSRelease tests extraction of the distribution version.
+func TestParseOSRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"pretty name", "NAME=\"Ubuntu\"\nVERSION_ID=\"24.04\"\nPRETTY_NAME=\"Ubuntu 24.04.1 LTS\"\n", "Ubuntu 24.04.1 LTS"},
+		{"name and version", "NAME=Alpine Linux\nVERSION_ID=3.20.3\n", "Alpine Linux 3.20.3"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseOSRelease(tt.content); got != tt.want {
+				t.Errorf("parseOSRelease() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseCPUInfo tests that heterogeneous processor blocks aggregate to the
+// same identifier regardless of their order or per-core index.
+func TestParseCPUInfo(t *testing.T) {
+	performance := "processor\t: 0\nvendor_id\t: GenuineIntel\nmodel name\t: Core P\nflags\t\t: fpu sse avx\n\n"
+	efficiency := "processor\t: 1\nvendor_id\t: GenuineIntel\nmodel name\t: Core E\nflags\t\t: sse fpu\n\n"
+
+	forward := parseCPUInfo(performance + efficiency)
+	reverse := parseCPUInfo(efficiency + performance)
+	if forward != reverse {
+		t.Errorf("parseCPUInfo() depends on block order: %q != %q", forward, reverse)
+	}
+
+	want := "GenuineIntel:Core E,Core P:avx fpu sse"
+	if forward != want {
+		t.Errorf("parseCPUInfo() = %q, want %q", forward, want)
+	}
+
+	renumbered := strings.Replace(performance, "processor\t: 0", "processor\t: 7", 1)
+	if got := parseCPUInfo(renumbered + efficiency); got != forward {
+		t.Errorf("parseCPUInfo() depends on the processor index: %q != %q", got, forward)
+	}
+}
+
+// TestSupportedComponents tests that Linux reports every component, including machine-id.
+func TestSupportedComponents(t *testing.T) {
+	got := SupportedComponents()
+	for _, component := range []string{ComponentCPU, ComponentMotherboard, ComponentSystemUUID, ComponentMAC, ComponentDisk, ComponentMachineID, ComponentTPM, ComponentGPU, ComponentMemory} {
+		if !slices.Contains(got, component) {
+			t.Errorf("SupportedComponents() = %v, missing %q", got, component)
+		}
+	}
+
+	got[0] = "mutated"
+	if SupportedComponents()[0] != ComponentCPU {
+		t.Error("SupportedComponents() returned a shared slice")
+	}
+}
+
+// TestWithFallbackToMachineID tests that a collection yielding no identifiers
+// fails without the fallback, and uses the systemd machine-id with it.
+func TestWithFallbackToMachineID(t *testing.T) {
+	ctx := context.Background()
+	machineIDFS := fstest.MapFS{
+		"etc/machine-id": {Data: []byte("0123456789abcdef0123456789abcdef\n")},
+	}
+
+	tests := []struct {
+		name     string
+		fallback bool
+		rootFS   fstest.MapFS
+		wantErr  error
+	}{
+		{"disabled", false, machineIDFS, ErrNoIdentifiers},
+		{"enabled", true, machineIDFS, nil},
+		{"no machine-id", true, fstest.MapFS{}, ErrNoIdentifiers},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New().WithMAC()
+			if tt.fallback {
+				p.WithFallbackToMachineID()
+			}
+			p.listInterfaces = failingInterfaces
+			p.rootFS = tt.rootFS
+
+			id, err := p.ID(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ID() error = %v, want %v", err, tt.wantErr)
+			}
+			diag := p.Diagnostics()
+			if diag.Errors[ComponentMAC] == nil {
+				t.Error("MAC error should still be reported")
+			}
+			if tt.wantErr != nil {
+				if tt.fallback && diag.Errors[ComponentOSMachineID] == nil {
+					t.Error("expected an os-machine-id error")
+				}
+
+				return
+			}
+
+			if id == "" {
+				t.Error("ID() returned an empty ID")
+			}
+			if !slices.Equal(diag.Collected, []string{ComponentOSMachineID}) {
+				t.Errorf("Collected = %v, want [%s]", diag.Collected, ComponentOSMachineID)
+			}
+			if !slices.Contains(diag.Notes, "no identifiers collected, using os-machine-id") {
+				t.Errorf("Notes = %v, want a fallback note", diag.Notes)
+			}
+		})
+	}
+}
+
+// TestWithTPMAbsent tests that a machine without a TPM records ErrNotFound
+// for the component and still generates an ID from the others.
+func TestWithTPMAbsent(t *testing.T) {
+	mock := newMockExecutor()
+	p := New().WithExecutor(mock).WithMAC().WithTPM()
+	p.listInterfaces = physicalOnlyInterfaces
+	p.rootFS = fstest.MapFS{}
+
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+
+	diag := p.Diagnostics()
+	if !errors.Is(diag.Errors[ComponentTPM], ErrNotFound) {
+		t.Errorf("tpm error = %v, want ErrNotFound", diag.Errors[ComponentTPM])
+	}
+	if !slices.Equal(diag.Collected, []string{ComponentMAC}) {
+		t.Errorf("Collected = %v, want [%s]", diag.Collected, ComponentMAC)
+	}
+	if mock.callCount["tpm2_getekcertificate"] != 0 {
+		t.Error("tpm2_getekcertificate should not run without a TPM")
+	}
+}
+
+// permissionFS is a test file system in which the listed files exist but
+// cannot be opened, like root-only sysfs files for an unprivileged user.
+type permissionFS struct {
+	fstest.MapFS
+	denied map[string]bool
+}
+
+// Open implements fs.FS.
+func (f permissionFS) Open(name string) (fs.File, error) {
+	if f.denied[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+
+	return f.MapFS.Open(name)
+}
+
+// unprivilegedFS simulates a machine whose DMI files are readable only by root.
+func unprivilegedFS() fs.FS {
+	return permissionFS{
+		MapFS: fstest.MapFS{
+			"proc/cpuinfo":   {Data: []byte("processor : 0\n")},
+			"etc/machine-id": {Data: []byte("0123456789abcdef0123456789abcdef\n")},
+		},
+		denied: map[string]bool{
+			"sys/class/dmi/id/product_uuid":           true,
+			"sys/devices/virtual/dmi/id/product_uuid": true,
+			"sys/class/dmi/id/board_serial":           true,
+			"sys/devices/virtual/dmi/id/board_serial": true,
+		},
+	}
+}
+
+// TestUnprivilegedComponents tests that components backed by unreadable DMI
+// files are not reported as collectable.
+func TestUnprivilegedComponents(t *testing.T) {
+	p := New().WithCPU().WithMotherboard().WithSystemUUID().WithMAC()
+	p.rootFS = unprivilegedFS()
+
+	got := p.UnprivilegedComponents(context.Background())
+	want := []string{ComponentCPU, ComponentMAC}
+	if !slices.Equal(got, want) {
+		t.Errorf("UnprivilegedComponents() = %v, want %v", got, want)
+	}
+
+	best := New().WithSystemUUID().WithBestUUID()
+	best.rootFS = unprivilegedFS()
+	if got := best.UnprivilegedComponents(context.Background()); !slices.Equal(got, []string{ComponentSystemUUID}) {
+		t.Errorf("UnprivilegedComponents() with best UUID = %v, want [%s] via machine-id", got, ComponentSystemUUID)
+	}
+}
+
+// TestUnprivilegedComponentsMemory tests that memory is collectable without
+// root when the sysfs total memory fallback is readable.
+func TestUnprivilegedComponentsMemory(t *testing.T) {
+	p := New().WithMemory()
+	p.rootFS = fstest.MapFS{
+		"sys/devices/system/memory/block_size_bytes": {Data: []byte("8000000\n")},
+	}
+	if got := p.UnprivilegedComponents(context.Background()); !slices.Equal(got, []string{ComponentMemory}) {
+		t.Errorf("UnprivilegedComponents() = %v, want [%s] via sysfs", got, ComponentMemory)
+	}
+
+	if os.Geteuid() == 0 {
+		return
+	}
+	p.rootFS = fstest.MapFS{}
+	if got := p.UnprivilegedComponents(context.Background()); len(got) != 0 {
+		t.Errorf("UnprivilegedComponents() = %v, want none without dmidecode or sysfs", got)
+	}
+}
+
+// TestWithoutMachineID tests that the machine-id is neither collected nor
+// reported in diagnostics when suppressed, and is no longer a best UUID source.
+func TestWithoutMachineID(t *testing.T) {
+	p := New().WithSystemUUID().WithoutMachineID()
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	identifiers, err := collectIdentifiers(context.Background(), p, diag)
+	if err != nil {
+		t.Fatalf("collectIdentifiers() error = %v", err)
+	}
+	for _, identifier := range identifiers {
+		if strings.HasPrefix(identifier, "machine:") {
+			t.Errorf("identifiers contain %q, want no machine-id", identifier)
+		}
+	}
+	if _, ok := diag.Errors[ComponentMachineID]; ok || slices.Contains(diag.Collected, ComponentMachineID) {
+		t.Errorf("diagnostics list %s: collected %v, errors %v", ComponentMachineID, diag.Collected, diag.Errors)
+	}
+
+	best := New().WithBestUUID().WithoutMachineID()
+	best.rootFS = unprivilegedFS()
+	if got := best.UnprivilegedComponents(context.Background()); len(got) != 0 {
+		t.Errorf("UnprivilegedComponents() = %v, want none without the machine-id fallback", got)
+	}
+}
+
+// TestWithUnprivilegedOnly tests that components requiring privileges are
+// skipped without being probed, and noted in diagnostics.
+func TestWithUnprivilegedOnly(t *testing.T) {
+	p := New().WithSystemUUID().WithUnprivilegedOnly()
+	p.rootFS = unprivilegedFS()
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+	ctx := context.Background()
+
+	called := false
+	identifiers := p.appendIdentifier(ctx, nil, func(context.Context) (string, error) {
+		called = true
+		return "uuid", nil
+	}, "uuid:", diag, ComponentSystemUUID)
+	identifiers = p.appendIdentifier(ctx, identifiers, func(context.Context) (string, error) {
+		return "0123456789abcdef0123456789abcdef", nil
+	}, "machine:", diag, ComponentMachineID)
+
+	if called {
+		t.Error("privileged component was collected")
+	}
+	if !slices.Equal(identifiers, []string{"machine:0123456789abcdef0123456789abcdef"}) {
+		t.Errorf("identifiers = %v, want only machine-id", identifiers)
+	}
+	if len(diag.Errors) != 0 {
+		t.Errorf("diag.Errors = %v, want none", diag.Errors)
+	}
+	if !slices.Contains(diag.Notes, ComponentSystemUUID+" skipped: requires elevated privileges") {
+		t.Errorf("diag.Notes = %v, want a skipped-component note", diag.Notes)
+	}
+}
+
+// nvmeListJSON is captured `nvme list -o json` output of a server with two
+// NVMe drives.
+const nvmeListJSON = `{
+  "Devices" : [
+    {
+      "NameSpace" : 1,
+      "DevicePath" : "/dev/nvme0n1",
+      "Firmware" : "GDC5602Q",
+      "Index" : 0,
+      "ModelNumber" : "SAMSUNG MZQL23T8HCLS-00A07",
+      "SerialNumber" : "S64HNE0R612345",
+      "UsedBytes" : 1059328000,
+      "MaximumLBA" : 7501476528,
+      "PhysicalSize" : 3840755982336,
+      "SectorSize" : 512
+    },
+    {
+      "NameSpace" : 1,
+      "DevicePath" : "/dev/nvme1n1",
+      "Firmware" : "1.2.0",
+      "Index" : 1,
+      "ModelNumber" : "Generic NVMe SSD",
+      "SerialNumber" : "GEN0000123",
+      "UsedBytes" : 0,
+      "MaximumLBA" : 1000215216,
+      "PhysicalSize" : 512110190592,
+      "SectorSize" : 512
+    }
+  ]
+}`
+
+// nvmeIDNamespaceJSON is captured `nvme id-ns /dev/nvme0n1 -o json` output,
+// trimmed to the identification fields.
+const nvmeIDNamespaceJSON = `{
+  "nsze" : 7501476528,
+  "ncap" : 7501476528,
+  "nuse" : 2068992,
+  "nsfeat" : 26,
+  "nlbaf" : 1,
+  "flbas" : 0,
+  "nguid" : "36344830526123450025384500000001",
+  "eui64" : "002538b521b01234"
+}`
+
+// TestLinuxGPUIDs tests reading the PCI IDs and address of each DRM card,
+// skipping connectors and cards without PCI IDs.
+func TestLinuxGPUIDs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sys/class/drm/card0/device/vendor": {Data: []byte("0x8086\n")},
+		"sys/class/drm/card0/device/device": {Data: []byte("0x3E9B\n")},
+		"sys/class/drm/card0/device/uevent": {Data: []byte("DRIVER=i915\nPCI_ID=8086:3E9B\nPCI_SLOT_NAME=0000:00:02.0\n")},
+		"sys/class/drm/card0-HDMI-A-1/dpms": {Data: []byte("On\n")},
+		"sys/class/drm/card1/device/vendor": {Data: []byte("0x10de\n")},
+		"sys/class/drm/card1/device/device": {Data: []byte("0x2484\n")},
+		"sys/class/drm/card1/device/uevent": {Data: []byte("DRIVER=nvidia\nPCI_SLOT_NAME=0000:01:00.0\n")},
+		"sys/class/drm/card2/device/uevent": {Data: []byte("DRIVER=vkms\n")},
+		"sys/class/drm/renderD128/dev":      {Data: []byte("226:128\n")},
+		"sys/class/drm/version":             {Data: []byte("drm 1.1.0 20060810\n")},
+		"sys/class/drm/card3/device/vendor": {Data: []byte("0x1af4\n")},
+		"sys/class/drm/card3/device/device": {Data: []byte("0x1050\n")},
+	}
+
+	got, err := linuxGPUIDs(fsys, nil)
+	if err != nil {
+		t.Fatalf("linuxGPUIDs() error = %v", err)
+	}
+	want := []string{"8086:3e9b@0000:00:02.0", "10de:2484@0000:01:00.0", "1af4:1050"}
+	if !slices.Equal(got, want) {
+		t.Errorf("linuxGPUIDs() = %v, want %v", got, want)
+	}
+
+	if _, err := linuxGPUIDs(fstest.MapFS{}, nil); err == nil {
+		t.Error("linuxGPUIDs() expected an error without /sys/class/drm")
+	}
+}
+
+// TestLinuxMemory tests that the total online memory in sysfs is used whether
+// or not dmidecode can read the modules, and that dmidecode's total is used
+// only without sysfs.
+func TestLinuxMemory(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sys/devices/system/memory/block_size_bytes":   {Data: []byte("8000000\n")},
+		"sys/devices/system/memory/memory0/online":     {Data: []byte("1\n")},
+		"sys/devices/system/memory/memory1/online":     {Data: []byte("1\n")},
+		"sys/devices/system/memory/memory2/online":     {Data: []byte("0\n")},
+		"sys/devices/system/memory/auto_online_blocks": {Data: []byte("online\n")},
+	}
+
+	mock := newMockExecutor()
+	mock.setOutputForArgs("dmidecode", []string{"-t", "memory"}, dmidecodeMemoryOutput)
+	got, err := linuxMemory(context.Background(), mock, fsys, nil)
+	if err != nil {
+		t.Fatalf("linuxMemory() error = %v", err)
+	}
+	if want := []string{"total:268435456"}; !slices.Equal(got, want) {
+		t.Errorf("linuxMemory() = %v, want %v", got, want)
+	}
+	if mock.callCount["dmidecode"] != 0 {
+		t.Error("dmidecode should not run when sysfs reports the total")
+	}
+
+	got, err = linuxMemory(context.Background(), mock, fstest.MapFS{}, nil)
+	if err != nil {
+		t.Fatalf("linuxMemory() without sysfs error = %v", err)
+	}
+	if want := []string{"total:34359738368"}; !slices.Equal(got, want) {
+		t.Errorf("linuxMemory() without sysfs = %v, want %v", got, want)
+	}
+
+	mock.setError("dmidecode", &CommandError{Command: "dmidecode", Err: os.ErrPermission})
+	if _, err := linuxMemory(context.Background(), mock, fstest.MapFS{}, nil); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("linuxMemory() error = %v, want the sysfs fs.ErrNotExist without dmidecode and sysfs", err)
+	}
+}
+
+// TestLinuxDiskSerialsFilter tests that disks are selected by their sysfs
+// removable flag, from both lsblk and /sys/block.
+func TestLinuxDiskSerialsFilter(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutputForArgs("lsblk", []string{"-d", "-n", "-o", "NAME,SERIAL"},
+		"sda     S3Z9NB0K123456A\nsdb     4C530001231115117313\nsr0\n")
+	fsys := fstest.MapFS{
+		"sys/block/sda/removable":         {Data: []byte("0\n")},
+		"sys/block/sdb/removable":         {Data: []byte("1\n")},
+		"sys/block/nvme0n1/removable":     {Data: []byte("0\n")},
+		"sys/block/nvme0n1/device/serial": {Data: []byte("PHKE123400AB1P0B\n")},
+		"sys/block/mmcblk0/removable":     {Data: []byte("1\n")},
+		"sys/block/mmcblk0/device/serial": {Data: []byte("0x1234abcd\n")},
+		"sys/block/loop0/device/serial":   {Data: []byte("LOOP\n")},
+	}
+
+	tests := []struct {
+		filter DiskFilter
+		want   []string
+	}{
+		{DiskFilterInternal, []string{"S3Z9NB0K123456A", "PHKE123400AB1P0B"}},
+		{DiskFilterAll, []string{"S3Z9NB0K123456A", "4C530001231115117313", "0x1234abcd", "PHKE123400AB1P0B"}},
+		{DiskFilterExternal, []string{"4C530001231115117313", "0x1234abcd"}},
+	}
+
+	for _, tt := range tests {
+		got, err := linuxDiskSerials(context.Background(), mock, fsys, tt.filter, nil)
+		if err != nil {
+			t.Fatalf("linuxDiskSerials(filter %d) error = %v", tt.filter, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("linuxDiskSerials(filter %d) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+// TestParseNVMeIDNamespace tests that the NGUID is preferred over the EUI-64
+// and that all-zero identifiers are treated as unset.
+func TestParseNVMeIDNamespace(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{"nguid", nvmeIDNamespaceJSON, "eui.36344830526123450025384500000001", false},
+		{"eui64 only", `{"nguid" : "00000000000000000000000000000000", "eui64" : "002538B521B01234"}`, "eui.002538b521b01234", false},
+		{"unset", `{"nguid" : "00000000000000000000000000000000", "eui64" : "0000000000000000"}`, "", true},
+		{"invalid", "not json", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNVMeIDNamespace(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNVMeIDNamespace() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseNVMeIDNamespace() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPreferNVMeIDs tests that NVMe serials are replaced by namespace
+// identifiers where reported, and that a missing nvme tool leaves the serials
+// unchanged with a diagnostic note.
+func TestPreferNVMeIDs(t *testing.T) {
+	serials := []string{"S64HNE0R612345", "GEN0000123", "WD-WCC4N1234567"}
+
+	mock := newMockExecutor()
+	mock.setOutputForArgs("nvme", []string{"list", "-o", "json"}, nvmeListJSON)
+	mock.setOutputForArgs("nvme", []string{"id-ns", "/dev/nvme0n1", "-o", "json"}, nvmeIDNamespaceJSON)
+	mock.setOutputForArgs("nvme", []string{"id-ns", "/dev/nvme1n1", "-o", "json"}, `{"nguid" : "00000000000000000000000000000000", "eui64" : "0000000000000000"}`)
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	got := preferNVMeIDs(context.Background(), mock, slices.Clone(serials), diag, nil)
+	want := []string{"eui.36344830526123450025384500000001", "GEN0000123", "WD-WCC4N1234567"}
+	if !slices.Equal(got, want) {
+		t.Errorf("preferNVMeIDs() = %v, want %v", got, want)
+	}
+	if len(diag.Notes) != 0 {
+		t.Errorf("diag.Notes = %v, want none", diag.Notes)
+	}
+
+	missing := newMockExecutor()
+	missing.setError("nvme", &CommandError{Command: "nvme", Err: exec.ErrNotFound})
+	diag = &DiagnosticInfo{Errors: make(map[string]error)}
+
+	if got := preferNVMeIDs(context.Background(), missing, slices.Clone(serials), diag, nil); !slices.Equal(got, serials) {
+		t.Errorf("preferNVMeIDs(no nvme) = %v, want %v", got, serials)
+	}
+	if !slices.Contains(diag.Notes, nvmeUnavailableNote) {
+		t.Errorf("diag.Notes = %v, want %q", diag.Notes, nvmeUnavailableNote)
+	}
+}
+
+// TestIsLinuxWireless tests wireless detection through sysfs, falling back to
+// the interface name for interfaces missing from sysfs.
+func TestIsLinuxWireless(t *testing.T) {
+	p := New()
+	p.rootFS = fstest.MapFS{
+		"sys/class/net/wlan0/wireless/.keep": {},
+		"sys/class/net/eth0/address":         {Data: []byte("00:1b:21:00:00:02\n")},
+		"sys/class/net/wl-usb/address":       {Data: []byte("00:1b:21:00:00:03\n")},
+	}
+
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"wlan0", true},
+		{"eth0", false},
+		{"wl-usb", false}, // sysfs wins over the name
+		{"wlp3s0", true},  // not in sysfs, classified by name
+	}
+
+	for _, tt := range tests {
+		if got := p.isLinuxWireless(net.Interface{Name: tt.name}); got != tt.expected {
+			t.Errorf("isLinuxWireless(%q) = %v, want %v", tt.name, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package machineid
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultSuspiciousSerialPatterns returns the serial templates rejected by
+// [Provider.WithSuspiciousSerialDetection] when it is called without patterns:
+// firmware defaults and counters that virtualization and cloud platforms
+// assign to every instance of a fleet.
+func DefaultSuspiciousSerialPatterns() []*regexp.Regexp {
+	return []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^(default string|not specified|not applicable|system serial number|chassis serial number|none|n/?a|serial|0123456789|123456789)$`),
+		regexp.MustCompile(`(?i)^(vm|instance|host|node|server)[-_ ]?0*[0-9]{1,3}$`),
+	}
+}
+
+// lowEntropyComponents lists the components whose values are serial numbers
+// checked by [Provider.WithSuspiciousSerialDetection].
+var lowEntropyComponents = map[string]bool{
+	ComponentMotherboard: true,
+	ComponentSystemUUID:  true,
+	ComponentDisk:        true,
+}
+
+// WithSuspiciousSerialDetection rejects motherboard, system UUID and disk
+// values that look valid but do not distinguish machines: a single repeated
+// character ("FFFFFFFF"), a sequential run ("123456789"), a zero-padded counter
+// ("0000000000000001"), or a match of one of patterns. Without patterns,
+// [DefaultSuspiciousSerialPatterns] are used. A rejected single value is
+// recorded in [DiagnosticInfo.Errors] as [ErrLowEntropy]; a rejected disk is
+// dropped with a note, and the component fails with [ErrLowEntropy] only if
+// every disk was rejected.
+//
+// Anti-fraud systems can use it to avoid trusting serials shared by a fleet
+// of cloud instances. Enabling it changes IDs on machines with such serials.
+func (p *Provider) WithSuspiciousSerialDetection(patterns ...*regexp.Regexp) *Provider {
+	if len(patterns) == 0 {
+		patterns = DefaultSuspiciousSerialPatterns()
+	}
+	p.suspiciousSerials = patterns
+
+	return p
+}
+
+// checkEntropy returns an error wrapping [ErrLowEntropy] if suspicious serial
+// detection is enabled and value of component is low-entropy. The error, which
+// reaches diagnostics, notes, logs and bundles, names the value only in the
+// form produced by [Provider.WithRedactor], or as a short digest by default.
+func (p *Provider) checkEntropy(component, value string) error {
+	if p.suspiciousSerials == nil || !lowEntropyComponents[component] {
+		return nil
+	}
+
+	if isLowEntropy(value, p.suspiciousSerials) {
+		return fmt.Errorf("%w: %s", ErrLowEntropy, p.bundleRedactor()(component, value))
+	}
+
+	return nil
+}
+
+// filterLowEntropy removes the low-entropy values of a multi-value component,
+// noting each in diag. It returns an error wrapping [ErrLowEntropy] if no value
+// remains.
+func (p *Provider) filterLowEntropy(component string, values []string, diag *DiagnosticInfo) ([]string, error) {
+	if p.suspiciousSerials == nil || !lowEntropyComponents[component] {
+		return values, nil
+	}
+
+	kept := values[:0:0]
+	for _, value := range values {
+		if err := p.checkEntropy(component, value); err != nil {
+			if diag != nil {
+				diag.Notes = append(diag.Notes, component+" value ignored: "+err.Error())
+			}
+
+			continue
+		}
+		kept = append(kept, value)
+	}
+
+	if len(kept) == 0 && len(values) > 0 {
+		return nil, ErrLowEntropy
+	}
+
+	return kept, nil
+}
+
+// isLowEntropy reports whether value is a repeated character, a sequential
+// run, a zero-padded counter, or matches one of patterns. Dashes and spaces
+// are ignored for the structural checks, so UUIDs such as
+// 00000000-0000-0000-0000-000000000001 are caught too.
+func isLowEntropy(value string, patterns []*regexp.Regexp) bool {
+	value = strings.TrimSpace(value)
+	for _, pattern := range patterns {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+
+	compact := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(value))
+	if len(compact) < 4 {
+		return false
+	}
+
+	if len(compact) >= 8 && len(strings.TrimLeft(compact, "0")) <= 2 {
+		return true
+	}
+
+	repeated, ascending, descending := true, true, true
+	for i := 1; i < len(compact); i++ {
+		repeated = repeated && compact[i] == compact[0]
+		ascending = ascending && compact[i] == compact[i-1]+1
+		descending = descending && compact[i] == compact[i-1]-1
+	}
+
+	return repeated || ascending || descending
+}
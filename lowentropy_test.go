@@ -0,0 +1,98 @@
+package machineid
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestIsLowEntropy tests the structural checks and default templates against
+// templated VM serials and genuine ones.
+func TestIsLowEntropy(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"0000000000000001", true},
+		{"00000000-0000-0000-0000-000000000001", true},
+		{"FFFFFFFF", true},
+		{"123456789", true},
+		{"ABCDEFG", true},
+		{"98765432", true},
+		{"Default string", true},
+		{"VM-001", true},
+		{"C02ZK0XXMD6T", false},
+		{"4C4C4544-0042-3510-8052-B4C04F384833", false},
+		{"S64HNE0R612345", false},
+		{"PF2ABCDE", false},
+		{"123", false},
+	}
+
+	patterns := DefaultSuspiciousSerialPatterns()
+	for _, tt := range tests {
+		if got := isLowEntropy(tt.value, patterns); got != tt.want {
+			t.Errorf("isLowEntropy(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestWithSuspiciousSerialDetection tests that templated serials are recorded
+// as ErrLowEntropy while genuine serials are collected.
+func TestWithSuspiciousSerialDetection(t *testing.T) {
+	p := New().WithSuspiciousSerialDetection()
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	identifiers := p.appendIdentifier(context.Background(), nil, func(context.Context) (string, error) {
+		return "0000000000000001", nil
+	}, "mb:", diag, ComponentMotherboard)
+	identifiers = p.appendIdentifiers(context.Background(), identifiers, func(context.Context) ([]string, error) {
+		return []string{"S64HNE0R612345", "0000000000000000"}, nil
+	}, "disk:", diag, ComponentDisk)
+
+	if want := []string{"disk:S64HNE0R612345"}; !slices.Equal(identifiers, want) {
+		t.Errorf("identifiers = %v, want %v", identifiers, want)
+	}
+	if !errors.Is(diag.Errors[ComponentMotherboard], ErrLowEntropy) {
+		t.Errorf("motherboard error = %v, want ErrLowEntropy", diag.Errors[ComponentMotherboard])
+	}
+	if len(diag.Notes) != 1 {
+		t.Errorf("diag.Notes = %v, want one ignored disk", diag.Notes)
+	}
+	if strings.Contains(diag.Errors[ComponentMotherboard].Error(), "0000000000000001") ||
+		len(diag.Notes) == 1 && strings.Contains(diag.Notes[0], "0000000000000000") {
+		t.Errorf("errors %v and notes %v should not contain raw serials", diag.Errors, diag.Notes)
+	}
+
+	p.appendIdentifiers(context.Background(), nil, func(context.Context) ([]string, error) {
+		return []string{"FFFFFFFF"}, nil
+	}, "disk:", diag, ComponentDisk)
+	if !errors.Is(diag.Errors[ComponentDisk], ErrLowEntropy) {
+		t.Errorf("disk error = %v, want ErrLowEntropy when every disk is rejected", diag.Errors[ComponentDisk])
+	}
+}
+
+// TestWithSuspiciousSerialDetectionPatterns tests that custom patterns replace
+// the default templates and that other components are not checked.
+func TestWithSuspiciousSerialDetectionPatterns(t *testing.T) {
+	p := New().WithSuspiciousSerialDetection(regexp.MustCompile(`^ACME-TEMPLATE$`))
+
+	if err := p.checkEntropy(ComponentMotherboard, "ACME-TEMPLATE"); !errors.Is(err, ErrLowEntropy) {
+		t.Errorf("checkEntropy(custom template) = %v, want ErrLowEntropy", err)
+	}
+	if err := p.checkEntropy(ComponentMotherboard, "Default string"); err != nil {
+		t.Errorf("checkEntropy(default template) = %v, want nil with custom patterns", err)
+	}
+	masked := New().WithSuspiciousSerialDetection().WithRedactor(func(_, value string) string { return "****" + value[len(value)-2:] })
+	if err := masked.checkEntropy(ComponentMotherboard, "0000000000000001"); err == nil || !strings.HasSuffix(err.Error(), ": ****01") {
+		t.Errorf("checkEntropy() with redactor = %v, want the redacted value", err)
+	}
+	if err := p.checkEntropy(ComponentCPU, "FFFFFFFF"); err != nil {
+		t.Errorf("checkEntropy(cpu) = %v, want nil", err)
+	}
+	if err := New().checkEntropy(ComponentMotherboard, "FFFFFFFF"); err != nil {
+		t.Errorf("checkEntropy() without detection = %v, want nil", err)
+	}
+}
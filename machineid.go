@@ -2,11 +2,25 @@ package machineid
 
 import (
 	"context"
+	"crypto/hkdf"
+	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
 	"log/slog"
+	"maps"
+	"net"
+	"regexp"
 	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -38,6 +52,50 @@ const (
 	// MACFilterVirtual includes only virtual network interfaces
 	// (VPN, bridge, container, and hypervisor interfaces).
 	MACFilterVirtual
+	// MACFilterWired includes only physical interfaces that are not wireless.
+	// Newer operating systems randomize Wi-Fi MAC addresses per network, so
+	// excluding them keeps laptop IDs stable.
+	MACFilterWired
+)
+
+// DiskFilter controls which disks are included in disk collection; see
+// [Provider.WithDiskFilter].
+type DiskFilter int
+
+const (
+	// DiskFilterInternal includes only internal, non-removable disks (default).
+	DiskFilterInternal DiskFilter = iota
+	// DiskFilterAll includes internal and external disks.
+	DiskFilterAll
+	// DiskFilterExternal includes only external or removable disks, such as a
+	// USB dongle deliberately pinned to a kiosk.
+	DiskFilterExternal
+)
+
+// includes reports whether a disk that is internal or not passes the filter.
+func (f DiskFilter) includes(internal bool) bool {
+	switch f {
+	case DiskFilterAll:
+		return true
+	case DiskFilterExternal:
+		return !internal
+	default:
+		return internal
+	}
+}
+
+// CPUSource selects where the macOS CPU identifier comes from; see
+// [Provider.WithMacCPUSource].
+type CPUSource int
+
+const (
+	// CPUSourceAuto tries sysctl first and falls back to system_profiler (default).
+	CPUSourceAuto CPUSource = iota
+	// CPUSourceSysctl uses only sysctl's brand string and features,
+	// e.g. "Apple M2 Pro:" on Apple Silicon.
+	CPUSourceSysctl
+	// CPUSourceProfiler uses only system_profiler's chip type, e.g. "Apple M2 Pro".
+	CPUSourceProfiler
 )
 
 // String returns the string representation of the MACFilter.
@@ -47,6 +105,8 @@ func (f MACFilter) String() string {
 		return "all"
 	case MACFilterVirtual:
 		return "virtual"
+	case MACFilterWired:
+		return "wired"
 	default:
 		return "physical"
 	}
@@ -59,17 +119,44 @@ const (
 	ComponentSystemUUID  = "uuid"
 	ComponentMAC         = "mac"
 	ComponentDisk        = "disk"
-	ComponentMachineID   = "machine-id" // Linux systemd machine-id
+	ComponentMachineID   = "machine-id"  // Linux systemd machine-id or FreeBSD hostid
+	ComponentThunderbolt = "thunderbolt" // macOS Thunderbolt host controller
+	ComponentModel       = "model"       // macOS hardware model and board-id
+	ComponentTPM         = "tpm"         // TPM Endorsement Key digest
+	ComponentGPU         = "gpu"         // graphics adapter PCI IDs or models
+	ComponentMemory      = "memory"      // DIMM serials or total RAM
+
+	// ComponentOSMachineID is the OS machine identifier collected by
+	// [Provider.WithFallbackToMachineID] when no other component succeeded.
+	ComponentOSMachineID = "os-machine-id"
 )
 
+// SupportedComponents returns the names of the components that have a
+// collector on the current platform, in canonical order. Selecting any other
+// component never contributes to the ID. [ComponentMachineID] exists only on
+// Linux and FreeBSD and is collected together with [ComponentSystemUUID].
+func SupportedComponents() []string {
+	return slices.Clone(supportedComponents)
+}
+
 // defaultTimeout is the default timeout for system command execution.
 const defaultTimeout = 5 * time.Second
 
 // DiagnosticInfo contains information about what was collected during ID generation.
 // Use [Provider.Diagnostics] to retrieve this information after calling [Provider.ID].
 type DiagnosticInfo struct {
-	Errors    map[string]error // Component names that failed with their errors
-	Collected []string         // Component names that were successfully collected
+	Errors     map[string]error    // Component names that failed with their errors
+	Collected  []string            // Component names that were successfully collected, in canonical component order
+	UUIDSource string              // UUID source chosen by [Provider.WithBestUUID], if enabled
+	Duplicates map[string][]string // Components whose value duplicates that of the listed components
+	Notes      []string            // Informational notes about the collection environment, e.g. missing tools
+	Absent     []string            // Optional components that legitimately returned no value
+	Platform   string              // Operating system (runtime.GOOS)
+	Arch       string              // Architecture (runtime.GOARCH)
+	OSVersion  string              // OS version, if probed via [Provider.WithOSVersionProbe]
+	Cached     []string            // Components that failed and fell back to [Provider.WithComponentCache]
+
+	probeMu *sync.Mutex // Guards fields written by concurrent component probes, while they run
 }
 
 // CommandExecutor is an interface for executing system commands, allowing for dependency injection and testing.
@@ -81,19 +168,100 @@ type CommandExecutor interface {
 // After the first call to [Provider.ID], the configuration is frozen and the result is cached.
 // Provider methods are safe for concurrent use after configuration is complete.
 type Provider struct {
-	commandExecutor    CommandExecutor
-	logger             *slog.Logger
-	diagnostics        *DiagnosticInfo
-	salt               string
-	cachedID           string
-	formatMode         FormatMode
-	mu                 sync.Mutex
-	includeCPU         bool
-	includeMotherboard bool
-	includeSystemUUID  bool
-	includeMAC         bool
-	macFilter          MACFilter
-	includeDisk        bool
+	commandExecutor     CommandExecutor
+	logger              *slog.Logger
+	diagnostics         *DiagnosticInfo
+	salt                string
+	cachedID            string
+	cachedHash          string
+	cachedFingerprint   string
+	cachedUUID          string
+	formatMode          FormatMode
+	formatErr           error
+	mu                  sync.Mutex
+	uppercase           bool
+	encoding            Encoding
+	includeCPU          bool
+	includeMotherboard  bool
+	includeSystemUUID   bool
+	includeMAC          bool
+	macFilter           MACFilter
+	includeDisk         bool
+	diskFilter          DiskFilter
+	includeThunderbolt  bool
+	includeModel        bool
+	includeTPM          bool
+	includeGPU          bool
+	includeMemory       bool
+	sourceConfig        []sourceConfigEntry
+	eventSink           func([]byte)
+	normalizeUnicode    bool
+	bestUUID            bool
+	excludeMachineID    bool
+	fallbackToMachineID bool
+	fastMode            bool
+	deduplicate         bool
+	componentValues     map[string][]string
+	componentTimeout    time.Duration
+	commandTimeout      time.Duration
+	timeSource          Clock
+	timeWindow          time.Duration
+	clock               func() time.Time
+	cachedWindow        int64
+	maxTotalDuration    time.Duration
+	collectDeadline     time.Time
+	parallel            bool
+	probeConcurrency    int
+	prefetch            *prefetchGroup
+	prefetched          map[string]prefetchResult
+	cleanCPUFormat      bool
+	componentDurations  map[string]time.Duration
+	lastDuration        time.Duration
+	unredactedBundle    bool
+	identifierWriter    io.Writer
+	lazyLogger          func() *slog.Logger
+	listInterfaces      func() ([]net.Interface, error)
+	optional            map[string]bool
+	strictValidation    bool
+	strict              bool
+	minComponents       int
+	macMaxCount         int
+	macExclude          []string
+	macAllow            []string
+	valueTransforms     map[string]func(string) string
+	componentPrefixes   map[string]string
+	probeOSVersion      bool
+	macCPUSource        CPUSource
+	componentCacheDir   string
+	persistentCachePath string
+	componentWeights    map[string]float64
+	errorCallback       func(component string, err error)
+	setHashing          map[string]bool
+	unprivilegedOnly    bool
+	rootFS              fs.FS
+	observer            func(CollectionEvent)
+	fallbackChains      []fallbackChain
+	fallbackAsPrimary   bool
+	canonicalDisks      bool
+	defaultLogger       bool
+	profile             bool
+	spans               []Span
+	profiledComponent   string
+	componentTimeouts   map[string]time.Duration
+	personalization     string
+	configBinding       bool
+	redactor            func(component, value string) string
+	nvmeDiskIDs         bool
+	hmacKey             []byte
+	hasher              func() hash.Hash
+	suspiciousSerials   []*regexp.Regexp
+	displayNames        map[string]string
+}
+
+// sourceConfigEntry is a key/value pair registered with [Provider.WithSourceConfig].
+type sourceConfigEntry struct {
+	key   any
+	value any
 }
 
 // New creates a new Provider with default settings.
@@ -108,6 +276,102 @@ func New() *Provider {
 	}
 }
 
+// Clone returns a copy of the provider's configuration with its own mutex, an
+// empty ID cache and no diagnostics, so a base provider can be branched, for
+// example with different salts, and the copies used concurrently. Maps and
+// slices are copied, as is the default executor; a custom executor, the
+// logger, filesystem and callbacks are shared.
+func (p *Provider) Clone() *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	executor := p.commandExecutor
+	if defaultExecutor, ok := executor.(*defaultCommandExecutor); ok {
+		copied := *defaultExecutor
+		executor = &copied
+	}
+
+	chains := make([]fallbackChain, len(p.fallbackChains))
+	for i, chain := range p.fallbackChains {
+		chains[i] = fallbackChain{primary: chain.primary, alternates: slices.Clone(chain.alternates)}
+	}
+
+	return &Provider{
+		commandExecutor:     executor,
+		logger:              p.logger,
+		salt:                p.salt,
+		formatMode:          p.formatMode,
+		formatErr:           p.formatErr,
+		uppercase:           p.uppercase,
+		encoding:            p.encoding,
+		includeCPU:          p.includeCPU,
+		includeMotherboard:  p.includeMotherboard,
+		includeSystemUUID:   p.includeSystemUUID,
+		includeMAC:          p.includeMAC,
+		macFilter:           p.macFilter,
+		includeDisk:         p.includeDisk,
+		diskFilter:          p.diskFilter,
+		includeThunderbolt:  p.includeThunderbolt,
+		includeModel:        p.includeModel,
+		includeTPM:          p.includeTPM,
+		includeGPU:          p.includeGPU,
+		includeMemory:       p.includeMemory,
+		sourceConfig:        slices.Clone(p.sourceConfig),
+		eventSink:           p.eventSink,
+		normalizeUnicode:    p.normalizeUnicode,
+		bestUUID:            p.bestUUID,
+		excludeMachineID:    p.excludeMachineID,
+		fallbackToMachineID: p.fallbackToMachineID,
+		fastMode:            p.fastMode,
+		deduplicate:         p.deduplicate,
+		componentTimeout:    p.componentTimeout,
+		commandTimeout:      p.commandTimeout,
+		timeSource:          p.timeSource,
+		timeWindow:          p.timeWindow,
+		clock:               p.clock,
+		maxTotalDuration:    p.maxTotalDuration,
+		parallel:            p.parallel,
+		probeConcurrency:    p.probeConcurrency,
+		cleanCPUFormat:      p.cleanCPUFormat,
+		unredactedBundle:    p.unredactedBundle,
+		identifierWriter:    p.identifierWriter,
+		lazyLogger:          p.lazyLogger,
+		listInterfaces:      p.listInterfaces,
+		optional:            maps.Clone(p.optional),
+		strictValidation:    p.strictValidation,
+		strict:              p.strict,
+		minComponents:       p.minComponents,
+		macMaxCount:         p.macMaxCount,
+		macExclude:          slices.Clone(p.macExclude),
+		macAllow:            slices.Clone(p.macAllow),
+		valueTransforms:     maps.Clone(p.valueTransforms),
+		componentPrefixes:   maps.Clone(p.componentPrefixes),
+		probeOSVersion:      p.probeOSVersion,
+		macCPUSource:        p.macCPUSource,
+		componentCacheDir:   p.componentCacheDir,
+		persistentCachePath: p.persistentCachePath,
+		componentWeights:    maps.Clone(p.componentWeights),
+		errorCallback:       p.errorCallback,
+		setHashing:          maps.Clone(p.setHashing),
+		unprivilegedOnly:    p.unprivilegedOnly,
+		rootFS:              p.rootFS,
+		fallbackChains:      chains,
+		fallbackAsPrimary:   p.fallbackAsPrimary,
+		canonicalDisks:      p.canonicalDisks,
+		defaultLogger:       p.defaultLogger,
+		profile:             p.profile,
+		componentTimeouts:   maps.Clone(p.componentTimeouts),
+		personalization:     p.personalization,
+		configBinding:       p.configBinding,
+		redactor:            p.redactor,
+		nvmeDiskIDs:         p.nvmeDiskIDs,
+		hmacKey:             slices.Clone(p.hmacKey),
+		hasher:              p.hasher,
+		suspiciousSerials:   slices.Clone(p.suspiciousSerials),
+		displayNames:        maps.Clone(p.displayNames),
+	}
+}
+
 // WithSalt sets a custom salt for additional entropy.
 func (p *Provider) WithSalt(salt string) *Provider {
 	p.salt = salt
@@ -115,14 +379,213 @@ func (p *Provider) WithSalt(salt string) *Provider {
 	return p
 }
 
+// WithSaltBytes sets a binary salt, such as raw key material, without hex or
+// other text encoding. The bytes are copied and hashed verbatim, so
+// WithSaltBytes([]byte(s)) is equivalent to [Provider.WithSalt](s). Both set
+// the same salt: the last call wins. An empty salt disables salting.
+func (p *Provider) WithSaltBytes(salt []byte) *Provider {
+	p.salt = string(salt)
+
+	return p
+}
+
+// WithHMAC computes the ID as an HMAC-SHA256 of the identifiers keyed with
+// key, instead of a plain SHA-256 over the salt-prefixed identifiers, so the ID
+// is cryptographically bound to a secret that the host does not reveal. A salt
+// set with [Provider.WithSalt] is still mixed into the message. The output
+// formats are unchanged. A nil key restores plain hashing.
+func (p *Provider) WithHMAC(key []byte) *Provider {
+	p.hmacKey = slices.Clone(key)
+
+	return p
+}
+
+// WithHasher replaces SHA-256 with the hash function constructed by newHash,
+// such as sha512.New, for environments that mandate a specific algorithm. It
+// also keys [Provider.WithHMAC] and extends [Format128] and [Format256]. IDs
+// keep the configured [FormatMode] length: a longer digest is truncated, and a
+// shorter one extended by rehashing. Changing the hash function changes IDs.
+// A nil newHash restores SHA-256.
+func (p *Provider) WithHasher(newHash func() hash.Hash) *Provider {
+	p.hasher = newHash
+
+	return p
+}
+
+// newHash returns the hash constructor configured with [Provider.WithHasher],
+// or sha256.New.
+func (p *Provider) newHash() func() hash.Hash {
+	if p.hasher != nil {
+		return p.hasher
+	}
+
+	return sha256.New
+}
+
+// WithPersonalization prepends a fixed domain tag, such as "machineid-v1", to
+// the hash input so that machine IDs never collide with digests other tools
+// compute over similar hardware values. Unlike [Provider.WithSalt], which
+// separates applications using this library, the personalization string
+// identifies the library or ID scheme itself and is meant to stay constant
+// across applications. It changes every ID, so it is opt-in.
+func (p *Provider) WithPersonalization(s string) *Provider {
+	p.personalization = s
+
+	return p
+}
+
+// WithConfigBinding appends a "config:<hash>" identifier derived from the
+// provider's configuration (enabled components, format, whether a salt,
+// personalization or HMAC key is set, and the options that affect component values), so
+// two differently configured providers never produce the same ID, even on a
+// machine where their component values coincide. The trade-off is that any
+// configuration change, such as enabling an option that has no effect on this
+// machine, rotates the ID.
+func (p *Provider) WithConfigBinding() *Provider {
+	p.configBinding = true
+
+	return p
+}
+
+// WithRedactor sets the function that produces the displayed form of
+// component values wherever they leave the provider: debug logs and
+// [Provider.WriteFingerprintBundle] bundles, where it replaces the built-in
+// digest redaction. The hash always uses the raw values. Snapshots still carry
+// raw values, since they exist to compare them. Without a redactor, logs show
+// raw values, as before.
+//
+// For example, to show only the last four characters of every value:
+//
+//	p.WithRedactor(func(component, value string) string {
+//		if len(value) <= 4 {
+//			return "****"
+//		}
+//		return "****" + value[len(value)-4:]
+//	})
+func (p *Provider) WithRedactor(redact func(component, value string) string) *Provider {
+	p.redactor = redact
+
+	return p
+}
+
+// WithTimeWindow mixes the start of the current time window of length d into
+// the salt, producing IDs that are stable within a window and rotate across
+// windows, e.g. daily with d = 24 * time.Hour. clock supplies the current time;
+// nil uses the [Provider.WithClock] clock, if any, else [time.Now].
+//
+// This deliberately gives up the "stable across reboots" property and is meant
+// only for short-lived device tokens, never for licensing or persistent
+// identification. The cached ID is regenerated when the window changes.
+func (p *Provider) WithTimeWindow(d time.Duration, clock func() time.Time) *Provider {
+	p.timeWindow = d
+	p.clock = clock
+
+	return p
+}
+
 // WithFormat sets the output format and length.
-// Use [Format64] (default), [Format32], [Format128], or [Format256].
+// Use [Format64] (default), [Format32], [Format128], or [Format256]. Any other
+// mode makes [Provider.ID] fail with [ErrInvalidFormat] until a valid mode is set.
 func (p *Provider) WithFormat(mode FormatMode) *Provider {
 	p.formatMode = mode
+	p.formatErr = nil
+	if !isValidFormat(mode) {
+		p.formatErr = fmt.Errorf("%w: FormatMode(%d), want Format32, Format64, Format128 or Format256", ErrInvalidFormat, int(mode))
+	}
+
+	return p
+}
+
+// WithUppercase emits the machine ID using uppercase hex digits instead of the
+// default lowercase. [Provider.Validate] compares in the configured case, so a
+// stored ID must use the same case to validate.
+func (p *Provider) WithUppercase() *Provider {
+	p.uppercase = true
+
+	return p
+}
+
+// WithUnicodeNormalization applies Unicode NFC normalization to every collected
+// hardware value before hashing. This matters only for internationalized OEM
+// fields (for example, serials or model names with accented letters), which
+// different tools may report in precomposed or decomposed form. ASCII values,
+// the overwhelming majority, are unaffected. Disabled by default.
+func (p *Provider) WithUnicodeNormalization() *Provider {
+	p.normalizeUnicode = true
+
+	return p
+}
+
+// WithCanonicalDiskSerials reduces every disk serial to its bare alphanumeric
+// form before hashing: hex-encoded serials are decoded, spaces, separators and
+// trailing dots are removed, and letters are uppercased. On dual-boot machines
+// the disk component then contributes the same value on every OS where the
+// underlying serial is the same. It cannot help where an OS does not report the
+// serial at all: macOS often identifies SATA and Apple internal disks by model
+// only, so a dual-boot Mac keeps a different disk value under macOS than under
+// Linux or Windows. Enabling it changes IDs that include disks.
+func (p *Provider) WithCanonicalDiskSerials() *Provider {
+	p.canonicalDisks = true
+
+	return p
+}
+
+// WithNVMeDiskIDs makes the Linux disk component prefer the globally unique
+// namespace identifier (NGUID, or EUI-64 when no NGUID is set) that NVMe drives
+// report through nvme-cli over their vendor serial string. Drives without one,
+// and non-NVMe disks, keep their serial. When the nvme tool is not installed,
+// [DiagnosticInfo.Notes] records it and the serials are used as before.
+// It has no effect on other platforms. Enabling it changes IDs that include
+// NVMe disks.
+func (p *Provider) WithNVMeDiskIDs() *Provider {
+	p.nvmeDiskIDs = true
+
+	return p
+}
+
+// WithComponentValueTransform registers fn to rewrite every value collected
+// for component (for example, [ComponentDisk]) before hashing, to reproduce the
+// normalization of a previous fingerprinting tool during migration.
+//
+// Transforms run after collection, OEM placeholder filtering, and Unicode
+// normalization, and before duplicate detection and hashing. A transform
+// returning "" makes the value count as empty. Registering a transform for the
+// same component again replaces the earlier one.
+func (p *Provider) WithComponentValueTransform(component string, fn func(string) string) *Provider {
+	if p.valueTransforms == nil {
+		p.valueTransforms = make(map[string]func(string) string)
+	}
+	p.valueTransforms[component] = fn
+
+	return p
+}
+
+// WithComponentPrefix sets the prefix that tags the values of component in
+// the hashed identifier string, such as "mb:" for [ComponentMotherboard],
+// including any separator. The prefixes are the same on every platform, so
+// the same hardware values produce the same ID on every OS; this escape hatch
+// pins a legacy prefix instead, for example "serial:" to keep the motherboard
+// IDs of macOS hosts generated before the prefixes were unified. Setting a
+// prefix for the same component again replaces the earlier one.
+func (p *Provider) WithComponentPrefix(component, prefix string) *Provider {
+	if p.componentPrefixes == nil {
+		p.componentPrefixes = make(map[string]string)
+	}
+	p.componentPrefixes[component] = prefix
 
 	return p
 }
 
+// prefixFor returns the [Provider.WithComponentPrefix] prefix of component,
+// or defaultPrefix if none is set.
+func (p *Provider) prefixFor(component, defaultPrefix string) string {
+	if prefix, ok := p.componentPrefixes[component]; ok {
+		return prefix
+	}
+
+	return defaultPrefix
+}
+
 // WithCPU includes the CPU identifier in the generation.
 func (p *Provider) WithCPU() *Provider {
 	p.includeCPU = true
@@ -130,6 +593,32 @@ func (p *Provider) WithCPU() *Provider {
 	return p
 }
 
+// WithCleanCPUFormat makes the macOS CPU identifier on Apple Silicon the bare
+// brand string, e.g. "Apple M1 Pro", instead of the default "Apple M1 Pro:"
+// whose trailing colon comes from the empty feature list. Intel Macs keep the
+// "brand:features" form, and other platforms are unaffected.
+//
+// WARNING: this changes the ID of every Apple Silicon Mac, invalidating IDs
+// stored by earlier versions (for example, license activations). Enable it only
+// for new deployments; the default preserves compatibility.
+func (p *Provider) WithCleanCPUFormat() *Provider {
+	p.cleanCPUFormat = true
+
+	return p
+}
+
+// WithMacCPUSource forces the macOS CPU identifier to come from a single
+// source instead of the default sysctl-first fallback, so the value in the hash
+// never depends on which tool happened to work. A forced source that fails
+// leaves the CPU component uncollected. The option has no effect on other
+// platforms. Forcing [CPUSourceProfiler] changes the ID of machines where sysctl
+// works.
+func (p *Provider) WithMacCPUSource(source CPUSource) *Provider {
+	p.macCPUSource = source
+
+	return p
+}
+
 // WithMotherboard includes the motherboard serial number in the generation.
 func (p *Provider) WithMotherboard() *Provider {
 	p.includeMotherboard = true
@@ -144,14 +633,48 @@ func (p *Provider) WithSystemUUID() *Provider {
 	return p
 }
 
+// WithBestUUID includes the system UUID, automatically selecting the most
+// reliable UUID source available on the current platform:
+//
+//   - Linux: the DMI product_uuid when it is valid, otherwise the systemd
+//     machine-id (DMI UUIDs are often all zeros or all F's in VMs)
+//   - macOS: IOPlatformUUID
+//   - Windows: the SMBIOS UUID when it is valid, otherwise the registry
+//     MachineGuid
+//
+// Exactly one UUID identifier is contributed, and the chosen source is
+// recorded in [DiagnosticInfo.UUIDSource]. IDs differ from those produced by
+// [Provider.WithSystemUUID].
+func (p *Provider) WithBestUUID() *Provider {
+	p.includeSystemUUID = true
+	p.bestUUID = true
+
+	return p
+}
+
+// WithoutMachineID stops [Provider.WithSystemUUID] from also collecting
+// [ComponentMachineID], the systemd machine-id on Linux or the hostid on
+// FreeBSD, while still collecting the firmware UUID. Container images often
+// copy or regenerate the machine-id, so it can make IDs of distinct hosts
+// collide or IDs of one host drift. On Linux it also removes the machine-id
+// fallback of [Provider.WithBestUUID]. Suppressed components do not appear in
+// [DiagnosticInfo]. Enabling it changes IDs.
+func (p *Provider) WithoutMachineID() *Provider {
+	p.excludeMachineID = true
+
+	return p
+}
+
 // WithMAC includes network interface MAC addresses in the generation.
-// An optional [MACFilter] controls which interfaces are included.
-// Default is [MACFilterPhysical], which excludes virtual, VPN, bridge,
-// and container interfaces for stability.
-func (p *Provider) WithMAC(filter ...MACFilter) *Provider {
+// Optional [MACOption] values, such as a [MACFilter] or [MACMaxCount], control
+// which addresses are included. The default filter is [MACFilterPhysical],
+// which excludes virtual, VPN, bridge, and container interfaces for stability.
+// Earlier releases took ...[MACFilter]; a []MACFilter slice must now be
+// declared as []MACOption to be spread into the call.
+func (p *Provider) WithMAC(opts ...MACOption) *Provider {
 	p.includeMAC = true
-	if len(filter) > 0 {
-		p.macFilter = filter[0]
+	for _, opt := range opts {
+		opt.applyMAC(p)
 	}
 
 	return p
@@ -164,87 +687,804 @@ func (p *Provider) WithDisk() *Provider {
 	return p
 }
 
+// WithDiskFilter selects which disks [Provider.WithDisk] collects. Only
+// internal disks are collected by default, so that plugging in a USB drive does
+// not change the ID; [DiskFilterExternal] instead binds the ID to external
+// media, and [DiskFilterAll] to both. macOS classifies disks with
+// system_profiler; Linux treats disks whose /sys/block/<dev>/removable flag is
+// set, such as USB flash drives and card readers, as external. USB hard disks
+// that report as fixed count as internal on Linux. Other platforms ignore the
+// filter.
+func (p *Provider) WithDiskFilter(filter DiskFilter) *Provider {
+	p.diskFilter = filter
+
+	return p
+}
+
+// WithThunderbolt includes the domain UUID of the Thunderbolt / USB4 host
+// controllers on macOS, a stable per-machine anchor that compensates for the
+// weak macOS disk signal (disk names are often only model names). Macs without
+// Thunderbolt report the component in [DiagnosticInfo.Absent] rather than as an
+// error. Thunderbolt has no collector on other platforms; see [SupportedComponents].
+func (p *Provider) WithThunderbolt() *Provider {
+	p.includeThunderbolt = true
+	p.WithOptionalComponents(ComponentThunderbolt)
+
+	return p
+}
+
+// WithMacModel includes the macOS hardware model identifier, such as
+// "MacBookPro16,1", together with the logic board's board-id. Combined with
+// the hardware serial it improves device classification and catches
+// logic-board replacements, which change the board-id. Apple Silicon Macs have
+// no board-id string, so the model is used alone. The model has no collector on
+// other platforms; see [SupportedComponents].
+func (p *Provider) WithMacModel() *Provider {
+	p.includeModel = true
+
+	return p
+}
+
+// WithTPM includes the SHA-256 digest of the TPM Endorsement Key, which is
+// burned into the TPM and survives OS reinstalls and disk replacements. On
+// Linux it is read with tpm2_getekcertificate, which requires access to
+// /dev/tpmrm0; on Windows with Get-TpmEndorsementKeyInfo, which requires
+// administrator rights. Machines without a TPM record [ErrNotFound] in
+// [DiagnosticInfo.Errors], and the ID is built from the other components. The
+// TPM has no collector on other platforms; see [SupportedComponents].
+func (p *Provider) WithTPM() *Provider {
+	p.includeTPM = true
+
+	return p
+}
+
+// WithGPU includes the graphics adapters, one value per GPU, for workstation
+// licenses bound to a discrete card. On Linux each value is the PCI vendor and
+// device ID with the PCI address from /sys/class/drm; on macOS the GPU model
+// (and device ID, where reported) from system_profiler SPDisplaysDataType; on
+// Windows the PCI PNPDeviceID of each video controller. Moving a card to
+// another slot changes the Linux and Windows values. Models and device IDs are
+// shared by every card of the same kind, so the GPU adds little uniqueness on
+// its own.
+func (p *Provider) WithGPU() *Provider {
+	p.includeGPU = true
+
+	return p
+}
+
+// WithMemory includes the memory configuration: the serial number of each
+// memory module or, when no module reports one, as on most laptops and
+// virtual machines, the total installed RAM. On Linux the modules are read
+// with dmidecode, which requires root, falling back to the online memory in
+// /sys/devices/system/memory; on macOS with system_profiler SPMemoryDataType;
+// on Windows from Win32_PhysicalMemory. Adding or replacing a module changes
+// the ID.
+func (p *Provider) WithMemory() *Provider {
+	p.includeMemory = true
+
+	return p
+}
+
+// WithAll enables every hardware component the package defines, like the CLI
+// -all flag, including platform-specific ones such as [Provider.WithTPM], which
+// are not collected on platforms without a collector; see [SupportedComponents].
+// Components added in later releases are enabled too, so IDs generated with
+// WithAll can change on upgrade; select components individually for IDs that
+// must stay stable. MAC addresses use the default filter unless
+// [Provider.WithMAC] is also called with options.
+func (p *Provider) WithAll() *Provider {
+	for _, flag := range p.componentFlags() {
+		*flag = true
+	}
+	p.WithOptionalComponents(ComponentThunderbolt)
+
+	return p
+}
+
+// WithOptionalComponents marks components as optional: when such a component
+// legitimately returns no value, for example [MACFilterVirtual] on a bare-metal
+// host without VPN or container interfaces, it is listed in
+// [DiagnosticInfo.Absent] instead of being recorded as an error. Optional
+// components that fail for other reasons are still reported in
+// [DiagnosticInfo.Errors]. An ID still requires at least one collected value, so
+// [ErrNoIdentifiers] is returned when every enabled component is absent.
+func (p *Provider) WithOptionalComponents(components ...string) *Provider {
+	if p.optional == nil {
+		p.optional = make(map[string]bool, len(components))
+	}
+	for _, component := range components {
+		p.optional[component] = true
+	}
+
+	return p
+}
+
+// WithSetHashing makes the named multi-value components ([ComponentMAC],
+// [ComponentDisk]) contribute a single value to the ID: the SHA-256 of their
+// sorted values, instead of one entry per value. The component still changes
+// the ID when its set of values changes, but it always contributes exactly one
+// entry, which keeps its volatility localized. Snapshots and diagnostics then
+// record the set hash. Single-value components are unaffected.
+func (p *Provider) WithSetHashing(components ...string) *Provider {
+	if p.setHashing == nil {
+		p.setHashing = make(map[string]bool, len(components))
+	}
+	for _, component := range components {
+		p.setHashing[component] = true
+	}
+
+	return p
+}
+
+// WithOSVersionProbe records the operating system version in
+// [DiagnosticInfo.OSVersion] to help correlate collection problems with OS
+// builds. The probe is best-effort and disabled by default because it may cost
+// an extra command: Linux reads /etc/os-release, macOS runs sw_vers, and
+// Windows runs ver. A failed probe leaves OSVersion empty and does not affect
+// the ID.
+func (p *Provider) WithOSVersionProbe() *Provider {
+	p.probeOSVersion = true
+
+	return p
+}
+
 // WithExecutor sets a custom [CommandExecutor], enabling deterministic testing
 // without real system commands.
 func (p *Provider) WithExecutor(executor CommandExecutor) *Provider {
 	p.commandExecutor = executor
 
-	return p
+	return p
+}
+
+// WithExecutorFunc sets fn as the [CommandExecutor], for test doubles that are
+// easier to write inline than as a type. It is shorthand for
+// WithExecutor([ExecutorFunc](fn)).
+func (p *Provider) WithExecutorFunc(fn ExecutorFunc) *Provider {
+	return p.WithExecutor(fn)
+}
+
+// WithTimeout bounds each system command run during collection by d instead
+// of the default 5 seconds, so slow commands such as wmic can be given longer.
+// It applies to a custom [CommandExecutor] too. A deadline on the context
+// passed to [Provider.ID] still wins when it is sooner, and each component
+// remains bounded by its own timeout; see [Provider.WithComponentTimeouts].
+// A zero or negative duration restores the default.
+func (p *Provider) WithTimeout(d time.Duration) *Provider {
+	p.commandTimeout = d
+
+	return p
+}
+
+// WithSourceConfig attaches an application-provided value to the context used
+// during hardware collection. Custom collectors, such as a [CommandExecutor]
+// installed via [Provider.WithExecutor], can read it with ctx.Value(key)
+// instead of relying on global state.
+//
+// Follow the [context.WithValue] convention: key must be comparable and should
+// be an unexported type defined in the caller's package, so that values never
+// collide with keys set by other packages. Registering the same key again
+// replaces the earlier value.
+func (p *Provider) WithSourceConfig(key, value any) *Provider {
+	p.sourceConfig = append(p.sourceConfig, sourceConfigEntry{key: key, value: value})
+
+	return p
+}
+
+// WithLogger sets an optional [*slog.Logger] for observability.
+// When set, the provider logs component collection, fallback paths, command
+// execution timing, and errors. A nil logger (the default) disables all logging
+// with zero overhead.
+//
+// Compatible with any [*slog.Logger], including [slog.Default] which bridges
+// to the standard [log] package.
+func (p *Provider) WithLogger(logger *slog.Logger) *Provider {
+	p.logger = logger
+	p.lazyLogger = nil
+	p.defaultLogger = false
+
+	return p
+}
+
+// WithLazyLogger is like [Provider.WithLogger], but the logger is obtained by
+// calling fn when [Provider.ID] runs, for frameworks that build the logger after
+// the provider is configured. The first non-nil logger returned is cached; a nil
+// return behaves like no logger, and fn is asked again on the next call.
+func (p *Provider) WithLazyLogger(fn func() *slog.Logger) *Provider {
+	p.logger = nil
+	p.lazyLogger = fn
+	p.defaultLogger = false
+
+	return p
+}
+
+// WithDefaultLogger makes the provider log to [slog.Default], resolved each
+// time [Provider.ID] runs, so later [slog.SetDefault] calls are honored. Unlike
+// WithLogger(slog.Default()), which captures the default logger once, this
+// follows the application's global logging configuration. Without a logger
+// option the provider stays silent at zero overhead.
+func (p *Provider) WithDefaultLogger() *Provider {
+	p.logger = nil
+	p.lazyLogger = nil
+	p.defaultLogger = true
+
+	return p
+}
+
+// WithEventSink registers fn to receive one compact JSON document per
+// successful ID generation. The document records the platform, enabled and
+// collected components, component errors, the generation duration, and a
+// SHA-256 digest of the ID (never the ID itself), making it suitable for
+// machine-readable telemetry independent of any [slog.Handler].
+//
+// fn is not called when [Provider.ID] returns a cached value or fails.
+// It runs synchronously while the provider lock is held, so it must not
+// block or call back into the provider.
+func (p *Provider) WithEventSink(fn func([]byte)) *Provider {
+	p.eventSink = fn
+
+	return p
+}
+
+// WithErrorCallback registers fn to be called as soon as a component fails,
+// with the component name and the [*ComponentError] also recorded in
+// [DiagnosticInfo].Errors. It lets callers react immediately, for example by
+// asking the user to run as administrator when the UUID needs privileges.
+//
+// fn is not called for optional components that are merely absent, see
+// [Provider.WithOptionalComponents]. It runs synchronously while the provider
+// lock is held, so it must not block, call back into the provider, or mutate it.
+func (p *Provider) WithErrorCallback(fn func(component string, err error)) *Provider {
+	p.errorCallback = fn
+
+	return p
+}
+
+// VMFriendly configures the provider for virtual machines (CPU + UUID only).
+func (p *Provider) VMFriendly() *Provider {
+	for component, flag := range p.componentFlags() {
+		*flag = component == ComponentCPU || component == ComponentSystemUUID
+	}
+
+	return p
+}
+
+// WithFastMode skips the components that require slow subprocesses, trading
+// some uniqueness for generation speed in high-frequency use such as telemetry.
+// Unlike [Provider.VMFriendly], which selects components for stability, it
+// only removes components from the configured set:
+//
+//   - Linux: disk (lsblk); all remaining sources are files or syscalls, and
+//     generation typically completes in under 10ms
+//   - macOS: disk and motherboard (system_profiler); the system UUID is read
+//     from ioreg first
+//   - Windows: disk and motherboard (wmic / PowerShell)
+//
+// Skipped components appear in neither [DiagnosticInfo.Collected] nor
+// [DiagnosticInfo.Errors], and IDs differ from those generated without fast
+// mode when a skipped component was enabled. A caching [CommandExecutor]
+// installed via [Provider.WithExecutor] further reduces the cost of the
+// remaining commands.
+func (p *Provider) WithFastMode() *Provider {
+	p.fastMode = true
+
+	return p
+}
+
+// WithDeduplicateComponents drops values that duplicate a value already
+// contributed by another component, such as a motherboard serial that some OEMs
+// also report as the chassis serial. Duplicates add no entropy; they are always
+// reported in [DiagnosticInfo.Duplicates], and this option additionally excludes
+// them from the hash. IDs differ from those generated without the option only
+// when a duplicate is present.
+func (p *Provider) WithDeduplicateComponents() *Provider {
+	p.deduplicate = true
+
+	return p
+}
+
+// DefaultComponentTimeouts returns a copy of the per-component timeouts used
+// on the current platform when no timeout is configured: short for components
+// read from files, generous for components that run tools such as
+// system_profiler, wmic, or PowerShell. A component exceeding its timeout
+// fails with [ErrComponentTimeout] without delaying the others.
+func DefaultComponentTimeouts() map[string]time.Duration {
+	return maps.Clone(defaultComponentTimeouts)
+}
+
+// WithComponentTimeout bounds the collection of every component to d,
+// replacing [DefaultComponentTimeouts], so that a hanging probe such as a disk
+// enumeration cannot hold up the others. A component exceeding d is recorded
+// in [DiagnosticInfo.Errors] with an error wrapping [ErrComponentTimeout], and
+// the ID is built from the remaining components. [Provider.WithComponentTimeouts]
+// overrides still take precedence; a zero or negative d restores the defaults.
+func (p *Provider) WithComponentTimeout(d time.Duration) *Provider {
+	p.componentTimeout = d
+
+	return p
+}
+
+// WithComponentTimeouts overrides the timeouts of individual components, taking
+// precedence over [Provider.WithComponentTimeout] and [DefaultComponentTimeouts].
+// A zero or negative duration disables the timeout of that component.
+func (p *Provider) WithComponentTimeouts(timeouts map[string]time.Duration) *Provider {
+	if p.componentTimeouts == nil {
+		p.componentTimeouts = make(map[string]time.Duration, len(timeouts))
+	}
+	maps.Copy(p.componentTimeouts, timeouts)
+
+	return p
+}
+
+// WithMaxTotalDuration bounds the time spent collecting all hardware components
+// to d, regardless of the context passed to [Provider.ID]. Components not
+// collected in time are recorded in [DiagnosticInfo.Errors] as
+// [ErrComponentTimeout], so [Provider.ID] returns within the bound, with the
+// components collected so far, even for callers passing [context.Background].
+// A deadline on the caller's context instead aborts the collection.
+func (p *Provider) WithMaxTotalDuration(d time.Duration) *Provider {
+	p.maxTotalDuration = d
+
+	return p
+}
+
+// ID generates the machine ID based on the configured options.
+// It caches the result, so subsequent calls return the same ID.
+// The configuration is frozen after the first successful call.
+// The provided context controls the timeout and cancellation of any
+// system commands executed during hardware identifier collection. Once it is
+// canceled or its deadline passes, no further component is collected and the
+// context's error is returned.
+// This method is safe for concurrent use.
+func (p *Provider) ID(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id, err := p.id(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.collectionError(); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// WithStrict makes [Provider.ID], and the methods built on it such as
+// [Provider.Validate], fail with [ErrPartialCollection] when an ID was
+// produced but some enabled component failed, so callers can treat a weaker
+// fingerprint than requested as fatal. The error wraps the [ComponentError]
+// of each failed component. The ID is still computed, and [Provider.Generate]
+// returns it along with the error. Skipped and optional absent components do
+// not count as failures.
+func (p *Provider) WithStrict() *Provider {
+	p.strict = true
+
+	return p
+}
+
+// WithMinimumComponents makes [Provider.ID], and the methods built on it such
+// as [Provider.Validate], fail with [ErrInsufficientComponents] when fewer than
+// n distinct components contributed to the ID, whichever they are. Unlike
+// [Provider.WithStrict], individual failures are tolerated as long as n
+// components were collected. The ID is still computed, and [Provider.Generate]
+// returns it along with the error. A value of 0 or less disables the check.
+func (p *Provider) WithMinimumComponents(n int) *Provider {
+	p.minComponents = n
+
+	return p
+}
+
+// collectionError returns the error, if any, of an ID produced by the last
+// collection: [ErrInsufficientComponents] under [Provider.WithMinimumComponents],
+// or else [ErrPartialCollection] under [Provider.WithStrict]. The caller must
+// hold p.mu.
+func (p *Provider) collectionError() error {
+	if err := p.insufficientComponentsError(); err != nil {
+		return err
+	}
+
+	return p.partialCollectionError()
+}
+
+// insufficientComponentsError returns an error wrapping
+// [ErrInsufficientComponents] if a minimum is set and fewer components
+// contributed to the last collection. The caller must hold p.mu.
+func (p *Provider) insufficientComponentsError() error {
+	if p.minComponents <= 0 || p.diagnostics == nil {
+		return nil
+	}
+
+	if collected := len(p.diagnostics.Collected); collected < p.minComponents {
+		return fmt.Errorf("%w: %d collected, %d required", ErrInsufficientComponents, collected, p.minComponents)
+	}
+
+	return nil
+}
+
+// partialCollectionError returns an error wrapping [ErrPartialCollection] and
+// the errors of the failed components, in name order, if strict mode is
+// enabled and any component of the last collection failed. The caller must
+// hold p.mu.
+func (p *Provider) partialCollectionError() error {
+	if !p.strict || p.diagnostics == nil || len(p.diagnostics.Errors) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, component := range slices.Sorted(maps.Keys(p.diagnostics.Errors)) {
+		errs = append(errs, p.diagnostics.Errors[component])
+	}
+
+	return fmt.Errorf("%w: %w", ErrPartialCollection, errors.Join(errs...))
+}
+
+// id implements [Provider.ID], passing per-component collection events to
+// observe if it is not nil. The caller must hold p.mu, and keep holding it
+// while reading the diagnostics of the generation.
+func (p *Provider) id(ctx context.Context, observe func(CollectionEvent)) (string, error) {
+	p.observer = observe
+	defer func() { p.observer = nil }()
+
+	p.resolveLogger()
+
+	if p.formatErr != nil {
+		return "", p.formatErr
+	}
+
+	window := p.currentWindow()
+	if p.cachedID != "" && window == p.cachedWindow {
+		p.logDebug("returning cached machine ID")
+
+		return p.cachedID, nil
+	}
+
+	start := time.Now()
+
+	p.logInfo("generating machine ID",
+		"platform", runtime.GOOS,
+		"format", p.formatMode,
+		"components", p.enabledComponents(),
+	)
+
+	identifiers, diag, err := p.collect(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if len(identifiers) == 0 {
+		cached, ok := p.loadPersistentIdentifiers(diag)
+		if !ok {
+			cached, ok = p.loadMachineIDFallback(ctx, diag)
+		}
+		if !ok {
+			p.diagnostics = diag
+			p.logWarn("no hardware identifiers collected", "errors", diag.Errors)
+
+			return "", ErrNoIdentifiers
+		}
+		identifiers = cached
+	} else {
+		p.storePersistentIdentifiers(identifiers)
+	}
+
+	if p.redactor != nil {
+		p.logDebug("collected identifiers", "count", len(identifiers), "values", p.redactedComponentValues())
+	} else {
+		p.logDebug("collected identifiers", "count", len(identifiers), "identifiers", identifiers)
+	}
+
+	p.diagnostics = diag
+	p.dumpIdentifiers(diag)
+	if p.configBinding {
+		identifiers = append(identifiers, "config:"+p.configHash())
+	}
+
+	newHash := p.newHash()
+	h := newHash()
+	if p.hmacKey != nil {
+		h = hmac.New(newHash, p.hmacKey)
+	}
+	p.cachedHash = hashIdentifiers(h, identifiers, p.hashSalt(window))
+	p.cachedFingerprint = redactedFingerprint(identifiers, p.hashSalt(window))
+	p.cachedUUID = nameBasedUUID(identifiers, p.hashSalt(window))
+	p.cachedID = p.presentID(formatHash(p.cachedHash, p.formatMode, newHash))
+	p.cachedWindow = window
+
+	p.logInfo("machine ID generated",
+		"collected", diag.Collected,
+		"errors_count", len(diag.Errors),
+	)
+
+	p.lastDuration = time.Since(start)
+	p.emitEvent(diag, p.lastDuration)
+
+	return p.cachedID, nil
+}
+
+// collect runs a fresh hardware collection, recording per-component values and
+// durations on the provider. The caller must hold p.mu.
+func (p *Provider) collect(ctx context.Context) ([]string, *DiagnosticInfo, error) {
+	diag := &DiagnosticInfo{
+		Errors:   make(map[string]error),
+		Platform: runtime.GOOS,
+		Arch:     runtime.GOARCH,
+	}
+
+	p.componentValues = make(map[string][]string)
+	p.componentDurations = make(map[string]time.Duration)
+
+	// The total bound is applied per component, so that ctx only reports the
+	// caller's cancellation, which aborts the collection.
+	if p.maxTotalDuration > 0 {
+		p.collectDeadline = time.Now().Add(p.maxTotalDuration)
+		defer func() { p.collectDeadline = time.Time{} }()
+	}
+
+	ctx = p.commandContext(ctx)
+
+	defer p.startProfile()()
+
+	identifiers, err := p.runCollector(ctx, diag, collectIdentifiers)
+	if err != nil {
+		return nil, nil, err
+	}
+	identifiers = p.applyFallbacks(ctx, identifiers, diag, collectIdentifiers)
+	if err := ctx.Err(); err != nil {
+		p.logDebug("collection canceled", "error", err)
+
+		return nil, nil, err
+	}
+
+	if p.probeOSVersion {
+		osCtx, cancel := p.componentContext(ctx, "")
+		osVersion, err := platformOSVersion(osCtx, p.commandExecutor, p.logger)
+		cancel()
+		if err == nil {
+			diag.OSVersion = osVersion
+		} else {
+			p.logDebug("OS version probe failed", "error", err)
+		}
+	}
+
+	order := p.enabledComponents()
+	sortComponents(diag.Collected, order)
+	sortComponents(diag.Absent, order)
+
+	return identifiers, diag, nil
+}
+
+// currentWindow returns the Unix start time of the current [Provider.WithTimeWindow]
+// window, or 0 when no time window is configured.
+func (p *Provider) currentWindow() int64 {
+	if p.timeWindow <= 0 {
+		return 0
+	}
+
+	now := time.Now
+	if p.clock != nil {
+		now = p.clock
+	} else if p.timeSource != nil {
+		now = p.timeSource.Now
+	}
+
+	return now().Truncate(p.timeWindow).Unix()
+}
+
+// windowSalt returns the configured salt combined with the time window, if any.
+func (p *Provider) windowSalt(window int64) string {
+	if p.timeWindow <= 0 {
+		return p.salt
+	}
+
+	windowSalt := "window:" + strconv.FormatInt(window, 10)
+	if p.salt == "" {
+		return windowSalt
+	}
+
+	return p.salt + "|" + windowSalt
+}
+
+// configHash returns a short digest of the configuration bound into the ID by
+// [Provider.WithConfigBinding].
+func (p *Provider) configHash() string {
+	key := fmt.Sprintf("%s|%d|%t|%t|%t|%t|%t|%t|%t|%d|%s|%d|%t|%t|%t|%t|%s|%s",
+		strings.Join(p.enabledComponents(), ","), p.formatMode, p.salt != "", p.personalization != "", p.hmacKey != nil,
+		p.normalizeUnicode, p.bestUUID, p.excludeMachineID, p.cleanCPUFormat, p.macCPUSource, p.macFilter, p.macMaxCount,
+		p.deduplicate, p.canonicalDisks, p.nvmeDiskIDs, p.suspiciousSerials != nil, strings.Join(slices.Sorted(maps.Keys(p.setHashing)), ","), p.timeWindow)
+	if len(p.macExclude) > 0 {
+		key += "|mac-exclude:" + strings.Join(p.macExclude, ",")
+	}
+	if len(p.macAllow) > 0 {
+		key += "|mac-allow:" + strings.Join(p.macAllow, ",")
+	}
+	if p.diskFilter != DiskFilterInternal {
+		key += fmt.Sprintf("|disk-filter:%d", p.diskFilter)
+	}
+	for _, component := range slices.Sorted(maps.Keys(p.componentPrefixes)) {
+		key += "|prefix:" + component + "=" + p.componentPrefixes[component]
+	}
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// hashSalt returns the prefix mixed into the digest: the personalization tag,
+// if any, followed by the salt and time window.
+func (p *Provider) hashSalt(window int64) string {
+	salt := p.windowSalt(window)
+	if p.personalization == "" {
+		return salt
+	}
+
+	tag := "personalization:" + p.personalization
+	if salt == "" {
+		return tag
+	}
+
+	return tag + "|" + salt
 }
 
-// WithLogger sets an optional [*slog.Logger] for observability.
-// When set, the provider logs component collection, fallback paths, command
-// execution timing, and errors. A nil logger (the default) disables all logging
-// with zero overhead.
+// IDBytes returns the raw digest bytes underlying [Provider.ID]: 16 bytes for
+// [Format32], 32 for [Format64], 64 for [Format128], and 128 for [Format256].
+// Use it when the ID feeds an HMAC or key derivation function, to avoid
+// decoding the ID string.
 //
-// Compatible with any [*slog.Logger], including [slog.Default] which bridges
-// to the standard [log] package.
-func (p *Provider) WithLogger(logger *slog.Logger) *Provider {
-	p.logger = logger
+// The bytes are independent of text presentation options such as
+// [Provider.WithUppercase] and [Provider.WithEncoding]; hex.EncodeToString(b)
+// equals the lowercase hex ID. It shares the cached ID, so it is consistent
+// with [Provider.ID].
+func (p *Provider) IDBytes(ctx context.Context) ([]byte, error) {
+	id, err := p.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	return p
+	p.mu.Lock()
+	encoding := p.encoding
+	p.mu.Unlock()
+
+	return decodeID(id, encoding)
 }
 
-// VMFriendly configures the provider for virtual machines (CPU + UUID only).
-func (p *Provider) VMFriendly() *Provider {
-	p.includeCPU = true
-	p.includeSystemUUID = true
-	p.includeMotherboard = false
-	p.includeMAC = false
-	p.includeDisk = false
+// IDPair returns a short [Format32] ID for display and a long [Format64] ID
+// for storage, both computed from a single collection and independent of the
+// configured [Provider.WithFormat]. short is always the prefix of long, so a
+// stored long ID can be matched against a displayed short one by comparing
+// long[:len(short)]. The prefix relationship holds only for the hex encoding;
+// both IDs follow [Provider.WithEncoding] and [Provider.WithUppercase].
+func (p *Provider) IDPair(ctx context.Context) (short, long string, err error) {
+	if _, err := p.ID(ctx); err != nil {
+		return "", "", err
+	}
 
-	return p
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	hexLong := formatHash(p.cachedHash, Format64, p.newHash())
+
+	return p.presentID(hexLong[:formatLength(Format32)]), p.presentID(hexLong), nil
 }
 
-// ID generates the machine ID based on the configured options.
-// It caches the result, so subsequent calls return the same ID.
-// The configuration is frozen after the first successful call.
-// The provided context controls the timeout and cancellation of any
-// system commands executed during hardware identifier collection.
-// This method is safe for concurrent use.
-func (p *Provider) ID(ctx context.Context) (string, error) {
+// Fingerprint returns the sorted, joined identifier string that was hashed
+// into the ID, with every raw value replaced by a short SHA-256 prefix, for
+// example "cpu:ab12cd…|uuid:34ef56…". A salt, if any, appears first as
+// "salt:<prefix>". Comparing fingerprints shows which component differs when
+// two machines or two releases disagree on an ID, without exposing serials or
+// enabling debug logging.
+//
+// It shares the collection and cache of [Provider.ID], so diagnostics are
+// populated and the result is stable across calls for as long as the ID is.
+func (p *Provider) Fingerprint(ctx context.Context) (string, error) {
+	if _, err := p.ID(ctx); err != nil {
+		return "", err
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.cachedID != "" {
-		p.logDebug("returning cached machine ID")
+	return p.cachedFingerprint, nil
+}
 
-		return p.cachedID, nil
+// redactedFingerprint returns the hashed message of identifiers and salt with
+// every value replaced by a short digest.
+func redactedFingerprint(identifiers []string, salt string) string {
+	parts := make([]string, 0, len(identifiers)+1)
+	if salt != "" {
+		parts = append(parts, "salt:"+digestPrefix(salt))
+	}
+	for _, identifier := range slices.Sorted(slices.Values(identifiers)) {
+		prefix, value, _ := strings.Cut(identifier, ":")
+		parts = append(parts, prefix+":"+digestPrefix(value))
 	}
 
-	p.logInfo("generating machine ID",
-		"platform", runtime.GOOS,
-		"format", p.formatMode,
-		"components", p.enabledComponents(),
-	)
+	return strings.Join(parts, "|")
+}
 
-	diag := &DiagnosticInfo{
-		Errors: make(map[string]error),
+// digestPrefix returns the first six hex characters of the SHA-256 of value.
+func digestPrefix(value string) string {
+	sum := sha256.Sum256([]byte(value))
+
+	return hex.EncodeToString(sum[:3]) + "…"
+}
+
+// DeriveID derives a distinct, deterministic sub-identifier of length hex
+// characters from the machine ID, for example one per feature or per user.
+// Different labels yield unrelated IDs, and the same label always yields the
+// same ID on the same machine.
+//
+// This is key derivation, not re-salting: the machine ID digest ([Provider.IDBytes])
+// is used as the HKDF-SHA256 pseudorandom key and label as the info input of
+// HKDF-Expand (RFC 5869). Hardware is probed only once, as the base ID is cached,
+// so derivations are cheap. The output is in the case configured by
+// [Provider.WithUppercase]; length may be at most 16320.
+func (p *Provider) DeriveID(ctx context.Context, label string, length int) (string, error) {
+	if length <= 0 {
+		return "", fmt.Errorf("invalid derived ID length %d", length)
 	}
 
-	identifiers, err := collectIdentifiers(ctx, p, diag)
+	base, err := p.IDBytes(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	if len(identifiers) == 0 {
-		p.diagnostics = diag
-		p.logWarn("no hardware identifiers collected", "errors", diag.Errors)
+	key, err := hkdf.Expand(sha256.New, base, label, (length+1)/2)
+	if err != nil {
+		return "", err
+	}
 
-		return "", ErrNoIdentifiers
+	derived := hex.EncodeToString(key)[:length]
+	if p.uppercase {
+		derived = strings.ToUpper(derived)
 	}
 
-	p.logDebug("collected identifiers", "count", len(identifiers), "identifiers", identifiers)
+	return derived, nil
+}
 
-	p.diagnostics = diag
-	p.cachedID = hashIdentifiers(identifiers, p.salt, p.formatMode)
+// generationEvent is the JSON document passed to the [Provider.WithEventSink] callback.
+type generationEvent struct {
+	Platform   string            `json:"platform"`
+	Format     FormatMode        `json:"format"`
+	Components []string          `json:"components"`
+	Collected  []string          `json:"collected"`
+	Errors     map[string]string `json:"errors,omitempty"`
+	DurationMS float64           `json:"duration_ms"`
+	IDSHA256   string            `json:"id_sha256"`
+}
 
-	p.logInfo("machine ID generated",
-		"collected", diag.Collected,
-		"errors_count", len(diag.Errors),
-	)
+// emitEvent sends a generation event to the configured event sink, if any.
+func (p *Provider) emitEvent(diag *DiagnosticInfo, duration time.Duration) {
+	if p.eventSink == nil {
+		return
+	}
 
-	return p.cachedID, nil
+	event := generationEvent{
+		Platform:   runtime.GOOS,
+		Format:     p.formatMode,
+		Components: p.enabledComponents(),
+		Collected:  diag.Collected,
+		DurationMS: durationMS(duration),
+	}
+
+	if len(diag.Errors) > 0 {
+		event.Errors = make(map[string]string, len(diag.Errors))
+		for component, err := range diag.Errors {
+			event.Errors[component] = err.Error()
+		}
+	}
+
+	idHash := sha256.Sum256([]byte(p.cachedID))
+	event.IDSHA256 = hex.EncodeToString(idHash[:])
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		p.logWarn("failed to encode generation event", "error", err)
+
+		return
+	}
+
+	p.eventSink(data)
 }
 
 // Diagnostics returns information about which hardware components were
@@ -258,66 +1498,187 @@ func (p *Provider) Diagnostics() *DiagnosticInfo {
 }
 
 // Validate reports whether the provided ID matches the current machine ID.
+// The comparison is exact, in the case configured by [Provider.WithUppercase],
+// and takes constant time for IDs of the expected length, so it does not leak
+// how many leading characters of a guess are correct. Only a length mismatch,
+// which the [FormatMode] makes public anyway, returns early.
 // The provided context is forwarded to [Provider.ID] if it needs to generate the ID.
+// With [Provider.WithStrictValidationInput], malformed input is rejected with
+// [ErrMalformedID] before any hardware is probed.
 func (p *Provider) Validate(ctx context.Context, id string) (bool, error) {
+	if p.strictValidation {
+		if err := p.checkIDFormat(id); err != nil {
+			return false, err
+		}
+	}
+
 	currentID, err := p.ID(ctx)
 	if err != nil {
 		return false, err
 	}
 
-	return currentID == id, nil
+	return ConstantTimeEqual(currentID, id), nil
 }
 
-// hashIdentifiers processes and hashes the hardware identifiers with optional salt.
-// Returns a hash formatted according to the specified [FormatMode].
-func hashIdentifiers(identifiers []string, salt string, mode FormatMode) string {
-	sort.Strings(identifiers)
-	combined := strings.Join(identifiers, "|")
-	if salt != "" {
-		combined = salt + "|" + combined
+// ConstantTimeEqual reports whether the machine IDs a and b are equal,
+// comparing them in constant time as [Provider.Validate] does, without
+// collecting any hardware. A license server can use it to check the ID a
+// client reports against the one it issued. The comparison is exact, so both
+// IDs must use the same format, encoding and case. Only a length mismatch
+// returns early.
+func ConstantTimeEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// WithStrictValidationInput makes [Provider.Validate] check that the provided
+// ID has the length of the configured [FormatMode] and [Encoding] and consists
+// only of characters of that encoding in the configured case, returning
+// [ErrMalformedID] otherwise. Servers
+// receiving untrusted input can then tell garbage input apart from a
+// well-formed ID of another machine. Disabled by default.
+func (p *Provider) WithStrictValidationInput() *Provider {
+	p.strictValidation = true
+
+	return p
+}
+
+// checkIDFormat reports whether id could have been produced by the provider's
+// configured format, encoding and case.
+func (p *Provider) checkIDFormat(id string) error {
+	if want := encodedLength(p.formatMode, p.encoding); len(id) != want {
+		return fmt.Errorf("%w: length %d, want %d", ErrMalformedID, len(id), want)
 	}
 
-	// Generate SHA256 hash
-	hash := sha256.Sum256([]byte(combined))
-	rawHash := hex.EncodeToString(hash[:])
+	for _, c := range id {
+		if !isEncodingChar(c, p.encoding, p.uppercase) {
+			return fmt.Errorf("%w: unexpected character %q", ErrMalformedID, c)
+		}
+	}
 
-	return formatHash(rawHash, mode)
+	return nil
 }
 
-// formatHash formats a 64-character SHA-256 hash according to the specified [FormatMode].
-// All formats produce power-of-2 lengths without dashes.
-func formatHash(hash string, mode FormatMode) string {
-	if len(hash) != 64 {
-		return hash
+// isValidFormat reports whether mode is one of the defined [FormatMode] constants.
+func isValidFormat(mode FormatMode) bool {
+	switch mode {
+	case Format32, Format64, Format128, Format256:
+		return true
+	default:
+		return false
 	}
+}
 
+// formatLength returns the number of hex characters produced by mode.
+func formatLength(mode FormatMode) int {
 	switch mode {
 	case Format32:
-		// 32 hex characters (2^5 = 32)
-		return hash[:32]
+		return 32
+	case Format128:
+		return 128
+	case Format256:
+		return 256
+	default:
+		return 64
+	}
+}
 
-	case Format64:
-		// 64 hex characters (2^6 = 64), no dashes - default
-		return hash
+// hashIdentifiers hashes the hardware identifiers with optional salt using h,
+// such as a SHA-256 or an HMAC, and returns the hex digest, which
+// [formatHash] formats according to a [FormatMode].
+func hashIdentifiers(h hash.Hash, identifiers []string, salt string) string {
+	writeIdentifiers(h, identifiers, salt)
 
-	case Format128:
-		// 128 hex characters (2^7 = 128)
-		// Generate second hash by rehashing the first
-		hash2 := sha256.Sum256([]byte(hash))
+	return hex.EncodeToString(h.Sum(nil))
+}
 
-		return hash + hex.EncodeToString(hash2[:])
+// writeIdentifiers sorts identifiers and writes the message that is hashed to
+// h: the identifiers joined with "|", prefixed with salt and "|" if salt is
+// set. It streams the message through one reused buffer instead of building it
+// in memory, and is byte-for-byte equivalent to hashing
+// salt + "|" + strings.Join(identifiers, "|").
+func writeIdentifiers(h hash.Hash, identifiers []string, salt string) {
+	sort.Strings(identifiers)
 
-	case Format256:
-		// 256 hex characters (2^8 = 256)
-		// Generate additional hashes for extended length
-		hash2 := sha256.Sum256([]byte(hash))
-		hash3 := sha256.Sum256([]byte(hex.EncodeToString(hash2[:])))
-		hash4 := sha256.Sum256([]byte(hex.EncodeToString(hash3[:])))
-		return hash + hex.EncodeToString(hash2[:]) +
-			hex.EncodeToString(hash3[:]) + hex.EncodeToString(hash4[:])
+	var buf []byte
+	if salt != "" {
+		buf = append(buf, salt...)
+		buf = append(buf, '|')
+		h.Write(buf)
+	}
+	for i, identifier := range identifiers {
+		buf = buf[:0]
+		if i > 0 {
+			buf = append(buf, '|')
+		}
+		buf = append(buf, identifier...)
+		h.Write(buf)
+	}
+}
 
-	default:
-		return hash
+// hashSet returns the hex SHA-256 of values, independent of their order.
+func hashSet(values []string) string {
+	sorted := slices.Sorted(slices.Values(values))
+	hash := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// formatHash formats a hex digest produced by newHash according to the
+// specified [FormatMode]. All formats produce power-of-2 lengths without dashes.
+// Formats shorter than the digest truncate it; longer ones extend it with
+// successive hashes, each of the previous one's hex encoding. For SHA-256 the
+// 64-character digest is itself [Format64]. Digests of another length, and
+// unknown modes, are returned unchanged.
+func formatHash(digest string, mode FormatMode, newHash func() hash.Hash) string {
+	h := newHash()
+	size := h.Size()
+	if len(digest) != 2*size {
+		return digest
+	}
+
+	if !isValidFormat(mode) {
+		return digest
+	}
+
+	length := formatLength(mode)
+	if length <= len(digest) {
+		return digest[:length]
+	}
+
+	// The hex encoding of each hash is appended in place, with room for the
+	// last one to overshoot length, and hashed from the buffer for the next.
+	formatted := make([]byte, len(digest), length+2*size)
+	copy(formatted, digest)
+	sum := make([]byte, 0, size)
+	for last := formatted; len(formatted) < length; {
+		h.Reset()
+		h.Write(last)
+		sum = h.Sum(sum[:0])
+		start := len(formatted)
+		formatted = hex.AppendEncode(formatted, sum)
+		last = formatted[start:]
+	}
+
+	return string(formatted[:length])
+}
+
+// resolveLogger resolves a logger registered with [Provider.WithLazyLogger],
+// caching it once available, or the current [slog.Default] logger with
+// [Provider.WithDefaultLogger]. The caller must hold p.mu.
+func (p *Provider) resolveLogger() {
+	if p.defaultLogger {
+		p.logger = slog.Default()
+
+		return
+	}
+
+	if p.logger != nil || p.lazyLogger == nil {
+		return
+	}
+
+	if logger := p.lazyLogger(); logger != nil {
+		p.logger = logger
+		p.lazyLogger = nil
 	}
 }
 
@@ -342,31 +1703,410 @@ func (p *Provider) logWarn(msg string, args ...any) {
 	}
 }
 
+// collectIdentifiersFor runs the identifier collector of goos. The macOS and
+// FreeBSD collectors only run commands through the provider's [CommandExecutor]
+// (and, on FreeBSD, read files through its filesystem), so they are available
+// on every host for tests that inject a mock executor. The Linux and Windows
+// collectors read local files and APIs and are only available on their own
+// platform.
+func collectIdentifiersFor(ctx context.Context, goos string, p *Provider, diag *DiagnosticInfo) ([]string, error) {
+	if goos == runtime.GOOS {
+		return collectIdentifiers(ctx, p, diag)
+	}
+
+	switch goos {
+	case "darwin":
+		return collectDarwinIdentifiers(ctx, p, diag)
+	case "freebsd":
+		return collectFreeBSDIdentifiers(ctx, p, diag)
+	}
+
+	return nil, fmt.Errorf("no %s collector available on %s", goos, runtime.GOOS)
+}
+
+// Components returns the names of the enabled hardware components, such as
+// [ComponentCPU], in a fixed order. It can be called before [Provider.ID], for
+// example to show users which hardware signals will be used, and neither
+// changes nor freezes the configuration. Components that are collected
+// implicitly, such as the Linux [ComponentMachineID] alongside the system
+// UUID, are not listed.
+func (p *Provider) Components() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.enabledComponents()
+}
+
+// WithDisplayNames sets friendly names for components, such as
+// "System UUID" for [ComponentSystemUUID], used only on human-facing
+// surfaces: [Provider.DisplayName], the display_name of components in
+// [Provider.WriteFingerprintBundle] bundles, and the CLI's text diagnostics.
+// Hash prefixes, [DiagnosticInfo] keys and other programmatic names are
+// unchanged. Components without an entry keep their short name.
+func (p *Provider) WithDisplayNames(names map[string]string) *Provider {
+	p.displayNames = maps.Clone(names)
+
+	return p
+}
+
+// DisplayName returns the name configured for component with
+// [Provider.WithDisplayNames], or component itself if there is none.
+func (p *Provider) DisplayName(component string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.displayName(component)
+}
+
+// displayName implements [Provider.DisplayName]. The caller must hold p.mu.
+func (p *Provider) displayName(component string) string {
+	if name, ok := p.displayNames[component]; ok {
+		return name
+	}
+
+	return component
+}
+
+// selectableComponents lists the components enabled by the With* methods, in
+// canonical order. The machine-id is collected implicitly with the system UUID.
+var selectableComponents = []string{
+	ComponentCPU,
+	ComponentMotherboard,
+	ComponentSystemUUID,
+	ComponentMAC,
+	ComponentDisk,
+	ComponentThunderbolt,
+	ComponentModel,
+	ComponentTPM,
+	ComponentGPU,
+	ComponentMemory,
+}
+
+// componentFlags maps each of [selectableComponents] to the provider field
+// that enables it.
+func (p *Provider) componentFlags() map[string]*bool {
+	return map[string]*bool{
+		ComponentCPU:         &p.includeCPU,
+		ComponentMotherboard: &p.includeMotherboard,
+		ComponentSystemUUID:  &p.includeSystemUUID,
+		ComponentMAC:         &p.includeMAC,
+		ComponentDisk:        &p.includeDisk,
+		ComponentThunderbolt: &p.includeThunderbolt,
+		ComponentModel:       &p.includeModel,
+		ComponentTPM:         &p.includeTPM,
+		ComponentGPU:         &p.includeGPU,
+		ComponentMemory:      &p.includeMemory,
+	}
+}
+
 // enabledComponents returns the names of the hardware components that are enabled.
 func (p *Provider) enabledComponents() []string {
+	flags := p.componentFlags()
+
 	var components []string
-	if p.includeCPU {
-		components = append(components, ComponentCPU)
+	for _, component := range selectableComponents {
+		if *flags[component] {
+			components = append(components, component)
+		}
+	}
+
+	return components
+}
+
+// sortComponents sorts components in place into the canonical order given by
+// order, so diagnostics do not depend on platform collection order. Components
+// missing from order, such as the Linux machine-id, follow in name order.
+func sortComponents(components, order []string) {
+	rank := func(component string) int {
+		if i := slices.Index(order, component); i >= 0 {
+			return i
+		}
+
+		return len(order)
+	}
+
+	slices.SortStableFunc(components, func(a, b string) int {
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra - rb
+		}
+
+		return strings.Compare(a, b)
+	})
+}
+
+// appendIdentifier collects a single-value component, applying the provider's
+// component deadline and value processing before delegating to [appendIdentifierIfValid].
+// Nothing is collected once ctx is done; see [Provider.collect].
+func (p *Provider) appendIdentifier(ctx context.Context, identifiers []string, getValue func(context.Context) (string, error), prefix string, diag *DiagnosticInfo, component string) []string {
+	if p.prefetchComponent(ctx, component, func() (any, error) { return fetchValue(ctx, p, component, getValue) }) {
+		return identifiers
+	}
+	if ctx.Err() != nil || p.skipComponent(diag, component) {
+		return identifiers
+	}
+	prefix = p.prefixFor(component, prefix)
+
+	begin := p.componentStart(component)
+	defer p.recordDuration(component, begin)
+	defer p.observeFinished(diag, component, begin)
+	defer p.recordSpan(component, spanCollect, begin)
+	p.observeStarted(component)
+	p.profiledComponent = component
+
+	start := len(identifiers)
+	identifiers = appendIdentifierIfValid(identifiers, func() (string, error) {
+		value, err := fetchValue(ctx, p, component, getValue)
+		if err != nil {
+			cached, ok := p.loadCachedValues(component, diag)
+			if !ok {
+				return "", err
+			}
+			value = cached[0]
+		} else {
+			if err := p.checkEntropy(component, value); err != nil {
+				return "", err
+			}
+			p.storeCachedValues(component, []string{value})
+		}
+
+		return p.processValue(component, value), nil
+	}, prefix, diag, component, p.logger, p.redactor)
+	p.markAbsent(diag, component)
+	p.reportError(diag, component)
+
+	return p.recordValues(identifiers, start, prefix, diag, component)
+}
+
+// appendIdentifiers collects a multi-value component, applying the provider's
+// component deadline and value processing before delegating to [appendIdentifiersIfValid].
+// Nothing is collected once ctx is done; see [Provider.collect].
+func (p *Provider) appendIdentifiers(ctx context.Context, identifiers []string, getValues func(context.Context) ([]string, error), prefix string, diag *DiagnosticInfo, component string) []string {
+	if p.prefetchComponent(ctx, component, func() (any, error) { return fetchValue(ctx, p, component, getValues) }) {
+		return identifiers
+	}
+	if ctx.Err() != nil || p.skipComponent(diag, component) {
+		return identifiers
+	}
+	prefix = p.prefixFor(component, prefix)
+
+	begin := p.componentStart(component)
+	defer p.recordDuration(component, begin)
+	defer p.observeFinished(diag, component, begin)
+	defer p.recordSpan(component, spanCollect, begin)
+	p.observeStarted(component)
+	p.profiledComponent = component
+
+	start := len(identifiers)
+	identifiers = appendIdentifiersIfValid(identifiers, func() ([]string, error) {
+		values, err := fetchValue(ctx, p, component, getValues)
+		if err != nil {
+			cached, ok := p.loadCachedValues(component, diag)
+			if !ok {
+				return nil, err
+			}
+			values = cached
+		} else {
+			values, err = p.filterLowEntropy(component, values, diag)
+			if err != nil {
+				return nil, err
+			}
+			p.storeCachedValues(component, values)
+		}
+
+		for i, value := range values {
+			values[i] = p.processValue(component, value)
+		}
+		if p.setHashing[component] && len(values) > 0 {
+			values = []string{hashSet(values)}
+		}
+
+		return values, nil
+	}, prefix, diag, component, p.logger, p.redactor)
+	p.markAbsent(diag, component)
+	p.reportError(diag, component)
+
+	return p.recordValues(identifiers, start, prefix, diag, component)
+}
+
+// reportError passes the error recorded for component in diag, if any, to the
+// [Provider.WithErrorCallback] callback.
+func (p *Provider) reportError(diag *DiagnosticInfo, component string) {
+	if p.errorCallback == nil || diag == nil {
+		return
+	}
+
+	if err, ok := diag.Errors[component]; ok {
+		p.errorCallback(component, err)
+	}
+}
+
+// markAbsent moves an empty-result error for an optional component from
+// diag.Errors to diag.Absent.
+func (p *Provider) markAbsent(diag *DiagnosticInfo, component string) {
+	if diag == nil || !p.optional[component] {
+		return
 	}
-	if p.includeMotherboard {
-		components = append(components, ComponentMotherboard)
+
+	err := diag.Errors[component]
+	if errors.Is(err, ErrNoValues) || errors.Is(err, ErrEmptyValue) {
+		delete(diag.Errors, component)
+		diag.Absent = append(diag.Absent, component)
+		p.logDebug("optional component absent", "component", component)
+	}
+}
+
+// recordDuration remembers how long component took to collect since start.
+func (p *Provider) recordDuration(component string, start time.Time) {
+	if p.componentDurations == nil {
+		p.componentDurations = make(map[string]time.Duration)
 	}
-	if p.includeSystemUUID {
-		components = append(components, ComponentSystemUUID)
+
+	p.componentDurations[component] = time.Since(start)
+}
+
+// recordValues remembers the values component appended to identifiers from
+// index start onwards, reporting (and, if enabled, dropping) any value already
+// contributed by another component.
+func (p *Provider) recordValues(identifiers []string, start int, prefix string, diag *DiagnosticInfo, component string) []string {
+	if p.componentValues == nil {
+		p.componentValues = make(map[string][]string)
 	}
-	if p.includeMAC {
-		components = append(components, ComponentMAC)
+
+	kept := identifiers[:start]
+	for _, identifier := range identifiers[start:] {
+		value := strings.TrimPrefix(identifier, prefix)
+		duplicateOf := p.componentsWithValue(value, component)
+		p.componentValues[component] = append(p.componentValues[component], value)
+
+		if len(duplicateOf) > 0 {
+			p.logInfo("component value duplicates another component", "component", component, "duplicates", duplicateOf)
+			if diag != nil {
+				if diag.Duplicates == nil {
+					diag.Duplicates = make(map[string][]string)
+				}
+				for _, other := range duplicateOf {
+					if !slices.Contains(diag.Duplicates[component], other) {
+						diag.Duplicates[component] = append(diag.Duplicates[component], other)
+					}
+				}
+			}
+			if p.deduplicate {
+				continue
+			}
+		}
+
+		kept = append(kept, identifier)
 	}
-	if p.includeDisk {
-		components = append(components, ComponentDisk)
+
+	return kept
+}
+
+// componentsWithValue returns the sorted names of components other than
+// component that have already contributed value.
+func (p *Provider) componentsWithValue(value, component string) []string {
+	var components []string
+	for _, other := range slices.Sorted(maps.Keys(p.componentValues)) {
+		if other != component && slices.Contains(p.componentValues[other], value) {
+			components = append(components, other)
+		}
 	}
 
 	return components
 }
 
+// componentContext derives the context for collecting a single component,
+// bounded by the component's timeout and the [Provider.WithMaxTotalDuration]
+// deadline, if any.
+func (p *Provider) componentContext(ctx context.Context, component string) (context.Context, context.CancelFunc) {
+	deadline := p.collectDeadline
+	if timeout := p.timeoutFor(component); timeout > 0 {
+		if componentDeadline := time.Now().Add(timeout); deadline.IsZero() || componentDeadline.Before(deadline) {
+			deadline = componentDeadline
+		}
+	}
+
+	if deadline.IsZero() {
+		return ctx, func() {}
+	}
+
+	return context.WithDeadline(ctx, deadline)
+}
+
+// timeoutFor returns the timeout for component: its [Provider.WithComponentTimeouts]
+// override, else the [Provider.WithComponentTimeout] bound, else the platform default.
+func (p *Provider) timeoutFor(component string) time.Duration {
+	if timeout, ok := p.componentTimeouts[component]; ok {
+		return timeout
+	}
+
+	if p.componentTimeout > 0 {
+		return p.componentTimeout
+	}
+
+	return defaultComponentTimeouts[component]
+}
+
+// componentTimeoutError wraps err in [ErrComponentTimeout] when the component's
+// own deadline expired while the parent context was still live, so that "too
+// slow" can be told apart from "not present" or a caller-side deadline.
+func componentTimeoutError(parent, componentCtx context.Context, err error) error {
+	if parent.Err() == nil && errors.Is(componentCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrComponentTimeout, err)
+	}
+
+	return err
+}
+
+// skipComponent reports whether component must not be collected under the
+// current configuration.
+func (p *Provider) skipComponent(diag *DiagnosticInfo, component string) bool {
+	if !p.isSkipped(component) {
+		return false
+	}
+
+	if p.fastMode && fastModeSkipped[component] {
+		p.logDebug("skipping component in fast mode", "component", component)
+
+		return true
+	}
+
+	if p.unprivilegedOnly && !canCollectUnprivileged(p, component) {
+		p.logInfo("skipping component that requires elevated privileges", "component", component)
+		if diag != nil {
+			diag.Notes = append(diag.Notes, component+" skipped: requires elevated privileges")
+		}
+	}
+
+	return true
+}
+
+// isSkipped is [Provider.skipComponent] without logging or notes.
+func (p *Provider) isSkipped(component string) bool {
+	return p.fastMode && fastModeSkipped[component] || p.unprivilegedOnly && !canCollectUnprivileged(p, component)
+}
+
+// processValue applies the configured normalizations and the component's
+// value transform to a collected value.
+func (p *Provider) processValue(component, value string) string {
+	if p.normalizeUnicode {
+		value = normalizeNFC(value)
+	}
+
+	if p.canonicalDisks && component == ComponentDisk {
+		value = canonicalDiskSerial(value)
+	}
+
+	if transform := p.valueTransforms[component]; transform != nil {
+		value = transform(value)
+	}
+
+	return value
+}
+
 // appendIdentifierIfValid adds the result of getValue to identifiers with the given prefix if valid.
-// It records the result in diag under the given component name.
-func appendIdentifierIfValid(identifiers []string, getValue func() (string, error), prefix string, diag *DiagnosticInfo, component string, logger *slog.Logger) []string {
+// It records the result in diag under the given component name. Logged values
+// are passed through redact, if not nil.
+func appendIdentifierIfValid(identifiers []string, getValue func() (string, error), prefix string, diag *DiagnosticInfo, component string, logger *slog.Logger, redact func(component, value string) string) []string {
 	value, err := getValue()
 	if err != nil {
 		compErr := &ComponentError{Component: component, Err: err}
@@ -397,15 +2137,18 @@ func appendIdentifierIfValid(identifiers []string, getValue func() (string, erro
 	}
 	if logger != nil {
 		logger.Info("component collected", "component", component)
-		logger.Debug("component value", "component", component, "value", value)
+		logger.Debug("component value", "component", component, "value", redactValues(redact, component, []string{value})[0])
 	}
 
 	return append(identifiers, prefix+value)
 }
 
 // appendIdentifiersIfValid adds the results of getValues to identifiers with the given prefix if valid.
-// It records the result in diag under the given component name.
-func appendIdentifiersIfValid(identifiers []string, getValues func() ([]string, error), prefix string, diag *DiagnosticInfo, component string, logger *slog.Logger) []string {
+// It records the result in diag under the given component name. Logged values
+// are passed through redact, if not nil. Values are appended in sorted order,
+// so the identifiers, and hence the ID, never depend on the order in which the
+// operating system enumerates disks or interfaces.
+func appendIdentifiersIfValid(identifiers []string, getValues func() ([]string, error), prefix string, diag *DiagnosticInfo, component string, logger *slog.Logger, redact func(component, value string) string) []string {
 	values, err := getValues()
 	if err != nil {
 		compErr := &ComponentError{Component: component, Err: err}
@@ -431,12 +2174,14 @@ func appendIdentifiersIfValid(identifiers []string, getValues func() ([]string,
 		return identifiers
 	}
 
+	values = slices.Sorted(slices.Values(values))
+
 	if diag != nil {
 		diag.Collected = append(diag.Collected, component)
 	}
 	if logger != nil {
 		logger.Info("component collected", "component", component, "count", len(values))
-		logger.Debug("component values", "component", component, "values", values)
+		logger.Debug("component values", "component", component, "values", redactValues(redact, component, values))
 	}
 
 	for _, value := range values {
@@ -445,3 +2190,29 @@ func appendIdentifiersIfValid(identifiers []string, getValues func() ([]string,
 
 	return identifiers
 }
+
+// redactValues returns the displayed form of component values: values itself
+// if redact is nil, otherwise a redacted copy.
+func redactValues(redact func(component, value string) string, component string, values []string) []string {
+	if redact == nil {
+		return values
+	}
+
+	redacted := make([]string, len(values))
+	for i, value := range values {
+		redacted[i] = redact(component, value)
+	}
+
+	return redacted
+}
+
+// redactedComponentValues returns the values of the last collection by
+// component, passed through the [Provider.WithRedactor] function.
+func (p *Provider) redactedComponentValues() map[string][]string {
+	values := make(map[string][]string, len(p.componentValues))
+	for component, raw := range p.componentValues {
+		values[component] = redactValues(p.redactor, component, raw)
+	}
+
+	return values
+}
@@ -3,15 +3,31 @@ package machineid
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"io/fs"
 	"log/slog"
+	"net"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
+	"unsafe"
 )
 
 // TestHashIdentifiersEmpty tests hashing with empty identifiers.
 func TestHashIdentifiersEmpty(t *testing.T) {
-	result := hashIdentifiers([]string{}, "", Format64)
+	result := hashIdentifiers(sha256.New(), []string{}, "")
 	if len(result) != 64 {
 		t.Errorf("Expected 64-character hash, got %d", len(result))
 	}
@@ -22,8 +38,8 @@ func TestHashIdentifiersSorting(t *testing.T) {
 	ids1 := []string{"cpu:intel", "uuid:123"}
 	ids2 := []string{"uuid:123", "cpu:intel"}
 
-	hash1 := hashIdentifiers(ids1, "test", Format64)
-	hash2 := hashIdentifiers(ids2, "test", Format64)
+	hash1 := hashIdentifiers(sha256.New(), ids1, "test")
+	hash2 := hashIdentifiers(sha256.New(), ids2, "test")
 
 	if hash1 != hash2 {
 		t.Error("Hash should be same regardless of input order")
@@ -34,14 +50,106 @@ func TestHashIdentifiersSorting(t *testing.T) {
 func TestHashIdentifiersWithoutSalt(t *testing.T) {
 	ids := []string{"test1", "test2"}
 
-	withSalt := hashIdentifiers(ids, "mysalt", Format64)
-	withoutSalt := hashIdentifiers(ids, "", Format64)
+	withSalt := hashIdentifiers(sha256.New(), ids, "mysalt")
+	withoutSalt := hashIdentifiers(sha256.New(), ids, "")
 
 	if withSalt == withoutSalt {
 		t.Error("Hash with salt should differ from hash without salt")
 	}
 }
 
+// TestHMACIdentifiers tests that keyed hashing depends on the key, differs
+// from plain hashing, and keeps the format lengths.
+func TestHMACIdentifiers(t *testing.T) {
+	ids := []string{"cpu:test", "uuid:test"}
+
+	keyA := hashIdentifiers(hmac.New(sha256.New, []byte("key-a")), slices.Clone(ids), "")
+	keyB := hashIdentifiers(hmac.New(sha256.New, []byte("key-b")), slices.Clone(ids), "")
+	if keyA == keyB {
+		t.Error("HMAC with different keys should produce different hashes")
+	}
+	if keyA == hashIdentifiers(sha256.New(), slices.Clone(ids), "") {
+		t.Error("HMAC should differ from plain SHA-256")
+	}
+	if keyA == hashIdentifiers(hmac.New(sha256.New, []byte("key-a")), slices.Clone(ids), "salt") {
+		t.Error("salt should still be mixed into the HMAC message")
+	}
+
+	for _, mode := range []FormatMode{Format32, Format64, Format128, Format256} {
+		if got, want := len(formatHash(keyA, mode, sha256.New)), formatLength(mode); got != want {
+			t.Errorf("formatHash() length of HMAC for mode %d = %d, want %d", mode, got, want)
+		}
+	}
+}
+
+// joinHashIdentifiers is the previous implementation of hashIdentifiers, which
+// joined the identifiers in memory before hashing.
+func joinHashIdentifiers(identifiers []string, salt string) string {
+	slices.Sort(identifiers)
+	combined := strings.Join(identifiers, "|")
+	if salt != "" {
+		combined = salt + "|" + combined
+	}
+	hash := sha256.Sum256([]byte(combined))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// largeIdentifierSet returns n distinct identifiers in unsorted order.
+func largeIdentifierSet(n int) []string {
+	identifiers := make([]string, n)
+	for i := range identifiers {
+		identifiers[i] = fmt.Sprintf("disk:SERIAL-%08d-%s", n-i, strings.Repeat("x", i%64))
+	}
+
+	return identifiers
+}
+
+// TestHashIdentifiersStreaming tests that streaming the identifiers into the
+// hash produces the same output as hashing the joined message.
+func TestHashIdentifiersStreaming(t *testing.T) {
+	tests := []struct {
+		name        string
+		identifiers []string
+		salt        string
+	}{
+		{"empty", nil, ""},
+		{"empty with salt", nil, "salt"},
+		{"single", []string{"cpu:test"}, ""},
+		{"large", largeIdentifierSet(10000), ""},
+		{"large with salt", largeIdentifierSet(10000), "personalization:app|window:42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := joinHashIdentifiers(slices.Clone(tt.identifiers), tt.salt)
+			if got := hashIdentifiers(sha256.New(), slices.Clone(tt.identifiers), tt.salt); got != want {
+				t.Errorf("hashIdentifiers() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+// BenchmarkHashIdentifiers compares the allocations of the joined and the
+// streamed hash input.
+func BenchmarkHashIdentifiers(b *testing.B) {
+	identifiers := largeIdentifierSet(1000)
+
+	b.Run("Join", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = joinHashIdentifiers(identifiers, "salt")
+		}
+	})
+
+	b.Run("Stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = hashIdentifiers(sha256.New(), identifiers, "salt")
+		}
+	})
+}
+
 // TestAppendIdentifierIfValidEmpty tests with empty value.
 func TestAppendIdentifierIfValidEmpty(t *testing.T) {
 	diag := &DiagnosticInfo{Errors: make(map[string]error)}
@@ -49,7 +157,7 @@ func TestAppendIdentifierIfValidEmpty(t *testing.T) {
 		return "", nil
 	}
 
-	result := appendIdentifierIfValid([]string{"existing"}, getValue, "prefix:", diag, "test", nil)
+	result := appendIdentifierIfValid([]string{"existing"}, getValue, "prefix:", diag, "test", nil, nil)
 	if len(result) != 1 {
 		t.Errorf("Expected 1 identifier, got %d", len(result))
 	}
@@ -76,7 +184,7 @@ func TestAppendIdentifierIfValidError(t *testing.T) {
 		return "", fmt.Errorf("test error")
 	}
 
-	result := appendIdentifierIfValid([]string{"existing"}, getValue, "prefix:", diag, "test", nil)
+	result := appendIdentifierIfValid([]string{"existing"}, getValue, "prefix:", diag, "test", nil, nil)
 	if len(result) != 1 {
 		t.Errorf("Expected 1 identifier (original), got %d", len(result))
 	}
@@ -100,7 +208,7 @@ func TestAppendIdentifierIfValidSuccess(t *testing.T) {
 		return "good-value", nil
 	}
 
-	result := appendIdentifierIfValid([]string{"existing"}, getValue, "prefix:", diag, "test", nil)
+	result := appendIdentifierIfValid([]string{"existing"}, getValue, "prefix:", diag, "test", nil, nil)
 	if len(result) != 2 {
 		t.Errorf("Expected 2 identifiers, got %d", len(result))
 	}
@@ -116,7 +224,7 @@ func TestAppendIdentifiersIfValidEmpty(t *testing.T) {
 		return []string{}, nil
 	}
 
-	result := appendIdentifiersIfValid([]string{"existing"}, getValues, "prefix:", diag, "test", nil)
+	result := appendIdentifiersIfValid([]string{"existing"}, getValues, "prefix:", diag, "test", nil, nil)
 	if len(result) != 1 {
 		t.Errorf("Expected 1 identifier, got %d", len(result))
 	}
@@ -129,7 +237,7 @@ func TestAppendIdentifiersIfValidError(t *testing.T) {
 		return nil, fmt.Errorf("test error")
 	}
 
-	result := appendIdentifiersIfValid([]string{"existing"}, getValues, "prefix:", diag, "test", nil)
+	result := appendIdentifiersIfValid([]string{"existing"}, getValues, "prefix:", diag, "test", nil, nil)
 	if len(result) != 1 {
 		t.Errorf("Expected 1 identifier (original), got %d", len(result))
 	}
@@ -153,7 +261,7 @@ func TestAppendIdentifiersIfValidMultiple(t *testing.T) {
 		return []string{"val1", "val2", "val3"}, nil
 	}
 
-	result := appendIdentifiersIfValid([]string{"existing"}, getValues, "prefix:", diag, "test", nil)
+	result := appendIdentifiersIfValid([]string{"existing"}, getValues, "prefix:", diag, "test", nil, nil)
 	if len(result) != 4 {
 		t.Errorf("Expected 4 identifiers, got %d", len(result))
 	}
@@ -175,7 +283,7 @@ func TestAppendIdentifierNilDiag(t *testing.T) {
 		return "value", nil
 	}
 
-	result := appendIdentifierIfValid(nil, getValue, "prefix:", nil, "test", nil)
+	result := appendIdentifierIfValid(nil, getValue, "prefix:", nil, "test", nil, nil)
 	if len(result) != 1 {
 		t.Errorf("Expected 1 identifier, got %d", len(result))
 	}
@@ -190,7 +298,7 @@ func TestAppendIdentifierIfValidWithLogger(t *testing.T) {
 
 		result := appendIdentifierIfValid(nil, func() (string, error) {
 			return "", fmt.Errorf("test error")
-		}, "prefix:", diag, "test-comp", logger)
+		}, "prefix:", diag, "test-comp", logger, nil)
 
 		if len(result) != 0 {
 			t.Errorf("Expected 0 identifiers, got %d", len(result))
@@ -207,7 +315,7 @@ func TestAppendIdentifierIfValidWithLogger(t *testing.T) {
 
 		result := appendIdentifierIfValid(nil, func() (string, error) {
 			return "", nil
-		}, "prefix:", diag, "test-comp", logger)
+		}, "prefix:", diag, "test-comp", logger, nil)
 
 		if len(result) != 0 {
 			t.Errorf("Expected 0 identifiers, got %d", len(result))
@@ -224,7 +332,7 @@ func TestAppendIdentifierIfValidWithLogger(t *testing.T) {
 
 		result := appendIdentifierIfValid(nil, func() (string, error) {
 			return "good-value", nil
-		}, "prefix:", diag, "test-comp", logger)
+		}, "prefix:", diag, "test-comp", logger, nil)
 
 		if len(result) != 1 {
 			t.Errorf("Expected 1 identifier, got %d", len(result))
@@ -238,6 +346,37 @@ func TestAppendIdentifierIfValidWithLogger(t *testing.T) {
 	})
 }
 
+// TestWithRedactor tests that the custom redactor masks values in log output
+// while the ID is still computed from the raw values.
+func TestWithRedactor(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	p := New().WithCPU().WithLogger(logger).WithRedactor(func(component, _ string) string {
+		return "masked-" + component
+	})
+
+	id, err := p.ID(context.Background())
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "masked-cpu") {
+		t.Errorf("log output should contain the redacted value, got:\n%s", output)
+	}
+	if raw := p.componentValues[ComponentCPU][0]; strings.Contains(output, raw) {
+		t.Errorf("log output should not contain the raw value %q", raw)
+	}
+
+	plain, err := New().WithCPU().ID(context.Background())
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	if id != plain {
+		t.Error("WithRedactor() should not change the ID")
+	}
+}
+
 // TestAppendIdentifiersIfValidWithLogger tests all logger paths in appendIdentifiersIfValid.
 func TestAppendIdentifiersIfValidWithLogger(t *testing.T) {
 	t.Run("error with logger", func(t *testing.T) {
@@ -247,7 +386,7 @@ func TestAppendIdentifiersIfValidWithLogger(t *testing.T) {
 
 		result := appendIdentifiersIfValid(nil, func() ([]string, error) {
 			return nil, fmt.Errorf("test error")
-		}, "prefix:", diag, "test-comp", logger)
+		}, "prefix:", diag, "test-comp", logger, nil)
 
 		if len(result) != 0 {
 			t.Errorf("Expected 0 identifiers, got %d", len(result))
@@ -264,7 +403,7 @@ func TestAppendIdentifiersIfValidWithLogger(t *testing.T) {
 
 		result := appendIdentifiersIfValid(nil, func() ([]string, error) {
 			return []string{}, nil
-		}, "prefix:", diag, "test-comp", logger)
+		}, "prefix:", diag, "test-comp", logger, nil)
 
 		if len(result) != 0 {
 			t.Errorf("Expected 0 identifiers, got %d", len(result))
@@ -281,7 +420,7 @@ func TestAppendIdentifiersIfValidWithLogger(t *testing.T) {
 
 		result := appendIdentifiersIfValid(nil, func() ([]string, error) {
 			return []string{"val1", "val2"}, nil
-		}, "prefix:", diag, "test-comp", logger)
+		}, "prefix:", diag, "test-comp", logger, nil)
 
 		if len(result) != 2 {
 			t.Errorf("Expected 2 identifiers, got %d", len(result))
@@ -300,7 +439,7 @@ func TestAppendIdentifiersIfValidEmptyWithDiag(t *testing.T) {
 	diag := &DiagnosticInfo{Errors: make(map[string]error)}
 	result := appendIdentifiersIfValid(nil, func() ([]string, error) {
 		return []string{}, nil
-	}, "prefix:", diag, "test", nil)
+	}, "prefix:", diag, "test", nil, nil)
 
 	if len(result) != 0 {
 		t.Errorf("Expected 0 identifiers, got %d", len(result))
@@ -321,10 +460,101 @@ func TestAppendIdentifiersIfValidEmptyWithDiag(t *testing.T) {
 	}
 }
 
+// TestFormatHashRehashChain tests that extended SHA-256 formats append the
+// hashes of the previous part's hex encoding.
+func TestFormatHashRehashChain(t *testing.T) {
+	digest := hashIdentifiers(sha256.New(), []string{"cpu:test"}, "")
+
+	want := digest
+	for last := digest; len(want) < 256; {
+		sum := sha256.Sum256([]byte(last))
+		last = hex.EncodeToString(sum[:])
+		want += last
+	}
+
+	if got := formatHash(digest, Format256, sha256.New); got != want {
+		t.Errorf("formatHash(Format256) = %s, want %s", got, want)
+	}
+	if got := formatHash(digest, Format128, sha256.New); got != want[:128] {
+		t.Errorf("formatHash(Format128) = %s, want %s", got, want[:128])
+	}
+}
+
+// concatFormatHash is the previous implementation of formatHash, which
+// concatenated the hex encoding of each hash into a new string.
+func concatFormatHash(digest string, mode FormatMode, newHash func() hash.Hash) string {
+	length := formatLength(mode)
+	formatted := digest
+	for last := digest; len(formatted) < length; {
+		h := newHash()
+		h.Write([]byte(last))
+		last = hex.EncodeToString(h.Sum(nil))
+		formatted += last
+	}
+
+	return formatted[:length]
+}
+
+// TestFormatHashMatchesConcat tests that formatHash is identical to the
+// previous concatenating implementation for every mode and hasher.
+func TestFormatHashMatchesConcat(t *testing.T) {
+	for _, newHash := range []func() hash.Hash{sha256.New, sha512.New} {
+		digest := hashIdentifiers(newHash(), []string{"cpu:test", "uuid:test"}, "salt")
+		for _, mode := range []FormatMode{Format32, Format64, Format128, Format256} {
+			if got, want := formatHash(digest, mode, newHash), concatFormatHash(digest, mode, newHash); got != want {
+				t.Errorf("formatHash(mode=%d, size=%d) = %s, want %s", mode, newHash().Size(), got, want)
+			}
+		}
+	}
+}
+
+// BenchmarkFormatHash compares the allocations of the concatenated and the
+// buffered Format256 extension.
+func BenchmarkFormatHash(b *testing.B) {
+	digest := hashIdentifiers(sha256.New(), []string{"cpu:test"}, "")
+
+	b.Run("Concat", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = concatFormatHash(digest, Format256, sha256.New)
+		}
+	})
+
+	b.Run("Buffer", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = formatHash(digest, Format256, sha256.New)
+		}
+	})
+}
+
+// TestFormatHashSHA512 tests that a 128-character SHA-512 digest is truncated
+// to the shorter formats, is itself Format128, and extends to Format256.
+func TestFormatHashSHA512(t *testing.T) {
+	digest := hashIdentifiers(sha512.New(), []string{"cpu:test"}, "")
+	if len(digest) != 128 {
+		t.Fatalf("SHA-512 digest length = %d, want 128", len(digest))
+	}
+
+	for _, mode := range []FormatMode{Format32, Format64, Format128, Format256} {
+		got := formatHash(digest, mode, sha512.New)
+		if len(got) != formatLength(mode) {
+			t.Errorf("formatHash(mode=%d) length = %d, want %d", mode, len(got), formatLength(mode))
+		}
+		if !strings.HasPrefix(got, digest[:min(len(got), len(digest))]) {
+			t.Errorf("formatHash(mode=%d) = %s, want a prefix of or extension of the digest", mode, got)
+		}
+	}
+
+	if got := formatHash(digest, Format64, sha256.New); got != digest {
+		t.Error("a digest of another hasher's length should be returned unchanged")
+	}
+}
+
 // TestFormatHashInvalidLength tests formatHash with non-64-char input.
 func TestFormatHashInvalidLength(t *testing.T) {
 	short := "abc123"
-	result := formatHash(short, Format64)
+	result := formatHash(short, Format64, sha256.New)
 	if result != short {
 		t.Errorf("Expected input returned unchanged for invalid length, got %q", result)
 	}
@@ -334,7 +564,7 @@ func TestFormatHashInvalidLength(t *testing.T) {
 func TestFormatHashDefaultCase(t *testing.T) {
 	// Create a valid 64-char hex string
 	hash := "a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2"
-	result := formatHash(hash, FormatMode(999))
+	result := formatHash(hash, FormatMode(999), sha256.New)
 	if result != hash {
 		t.Errorf("Expected input returned unchanged for unknown format mode, got %q", result)
 	}
@@ -355,7 +585,7 @@ func TestFormatHashAllModes(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		result := formatHash(hash, tt.mode)
+		result := formatHash(hash, tt.mode, sha256.New)
 		if len(result) != tt.wantLength {
 			t.Errorf("formatHash(mode=%d) length = %d, want %d", tt.mode, len(result), tt.wantLength)
 		}
@@ -422,6 +652,31 @@ func TestEnabledComponentsAll(t *testing.T) {
 	}
 }
 
+// TestWithAll tests that WithAll enables every selectable component, and that
+// every hardware component with a default weight is selectable.
+func TestWithAll(t *testing.T) {
+	p := New().WithAll()
+
+	if got := p.enabledComponents(); !slices.Equal(got, selectableComponents) {
+		t.Errorf("enabledComponents() = %v, want %v", got, selectableComponents)
+	}
+	if flags := p.componentFlags(); len(flags) != len(selectableComponents) {
+		t.Errorf("componentFlags() has %d entries, want %d", len(flags), len(selectableComponents))
+	}
+	for component := range defaultComponentWeights {
+		if component != ComponentMachineID && component != ComponentOSMachineID && !slices.Contains(selectableComponents, component) {
+			t.Errorf("component %q is not enabled by WithAll", component)
+		}
+	}
+	if !p.optional[ComponentThunderbolt] {
+		t.Error("WithAll should keep thunderbolt optional")
+	}
+
+	if got := p.VMFriendly().enabledComponents(); !slices.Equal(got, []string{ComponentCPU, ComponentSystemUUID}) {
+		t.Errorf("VMFriendly() after WithAll enabled %v", got)
+	}
+}
+
 // TestEnabledComponentsNone tests no components enabled.
 func TestEnabledComponentsNone(t *testing.T) {
 	p := New()
@@ -493,3 +748,871 @@ func TestExecuteCommandWithLogger(t *testing.T) {
 		}
 	})
 }
+
+// sourceConfigKey is the context key used by TestWithSourceConfig.
+type sourceConfigKey struct{}
+
+// sourceConfigExecutor is a custom collector that answers every command with the
+// value configured under sourceConfigKey.
+type sourceConfigExecutor struct {
+	seen []any
+}
+
+// Execute implements CommandExecutor.
+func (e *sourceConfigExecutor) Execute(ctx context.Context, name string, args ...string) (string, error) {
+	value := ctx.Value(sourceConfigKey{})
+	e.seen = append(e.seen, value)
+
+	device, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("no device configured for %q", name)
+	}
+
+	return device, nil
+}
+
+// TestWithSourceConfig tests that configured values reach custom collectors via context.
+func TestWithSourceConfig(t *testing.T) {
+	executor := &sourceConfigExecutor{}
+
+	p := New().
+		WithExecutor(executor).
+		WithSourceConfig(sourceConfigKey{}, "/dev/custom0").
+		WithDisk()
+
+	_, _ = p.ID(context.Background())
+
+	if len(executor.seen) == 0 {
+		t.Fatal("Expected custom executor to be invoked")
+	}
+	for i, value := range executor.seen {
+		if value != "/dev/custom0" {
+			t.Errorf("call %d: ctx.Value() = %v, want %q", i, value, "/dev/custom0")
+		}
+	}
+}
+
+// TestWithSourceConfigReplace tests that registering the same key twice keeps the last value.
+func TestWithSourceConfigReplace(t *testing.T) {
+	executor := &sourceConfigExecutor{}
+
+	p := New().
+		WithExecutor(executor).
+		WithSourceConfig(sourceConfigKey{}, "first").
+		WithSourceConfig(sourceConfigKey{}, "second").
+		WithDisk()
+
+	_, _ = p.ID(context.Background())
+
+	if len(executor.seen) == 0 {
+		t.Fatal("Expected custom executor to be invoked")
+	}
+	if executor.seen[0] != "second" {
+		t.Errorf("ctx.Value() = %v, want %q", executor.seen[0], "second")
+	}
+}
+
+// TestWithEventSink tests that the event sink fires once per fresh generation.
+func TestWithEventSink(t *testing.T) {
+	var events [][]byte
+
+	p := New().WithCPU().WithEventSink(func(data []byte) {
+		events = append(events, data)
+	})
+
+	id, err := p.ID(context.Background())
+	if err != nil {
+		t.Fatalf("ID() error: %v", err)
+	}
+
+	// Cached call must not emit another event.
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Fatalf("second ID() error: %v", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+
+	var event generationEvent
+	if err := json.Unmarshal(events[0], &event); err != nil {
+		t.Fatalf("event is not valid JSON: %v", err)
+	}
+	if event.Platform != runtime.GOOS {
+		t.Errorf("event.Platform = %q, want %q", event.Platform, runtime.GOOS)
+	}
+	if len(event.Components) != 1 || event.Components[0] != ComponentCPU {
+		t.Errorf("event.Components = %v, want [%s]", event.Components, ComponentCPU)
+	}
+	if bytes.Contains(events[0], []byte(id)) {
+		t.Error("event must not contain the raw machine ID")
+	}
+
+	idHash := sha256.Sum256([]byte(id))
+	if event.IDSHA256 != hex.EncodeToString(idHash[:]) {
+		t.Errorf("event.IDSHA256 = %q, want SHA-256 of ID", event.IDSHA256)
+	}
+}
+
+// TestWithEventSinkNotCalledOnFailure tests that failed generations emit no event.
+func TestWithEventSinkNotCalledOnFailure(t *testing.T) {
+	called := false
+	p := New().WithEventSink(func([]byte) { called = true })
+
+	if _, err := p.ID(context.Background()); !errors.Is(err, ErrNoIdentifiers) {
+		t.Fatalf("Expected ErrNoIdentifiers, got %v", err)
+	}
+	if called {
+		t.Error("event sink should not be called when generation fails")
+	}
+}
+
+// TestWithEventSinkNil tests that a nil event sink is safe.
+func TestWithEventSinkNil(t *testing.T) {
+	p := New().WithCPU().WithEventSink(nil)
+
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error: %v", err)
+	}
+}
+
+// TestWithErrorCallback tests that the error callback fires once per failed
+// component with the wrapped ComponentError, and not for successes or absent
+// optional components.
+func TestWithErrorCallback(t *testing.T) {
+	type failure struct {
+		component string
+		err       error
+	}
+	var failures []failure
+	probeErr := errors.New("requires administrator")
+
+	p := New().WithOptionalComponents(ComponentMAC).WithErrorCallback(func(component string, err error) {
+		failures = append(failures, failure{component, err})
+	})
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+	ctx := context.Background()
+
+	p.appendIdentifier(ctx, nil, func(context.Context) (string, error) {
+		return "GenuineIntel", nil
+	}, "cpu:", diag, ComponentCPU)
+	p.appendIdentifier(ctx, nil, func(context.Context) (string, error) {
+		return "", probeErr
+	}, "uuid:", diag, ComponentSystemUUID)
+	p.appendIdentifiers(ctx, nil, func(context.Context) ([]string, error) {
+		return nil, nil
+	}, "mac:", diag, ComponentMAC)
+
+	if len(failures) != 1 {
+		t.Fatalf("callback called %d times, want 1: %v", len(failures), failures)
+	}
+	if failures[0].component != ComponentSystemUUID {
+		t.Errorf("callback component = %q, want %q", failures[0].component, ComponentSystemUUID)
+	}
+	var compErr *ComponentError
+	if !errors.As(failures[0].err, &compErr) || compErr.Component != ComponentSystemUUID {
+		t.Errorf("callback error = %v, want *ComponentError for %q", failures[0].err, ComponentSystemUUID)
+	}
+	if !errors.Is(failures[0].err, probeErr) {
+		t.Errorf("callback error = %v, want it to wrap %v", failures[0].err, probeErr)
+	}
+}
+
+// TestWithFastModeSkipsDisk tests that fast mode never collects disk serials.
+func TestWithFastModeSkipsDisk(t *testing.T) {
+	mock := newMockExecutor()
+	p := New().WithCPU().WithDisk().WithFastMode().WithExecutor(mock)
+
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error: %v", err)
+	}
+
+	diag := p.Diagnostics()
+	if slices.Contains(diag.Collected, ComponentDisk) {
+		t.Error("disk should not be collected in fast mode")
+	}
+	if _, ok := diag.Errors[ComponentDisk]; ok {
+		t.Error("disk should not be attempted in fast mode")
+	}
+}
+
+// TestDuplicateComponentValues tests that a serial reported by two components is
+// flagged in diagnostics and, with deduplication, contributes only once.
+func TestDuplicateComponentValues(t *testing.T) {
+	const chassis = "chassis"
+	ctx := context.Background()
+	serial := func(context.Context) (string, error) { return "OEM-SERIAL-123", nil }
+
+	for _, deduplicate := range []bool{false, true} {
+		p := New()
+		if deduplicate {
+			p.WithDeduplicateComponents()
+		}
+		diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+		var identifiers []string
+		identifiers = p.appendIdentifier(ctx, identifiers, serial, "mb:", diag, ComponentMotherboard)
+		identifiers = p.appendIdentifier(ctx, identifiers, serial, "chassis:", diag, chassis)
+
+		if got := diag.Duplicates[chassis]; !slices.Equal(got, []string{ComponentMotherboard}) {
+			t.Errorf("deduplicate=%v: Duplicates[%q] = %v, want [%s]", deduplicate, chassis, got, ComponentMotherboard)
+		}
+		if _, ok := diag.Duplicates[ComponentMotherboard]; ok {
+			t.Errorf("deduplicate=%v: first component should not be reported as a duplicate", deduplicate)
+		}
+
+		wantCount := 2
+		if deduplicate {
+			wantCount = 1
+		}
+		if len(identifiers) != wantCount {
+			t.Errorf("deduplicate=%v: got %d identifiers, want %d", deduplicate, len(identifiers), wantCount)
+		}
+	}
+}
+
+// TestDuplicateComponentValuesMultiple tests that only duplicated values of a
+// multi-value component are dropped.
+func TestDuplicateComponentValuesMultiple(t *testing.T) {
+	p := New().WithDeduplicateComponents()
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	ctx := context.Background()
+
+	identifiers := p.appendIdentifier(ctx, nil, func(context.Context) (string, error) {
+		return "SERIAL-A", nil
+	}, "mb:", diag, ComponentMotherboard)
+	identifiers = p.appendIdentifiers(ctx, identifiers, func(context.Context) ([]string, error) {
+		return []string{"SERIAL-A", "SERIAL-B"}, nil
+	}, "disk:", diag, ComponentDisk)
+
+	want := []string{"mb:SERIAL-A", "disk:SERIAL-B"}
+	if !slices.Equal(identifiers, want) {
+		t.Errorf("identifiers = %v, want %v", identifiers, want)
+	}
+	if !slices.Contains(diag.Collected, ComponentDisk) {
+		t.Error("disk should still be reported as collected")
+	}
+}
+
+// TestComponentTimeoutError tests that a component exceeding its own deadline
+// is recorded with ErrComponentTimeout, while other failures are not.
+func TestComponentTimeoutError(t *testing.T) {
+	p := New().WithComponentTimeout(10 * time.Millisecond)
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+	slow := &slowExecutor{delay: time.Second, output: "SERIAL"}
+
+	identifiers := p.appendIdentifier(context.Background(), nil, func(ctx context.Context) (string, error) {
+		return executeCommand(ctx, slow, nil, "slow-tool")
+	}, "disk:", diag, ComponentDisk)
+	identifiers = p.appendIdentifier(context.Background(), identifiers, func(context.Context) (string, error) {
+		return "", ErrNotFound
+	}, "mb:", diag, ComponentMotherboard)
+
+	if len(identifiers) != 0 {
+		t.Errorf("expected no identifiers, got %v", identifiers)
+	}
+
+	diskErr := diag.Errors[ComponentDisk]
+	if !errors.Is(diskErr, ErrComponentTimeout) {
+		t.Errorf("disk error = %v, want ErrComponentTimeout", diskErr)
+	}
+	if !errors.Is(diskErr, context.DeadlineExceeded) {
+		t.Errorf("disk error = %v, want wrapped context.DeadlineExceeded", diskErr)
+	}
+	var cmdErr *CommandError
+	if !errors.As(diskErr, &cmdErr) || cmdErr.Command != "slow-tool" {
+		t.Errorf("disk error = %v, want CommandError for slow-tool", diskErr)
+	}
+
+	if errors.Is(diag.Errors[ComponentMotherboard], ErrComponentTimeout) {
+		t.Error("motherboard error should not be classified as a timeout")
+	}
+}
+
+// TestComponentTimeoutErrorClassification tests that the error reported for
+// a timed-out component both classifies as a timeout and attributes it to the
+// component, on the same error value.
+func TestComponentTimeoutErrorClassification(t *testing.T) {
+	var reported error
+	p := New().
+		WithComponentTimeouts(map[string]time.Duration{ComponentDisk: 10 * time.Millisecond}).
+		WithErrorCallback(func(_ string, err error) { reported = err })
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+	slow := &slowExecutor{delay: time.Second, output: "SERIAL"}
+
+	p.appendIdentifier(context.Background(), nil, func(ctx context.Context) (string, error) {
+		return executeCommand(ctx, slow, nil, "slow-tool")
+	}, "disk:", diag, ComponentDisk)
+
+	for name, err := range map[string]error{"diagnostics": diag.Errors[ComponentDisk], "callback": reported} {
+		if !errors.Is(err, ErrComponentTimeout) {
+			t.Errorf("%s error = %v, want ErrComponentTimeout", name, err)
+		}
+		var compErr *ComponentError
+		if !errors.As(err, &compErr) || compErr.Component != ComponentDisk {
+			t.Errorf("%s error = %v, want ComponentError for %q", name, err, ComponentDisk)
+		}
+	}
+}
+
+// TestDefaultComponentTimeouts tests that every supported component has a
+// default timeout and that file-backed components are not given the generous
+// timeout of the disk probe.
+func TestDefaultComponentTimeouts(t *testing.T) {
+	defaults := DefaultComponentTimeouts()
+	for _, component := range SupportedComponents() {
+		if defaults[component] <= 0 {
+			t.Errorf("default timeout for %q = %v, want > 0", component, defaults[component])
+		}
+		if defaults[component] > defaults[ComponentDisk] {
+			t.Errorf("default timeout for %q = %v exceeds the disk timeout %v", component, defaults[component], defaults[ComponentDisk])
+		}
+	}
+
+	defaults[ComponentCPU] = time.Hour
+	if DefaultComponentTimeouts()[ComponentCPU] == time.Hour {
+		t.Error("DefaultComponentTimeouts() returned the shared table")
+	}
+}
+
+// TestWithComponentTimeouts tests that a fast component times out on its own
+// short timeout instead of waiting for a slow component's generous one, and
+// the precedence of overrides over the uniform and default timeouts.
+func TestWithComponentTimeouts(t *testing.T) {
+	p := New().WithComponentTimeouts(map[string]time.Duration{
+		ComponentCPU:  20 * time.Millisecond,
+		ComponentDisk: 5 * time.Second,
+	})
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+	hanging := &slowExecutor{delay: time.Second, output: "GenuineIntel"}
+	slow := &slowExecutor{delay: 50 * time.Millisecond, output: "SERIAL"}
+
+	identifiers := p.appendIdentifier(context.Background(), nil, func(ctx context.Context) (string, error) {
+		return executeCommand(ctx, hanging, nil, "cpu-tool")
+	}, "cpu:", diag, ComponentCPU)
+	identifiers = p.appendIdentifiers(context.Background(), identifiers, func(ctx context.Context) ([]string, error) {
+		serial, err := executeCommand(ctx, slow, nil, "disk-tool")
+		return []string{serial}, err
+	}, "disk:", diag, ComponentDisk)
+
+	if !slices.Equal(identifiers, []string{"disk:SERIAL"}) {
+		t.Errorf("identifiers = %v, want only the disk", identifiers)
+	}
+	if !errors.Is(diag.Errors[ComponentCPU], ErrComponentTimeout) {
+		t.Errorf("cpu error = %v, want ErrComponentTimeout", diag.Errors[ComponentCPU])
+	}
+	if d := p.componentDurations[ComponentCPU]; d >= 500*time.Millisecond {
+		t.Errorf("cpu took %v, want it bounded by its own 20ms timeout", d)
+	}
+
+	p.WithComponentTimeout(time.Minute)
+	if got := p.timeoutFor(ComponentCPU); got != 20*time.Millisecond {
+		t.Errorf("timeoutFor(cpu) = %v, want the override", got)
+	}
+	if got := p.timeoutFor(ComponentMAC); got != time.Minute {
+		t.Errorf("timeoutFor(mac) = %v, want the uniform timeout", got)
+	}
+	if got := New().timeoutFor(ComponentMAC); got != defaultComponentTimeouts[ComponentMAC] {
+		t.Errorf("timeoutFor(mac) = %v, want the platform default", got)
+	}
+}
+
+// TestComponentTimeoutErrorParentDeadline tests that an expired caller context
+// is not reported as a component timeout.
+func TestComponentTimeoutErrorParentDeadline(t *testing.T) {
+	p := New().WithComponentTimeout(time.Second)
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+	slow := &slowExecutor{delay: time.Second, output: "SERIAL"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	p.appendIdentifier(ctx, nil, func(ctx context.Context) (string, error) {
+		return executeCommand(ctx, slow, nil, "slow-tool")
+	}, "disk:", diag, ComponentDisk)
+
+	diskErr := diag.Errors[ComponentDisk]
+	if !errors.Is(diskErr, context.DeadlineExceeded) {
+		t.Fatalf("disk error = %v, want context.DeadlineExceeded", diskErr)
+	}
+	if errors.Is(diskErr, ErrComponentTimeout) {
+		t.Error("caller deadline should not be classified as a component timeout")
+	}
+}
+
+// TestIDCanceledContext tests that ID returns the context error without
+// collecting anything when the caller's context is already canceled.
+func TestIDCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	listed := 0
+	p := New().WithMAC()
+	p.listInterfaces = func() ([]net.Interface, error) {
+		listed++
+		return physicalOnlyInterfaces()
+	}
+
+	if id, err := p.ID(ctx); !errors.Is(err, context.Canceled) || id != "" {
+		t.Errorf("ID() = %q, %v; want context.Canceled", id, err)
+	}
+	if listed != 0 {
+		t.Errorf("interfaces listed %d times, want 0", listed)
+	}
+
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Errorf("ID() after cancellation error = %v, want an ID", err)
+	}
+}
+
+// TestWithMaxTotalDuration tests that ID returns within the total bound even
+// when every command-based collector is slow and the caller passes Background.
+func TestWithMaxTotalDuration(t *testing.T) {
+	slow := &slowExecutor{delay: 5 * time.Second, output: "SLOW"}
+	p := New().WithCPU().WithMotherboard().WithSystemUUID().WithDisk().
+		WithExecutor(slow).
+		WithMaxTotalDuration(50 * time.Millisecond)
+
+	start := time.Now()
+	_, err := p.ID(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ID() took %v, want it bounded by WithMaxTotalDuration", elapsed)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ID() error = %v; the total bound should not abort the collection", err)
+	}
+}
+
+// TestWithLazyLogger tests that a lazily supplied logger receives generation
+// log lines and is resolved only once it becomes available.
+func TestWithLazyLogger(t *testing.T) {
+	var buf bytes.Buffer
+	var logger *slog.Logger
+	calls := 0
+
+	p := New().WithCPU().WithLazyLogger(func() *slog.Logger {
+		calls++
+		return logger
+	})
+
+	// The framework has not built the logger yet: generation proceeds unlogged.
+	p.mu.Lock()
+	p.resolveLogger()
+	p.mu.Unlock()
+	if p.logger != nil {
+		t.Fatal("nil lazy logger should behave like no logger")
+	}
+
+	logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error: %v", err)
+	}
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error: %v", err)
+	}
+
+	if !bytes.Contains(buf.Bytes(), []byte("generating machine ID")) {
+		t.Error("Expected 'generating machine ID' in lazy logger output")
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("returning cached machine ID")) {
+		t.Error("Expected 'returning cached machine ID' in lazy logger output")
+	}
+	if calls != 2 {
+		t.Errorf("lazy logger func called %d times, want 2 (once nil, then cached)", calls)
+	}
+}
+
+// TestWithDefaultLogger tests that the provider logs to slog.Default and
+// follows later slog.SetDefault calls.
+func TestWithDefaultLogger(t *testing.T) {
+	previous := slog.Default()
+	defer slog.SetDefault(previous)
+
+	var first, second bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewTextHandler(&first, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	p := New().WithCPU().WithDefaultLogger()
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error: %v", err)
+	}
+	if !bytes.Contains(first.Bytes(), []byte("generating machine ID")) {
+		t.Error("Expected 'generating machine ID' in default logger output")
+	}
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(&second, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error: %v", err)
+	}
+	if !bytes.Contains(second.Bytes(), []byte("returning cached machine ID")) {
+		t.Error("Expected 'returning cached machine ID' in the new default logger output")
+	}
+	if bytes.Contains(first.Bytes(), []byte("returning cached machine ID")) {
+		t.Error("Replaced default logger should not receive further output")
+	}
+}
+
+// physicalOnlyInterfaces lists a bare-metal host with a single physical NIC.
+func physicalOnlyInterfaces() ([]net.Interface, error) {
+	return []net.Interface{
+		{Index: 1, Name: "lo", Flags: net.FlagUp | net.FlagLoopback},
+		{Index: 2, Name: "eth0", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x3c, 0x7c, 0x3f, 0x1a, 0x2b, 0x3c}},
+	}, nil
+}
+
+// TestWithOptionalComponents tests that an optional component returning no
+// values under MACFilterVirtual on bare metal is absent rather than failed.
+func TestWithOptionalComponents(t *testing.T) {
+	p := New().WithCPU().WithMAC(MACFilterVirtual).WithExecutor(newMockExecutor()).
+		WithOptionalComponents(ComponentMAC)
+	p.listInterfaces = physicalOnlyInterfaces
+
+	if _, err := p.ID(context.Background()); err != nil && !errors.Is(err, ErrNoIdentifiers) {
+		t.Fatalf("ID() error: %v", err)
+	}
+
+	diag := p.Diagnostics()
+	if _, ok := diag.Errors[ComponentMAC]; ok {
+		t.Errorf("optional MAC should not be recorded as an error: %v", diag.Errors[ComponentMAC])
+	}
+	if !slices.Equal(diag.Absent, []string{ComponentMAC}) {
+		t.Errorf("Absent = %v, want [%s]", diag.Absent, ComponentMAC)
+	}
+
+	required := New().WithCPU().WithMAC(MACFilterVirtual).WithExecutor(newMockExecutor())
+	required.listInterfaces = physicalOnlyInterfaces
+	_, _ = required.ID(context.Background())
+
+	if err := required.Diagnostics().Errors[ComponentMAC]; !errors.Is(err, ErrNoValues) {
+		t.Errorf("required MAC error = %v, want ErrNoValues", err)
+	}
+	if len(required.Diagnostics().Absent) != 0 {
+		t.Errorf("required MAC should not be absent: %v", required.Diagnostics().Absent)
+	}
+}
+
+// TestWithOptionalComponentsAllAbsent tests that an ID still needs a value.
+func TestWithOptionalComponentsAllAbsent(t *testing.T) {
+	p := New().WithMAC(MACFilterVirtual).WithOptionalComponents(ComponentMAC)
+	p.listInterfaces = physicalOnlyInterfaces
+
+	if _, err := p.ID(context.Background()); !errors.Is(err, ErrNoIdentifiers) {
+		t.Errorf("ID() error = %v, want ErrNoIdentifiers", err)
+	}
+}
+
+// TestSortComponents tests that collected components follow enabledComponents
+// order regardless of the order in which they were collected.
+func TestSortComponents(t *testing.T) {
+	p := New().WithCPU().WithMotherboard().WithSystemUUID().WithMAC().WithDisk()
+	order := p.enabledComponents()
+
+	collected := []string{ComponentDisk, ComponentMachineID, ComponentSystemUUID, ComponentMAC, ComponentCPU, ComponentMotherboard}
+	sortComponents(collected, order)
+
+	want := append(slices.Clone(order), ComponentMachineID)
+	if !slices.Equal(collected, want) {
+		t.Errorf("sortComponents() = %v, want %v", collected, want)
+	}
+}
+
+// TestCollectedOrder tests that Diagnostics().Collected follows enabledComponents order.
+func TestCollectedOrder(t *testing.T) {
+	p := New().WithCPU().WithSystemUUID().WithMAC()
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Skipf("ID() error: %v", err)
+	}
+
+	collected := p.Diagnostics().Collected
+	sorted := slices.Clone(collected)
+	sortComponents(sorted, p.enabledComponents())
+	if !slices.Equal(collected, sorted) {
+		t.Errorf("Collected = %v, want canonical order %v", collected, sorted)
+	}
+}
+
+// TestWithComponentValueTransform tests reproducing a legacy tool's disk serial
+// normalization (uppercased, colons stripped) without affecting other components.
+func TestWithComponentValueTransform(t *testing.T) {
+	legacy := func(value string) string {
+		return strings.ToUpper(strings.ReplaceAll(value, ":", ""))
+	}
+	p := New().WithComponentValueTransform(ComponentDisk, legacy)
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+	ctx := context.Background()
+
+	identifiers := p.appendIdentifiers(ctx, nil, func(context.Context) ([]string, error) {
+		return []string{"ws:dc-1234", "s4ev:nf0m"}, nil
+	}, "disk:", diag, ComponentDisk)
+	identifiers = p.appendIdentifier(ctx, identifiers, func(context.Context) (string, error) {
+		return "mb:serial", nil
+	}, "mb:", diag, ComponentMotherboard)
+
+	want := []string{"disk:S4EVNF0M", "disk:WSDC-1234", "mb:mb:serial"}
+	if !slices.Equal(identifiers, want) {
+		t.Errorf("identifiers = %v, want %v", identifiers, want)
+	}
+}
+
+// TestWithSetHashing tests that a set-hashed component contributes a single,
+// order-independent value, while other components keep one entry per value.
+func TestWithSetHashing(t *testing.T) {
+	ctx := context.Background()
+	collect := func(p *Provider, macs, disks []string) []string {
+		identifiers := p.appendIdentifiers(ctx, nil, func(context.Context) ([]string, error) {
+			return slices.Clone(macs), nil
+		}, "mac:", nil, ComponentMAC)
+
+		return p.appendIdentifiers(ctx, identifiers, func(context.Context) ([]string, error) {
+			return slices.Clone(disks), nil
+		}, "disk:", nil, ComponentDisk)
+	}
+	macs := []string{"aa:aa:aa:aa:aa:aa", "cc:cc:cc:cc:cc:cc"}
+	disks := []string{"WD-1", "WD-2"}
+
+	perValue := collect(New(), macs, disks)
+	if len(perValue) != 4 {
+		t.Fatalf("per-value identifiers = %v, want 4 entries", perValue)
+	}
+
+	setHashed := collect(New().WithSetHashing(ComponentMAC), macs, disks)
+	want := []string{"mac:" + hashSet(macs), "disk:WD-1", "disk:WD-2"}
+	if !slices.Equal(setHashed, want) {
+		t.Errorf("set-hashed identifiers = %v, want %v", setHashed, want)
+	}
+
+	reordered := collect(New().WithSetHashing(ComponentMAC), []string{macs[1], macs[0]}, disks)
+	if !slices.Equal(reordered, setHashed) {
+		t.Errorf("set hash depends on value order: %v != %v", reordered, setHashed)
+	}
+
+	grown := collect(New().WithSetHashing(ComponentMAC), append(slices.Clone(macs), "bb:bb:bb:bb:bb:bb"), disks)
+	if len(grown) != len(setHashed) || grown[0] == setHashed[0] {
+		t.Errorf("grown set identifiers = %v, want one changed MAC entry", grown)
+	}
+}
+
+// TestWithStrict tests that strict mode fails partial collections while
+// keeping the ID available through Generate, and leaves full success and
+// total failure unchanged.
+func TestWithStrict(t *testing.T) {
+	ctx := context.Background()
+	failingInterfaces := func() ([]net.Interface, error) {
+		return nil, errors.New("netlink unavailable")
+	}
+
+	t.Run("full success", func(t *testing.T) {
+		id, err := New().WithCPU().WithStrict().ID(ctx)
+		if err != nil || id == "" {
+			t.Errorf("ID() = %q, %v; want an ID", id, err)
+		}
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		p := New().WithCPU().WithMAC().WithStrict()
+		p.listInterfaces = failingInterfaces
+
+		id, err := p.ID(ctx)
+		if !errors.Is(err, ErrPartialCollection) || id != "" {
+			t.Fatalf("ID() = %q, %v; want ErrPartialCollection", id, err)
+		}
+		var compErr *ComponentError
+		if !errors.As(err, &compErr) || compErr.Component != ComponentMAC {
+			t.Errorf("ID() error = %v, want the MAC ComponentError wrapped", err)
+		}
+
+		result, err := p.Generate(ctx)
+		if !errors.Is(err, ErrPartialCollection) || result == nil || result.ID == "" {
+			t.Fatalf("Generate() = %+v, %v; want a result and ErrPartialCollection", result, err)
+		}
+		if !slices.Equal(result.Components, []string{ComponentCPU}) {
+			t.Errorf("Generate().Components = %v, want [%s]", result.Components, ComponentCPU)
+		}
+
+		lenient := New().WithCPU().WithMAC()
+		lenient.listInterfaces = failingInterfaces
+		if id, err := lenient.ID(ctx); err != nil || id != result.ID {
+			t.Errorf("ID() without strict = %q, %v; want %q", id, err, result.ID)
+		}
+	})
+
+	t.Run("total failure", func(t *testing.T) {
+		p := New().WithMAC().WithStrict()
+		p.listInterfaces = failingInterfaces
+
+		if _, err := p.ID(ctx); !errors.Is(err, ErrNoIdentifiers) {
+			t.Errorf("ID() error = %v, want ErrNoIdentifiers", err)
+		}
+	})
+}
+
+// TestWithMinimumComponents tests that ID fails with ErrInsufficientComponents
+// when fewer components than required were collected, tolerating failures
+// otherwise.
+func TestWithMinimumComponents(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		minimum    int
+		interfaces func() ([]net.Interface, error)
+		wantErr    error
+	}{
+		{"n collected", 2, physicalOnlyInterfaces, nil},
+		{"n-1 collected", 2, failingInterfaces, ErrInsufficientComponents},
+		{"failure tolerated", 1, failingInterfaces, nil},
+		{"disabled", 0, failingInterfaces, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New().WithCPU().WithMAC().WithMinimumComponents(tt.minimum)
+			p.listInterfaces = tt.interfaces
+
+			id, err := p.ID(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ID() error = %v, want %v", err, tt.wantErr)
+			}
+			if (id == "") != (tt.wantErr != nil) {
+				t.Errorf("ID() = %q with error %v", id, err)
+			}
+
+			result, err := p.Generate(ctx)
+			if !errors.Is(err, tt.wantErr) || result == nil || result.ID == "" {
+				t.Errorf("Generate() = %+v, %v; want a result and %v", result, err, tt.wantErr)
+			}
+		})
+	}
+
+	if p := New().WithMinimumComponents(3); p.Clone().minComponents != 3 {
+		t.Error("Clone() should copy the minimum component count")
+	}
+}
+
+// cloneResetFields are the Provider fields that Clone deliberately leaves at
+// their zero value: the lock, the ID cache and per-generation state.
+var cloneResetFields = map[string]bool{
+	"mu":                 true,
+	"diagnostics":        true,
+	"cachedID":           true,
+	"cachedHash":         true,
+	"cachedFingerprint":  true,
+	"cachedUUID":         true,
+	"cachedWindow":       true,
+	"componentValues":    true,
+	"collectDeadline":    true,
+	"prefetch":           true,
+	"prefetched":         true,
+	"componentDurations": true,
+	"lastDuration":       true,
+	"observer":           true,
+	"spans":              true,
+	"profiledComponent":  true,
+}
+
+// TestCloneCopiesEveryField tests that Clone carries over every configuration
+// field of Provider, so an option added later cannot be silently dropped.
+func TestCloneCopiesEveryField(t *testing.T) {
+	p := New()
+	v := reflect.ValueOf(p).Elem()
+	typ := v.Type()
+
+	for i := range typ.NumField() {
+		name := typ.Field(i).Name
+		if cloneResetFields[name] {
+			continue
+		}
+
+		field := reflect.NewAt(typ.Field(i).Type, unsafe.Pointer(v.Field(i).UnsafeAddr())).Elem()
+		value, ok := nonZeroValue(field.Type())
+		if !ok {
+			t.Fatalf("no test value for field %s of type %s; extend nonZeroValue", name, field.Type())
+		}
+		field.Set(value)
+	}
+
+	clone := reflect.ValueOf(p.Clone()).Elem()
+	for i := range typ.NumField() {
+		name := typ.Field(i).Name
+		if cloneResetFields[name] {
+			continue
+		}
+		if clone.Field(i).IsZero() {
+			t.Errorf("Clone() drops field %s; copy it in Clone or list it in cloneResetFields", name)
+		}
+	}
+}
+
+// nonZeroValue returns a non-zero value of type typ, if it knows how to build one.
+func nonZeroValue(typ reflect.Type) (reflect.Value, bool) {
+	switch typ {
+	case reflect.TypeFor[CommandExecutor]():
+		return reflect.ValueOf(ExecutorFunc(nil)).Convert(typ), true
+	case reflect.TypeFor[Clock]():
+		return reflect.ValueOf(Clock(realClock{})), true
+	case reflect.TypeFor[io.Writer]():
+		return reflect.ValueOf(io.Writer(io.Discard)), true
+	case reflect.TypeFor[fs.FS]():
+		return reflect.ValueOf(fs.FS(fstest.MapFS{})), true
+	case reflect.TypeFor[error]():
+		return reflect.ValueOf(errors.New("test")), true
+	}
+
+	value := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.Bool:
+		value.SetBool(true)
+	case reflect.Int, reflect.Int64:
+		value.SetInt(1)
+	case reflect.Float64:
+		value.SetFloat(1)
+	case reflect.String:
+		value.SetString("x")
+	case reflect.Slice:
+		value = reflect.MakeSlice(typ, 1, 1)
+	case reflect.Map:
+		value = reflect.MakeMap(typ)
+		value.SetMapIndex(reflect.New(typ.Key()).Elem(), reflect.New(typ.Elem()).Elem())
+	case reflect.Pointer:
+		value = reflect.New(typ.Elem())
+	case reflect.Func:
+		value = reflect.MakeFunc(typ, func([]reflect.Value) []reflect.Value { return nil })
+	default:
+		return reflect.Value{}, false
+	}
+
+	return value, true
+}
+
+// TestSnapshotKeepsDiagnostics tests that Snapshot leaves the diagnostics and
+// values of the cached ID in place.
+func TestSnapshotKeepsDiagnostics(t *testing.T) {
+	p := New().WithMAC().WithExecutor(newMockExecutor())
+	p.listInterfaces = physicalOnlyInterfaces
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	diag := p.Diagnostics()
+	values := slices.Clone(p.componentValues[ComponentMAC])
+
+	p.listInterfaces = func() ([]net.Interface, error) {
+		return []net.Interface{
+			{Index: 2, Name: "eth0", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x3c, 0x7c, 0x3f, 0x1a, 0x2b, 0xff}},
+		}, nil
+	}
+	snapshot, err := p.Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if slices.Equal(snapshot.Components[ComponentMAC], values) {
+		t.Fatalf("Snapshot() MAC = %v, want the new address", snapshot.Components[ComponentMAC])
+	}
+
+	if p.Diagnostics() != diag {
+		t.Error("Snapshot() replaced the diagnostics of the cached ID")
+	}
+	if got := p.componentValues[ComponentMAC]; !slices.Equal(got, values) {
+		t.Errorf("component values after Snapshot() = %v, want %v", got, values)
+	}
+}
@@ -1,10 +1,20 @@
 package machineid_test
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/slashdevops/machineid"
 )
@@ -125,6 +135,206 @@ func TestProviderWithSalt(t *testing.T) {
 	}
 }
 
+// TestProviderWithSaltBytes tests that a binary salt is hashed verbatim,
+// differing from its hex string interpretation, and that the last salt wins.
+func TestProviderWithSaltBytes(t *testing.T) {
+	newID := func(p *machineid.Provider) string {
+		t.Helper()
+
+		id, err := p.ID(context.Background())
+		if err != nil {
+			t.Fatalf("ID() error = %v", err)
+		}
+
+		return id
+	}
+	newProvider := func() *machineid.Provider { return machineid.New().WithCPU().WithSystemUUID() }
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x00, 0xff}
+
+	binary := newID(newProvider().WithSaltBytes(key))
+	if hexSalt := newID(newProvider().WithSalt(hex.EncodeToString(key))); binary == hexSalt {
+		t.Error("ID() with a binary salt should differ from its hex string")
+	}
+	if plain := newID(newProvider()); binary == plain {
+		t.Error("ID() with a binary salt should differ from no salt")
+	}
+	if text := newID(newProvider().WithSalt(string(key))); binary != text {
+		t.Error("ID() with a binary salt should equal the same bytes passed as a string")
+	}
+
+	if last := newID(newProvider().WithSalt("app").WithSaltBytes(key)); last != binary {
+		t.Error("WithSaltBytes() after WithSalt() should replace the salt")
+	}
+	if last, want := newID(newProvider().WithSaltBytes(key).WithSalt("app")), newID(newProvider().WithSalt("app")); last != want {
+		t.Error("WithSalt() after WithSaltBytes() should replace the salt")
+	}
+}
+
+// TestProviderWithPersonalization tests that a personalization string changes
+// the ID deterministically and is distinct from a salt with the same value.
+func TestProviderWithPersonalization(t *testing.T) {
+	newID := func(configure func(*machineid.Provider) *machineid.Provider) string {
+		t.Helper()
+
+		id, err := configure(machineid.New().WithCPU().WithSystemUUID()).ID(context.Background())
+		if err != nil {
+			t.Fatalf("ID() error = %v", err)
+		}
+
+		return id
+	}
+
+	plain := newID(func(p *machineid.Provider) *machineid.Provider { return p })
+	personalized := newID(func(p *machineid.Provider) *machineid.Provider {
+		return p.WithPersonalization("machineid-v1")
+	})
+	again := newID(func(p *machineid.Provider) *machineid.Provider {
+		return p.WithPersonalization("machineid-v1")
+	})
+	salted := newID(func(p *machineid.Provider) *machineid.Provider {
+		return p.WithSalt("machineid-v1")
+	})
+	other := newID(func(p *machineid.Provider) *machineid.Provider {
+		return p.WithPersonalization("machineid-v2")
+	})
+
+	if personalized != again {
+		t.Error("ID() with the same personalization should be deterministic")
+	}
+	if personalized == plain {
+		t.Error("ID() should change when a personalization is set")
+	}
+	if personalized == salted {
+		t.Error("ID() with a personalization should differ from a salt of the same value")
+	}
+	if personalized == other {
+		t.Error("ID() should differ for different personalizations")
+	}
+}
+
+// TestProviderWithConfigBinding tests that configurations producing identical
+// component values yield different IDs only when configuration binding is on.
+func TestProviderWithConfigBinding(t *testing.T) {
+	newID := func(p *machineid.Provider) string {
+		t.Helper()
+
+		id, err := p.ID(context.Background())
+		if err != nil {
+			t.Fatalf("ID() error = %v", err)
+		}
+
+		return id
+	}
+
+	// Canonical disk serials affect only the disk component, which is not
+	// enabled, so both configurations collect identical values.
+	plain := newID(machineid.New().WithCPU().WithSystemUUID())
+	canonical := newID(machineid.New().WithCPU().WithSystemUUID().WithCanonicalDiskSerials())
+	if plain != canonical {
+		t.Fatal("ID() should not depend on configuration without binding")
+	}
+
+	bound := newID(machineid.New().WithCPU().WithSystemUUID().WithConfigBinding())
+	boundCanonical := newID(machineid.New().WithCPU().WithSystemUUID().WithCanonicalDiskSerials().WithConfigBinding())
+	if bound == boundCanonical {
+		t.Error("ID() with config binding should differ for different configurations")
+	}
+	if bound == plain {
+		t.Error("ID() should change when config binding is enabled")
+	}
+	if again := newID(machineid.New().WithCPU().WithSystemUUID().WithConfigBinding()); again != bound {
+		t.Error("ID() with config binding should be deterministic")
+	}
+}
+
+// TestProviderWithHMAC tests that two HMAC keys produce different IDs for
+// identical hardware, and the same key the same ID.
+func TestProviderWithHMAC(t *testing.T) {
+	newID := func(key []byte) string {
+		t.Helper()
+
+		id, err := machineid.New().WithCPU().WithSystemUUID().WithHMAC(key).ID(context.Background())
+		if err != nil {
+			t.Fatalf("ID() error = %v", err)
+		}
+
+		return id
+	}
+
+	keyA, keyB := newID([]byte("key-a")), newID([]byte("key-b"))
+	if keyA == keyB {
+		t.Error("ID() should differ for different HMAC keys")
+	}
+	if again := newID([]byte("key-a")); again != keyA {
+		t.Error("ID() with the same HMAC key should be deterministic")
+	}
+	if plain := newID(nil); plain == keyA {
+		t.Error("ID() with an HMAC key should differ from plain hashing")
+	}
+}
+
+// TestProviderWithHasher tests that a custom hasher changes the ID, keeps the
+// format lengths, and combines with HMAC.
+func TestProviderWithHasher(t *testing.T) {
+	ctx := context.Background()
+	newProvider := func() *machineid.Provider {
+		return machineid.New().WithCPU().WithSystemUUID()
+	}
+
+	sha256ID, err := newProvider().ID(ctx)
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	if id, _ := newProvider().WithHasher(sha256.New).ID(ctx); id != sha256ID {
+		t.Error("WithHasher(sha256.New) should match the default")
+	}
+
+	lengths := map[machineid.FormatMode]int{
+		machineid.Format32:  32,
+		machineid.Format64:  64,
+		machineid.Format128: 128,
+		machineid.Format256: 256,
+	}
+	for mode, want := range lengths {
+		id, err := newProvider().WithHasher(sha512.New).WithFormat(mode).ID(ctx)
+		if err != nil {
+			t.Fatalf("ID() error = %v", err)
+		}
+		if len(id) != want {
+			t.Errorf("ID() with SHA-512 length for mode %d = %d, want %d", mode, len(id), want)
+		}
+		if mode == machineid.Format64 && id == sha256ID {
+			t.Error("ID() with SHA-512 should differ from SHA-256")
+		}
+	}
+
+	plain, _ := newProvider().WithHasher(sha512.New).ID(ctx)
+	if keyed, _ := newProvider().WithHasher(sha512.New).WithHMAC([]byte("key")).ID(ctx); keyed == plain {
+		t.Error("ID() with HMAC-SHA-512 should differ from plain SHA-512")
+	}
+}
+
+// TestProviderComponents tests that Components lists the enabled components
+// in a fixed order, before and after generating an ID.
+func TestProviderComponents(t *testing.T) {
+	if got := machineid.New().Components(); len(got) != 0 {
+		t.Errorf("Components() = %v, want none", got)
+	}
+
+	g := machineid.New().WithDisk().WithSystemUUID().WithCPU()
+	want := []string{machineid.ComponentCPU, machineid.ComponentSystemUUID, machineid.ComponentDisk}
+	if got := g.Components(); !slices.Equal(got, want) {
+		t.Errorf("Components() = %v, want %v", got, want)
+	}
+
+	if _, err := g.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	if got := g.Components(); !slices.Equal(got, want) {
+		t.Errorf("Components() after ID() = %v, want %v", got, want)
+	}
+}
+
 func TestProviderValidate(t *testing.T) {
 	g := machineid.New().WithCPU().WithSystemUUID()
 
@@ -153,6 +363,71 @@ func TestProviderValidate(t *testing.T) {
 	}
 }
 
+// TestProviderValidateComparison tests that the constant-time comparison of
+// Validate matches plain string equality for equal and unequal IDs of equal
+// and differing lengths.
+func TestProviderValidateComparison(t *testing.T) {
+	g := machineid.New().WithCPU().WithSystemUUID()
+
+	id, err := g.ID(context.Background())
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	flipped := []byte(id)
+	flipped[len(flipped)-1] ^= 1
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"equal", id},
+		{"same length, first differs", "x" + id[1:]},
+		{"same length, last differs", string(flipped)},
+		{"prefix", id[:len(id)-1]},
+		{"extended", id + "0"},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, err := g.Validate(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if want := tt.input == id; valid != want {
+				t.Errorf("Validate() = %v, want %v", valid, want)
+			}
+		})
+	}
+}
+
+// TestConstantTimeEqual tests that externally supplied IDs are compared
+// exactly, like string equality.
+func TestConstantTimeEqual(t *testing.T) {
+	const issued = "b5c42832542981af58c9dc3bc241219e780ff7d276cfad05fac222846edb84f7"
+
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"equal", issued, issued, true},
+		{"last differs", issued, issued[:63] + "8", false},
+		{"uppercase", issued, strings.ToUpper(issued), false},
+		{"prefix", issued, issued[:32], false},
+		{"empty", "", "", true},
+		{"one empty", issued, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := machineid.ConstantTimeEqual(tt.a, tt.b); got != tt.want {
+				t.Errorf("ConstantTimeEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestVMFriendly(t *testing.T) {
 	g := machineid.New().VMFriendly().WithSalt("vm-test")
 
@@ -258,6 +533,50 @@ func TestProviderConcurrency(t *testing.T) {
 	}
 }
 
+// TestProviderClone tests that clones of a base provider branched with
+// different salts generate independent IDs concurrently, and that cloning a
+// provider that already generated an ID does not carry its cache over.
+func TestProviderClone(t *testing.T) {
+	ctx := context.Background()
+	base := machineid.New().WithCPU().WithSystemUUID()
+	baseID, err := base.ID(ctx)
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+
+	salts := []string{"app-a", "app-b", "app-c"}
+	ids := make([]string, len(salts))
+	errs := make([]error, len(salts))
+	var wg sync.WaitGroup
+	for i, salt := range salts {
+		clone := base.Clone().WithSalt(salt)
+		if clone.Diagnostics() != nil {
+			t.Errorf("clone Diagnostics() = %v, want nil", clone.Diagnostics())
+		}
+		wg.Go(func() {
+			ids[i], errs[i] = clone.ID(ctx)
+		})
+	}
+	wg.Wait()
+
+	for i, salt := range salts {
+		if errs[i] != nil {
+			t.Fatalf("clone %q ID() error = %v", salt, errs[i])
+		}
+		want, _ := machineid.New().WithCPU().WithSystemUUID().WithSalt(salt).ID(ctx)
+		if ids[i] != want {
+			t.Errorf("clone %q ID() = %s, want %s", salt, ids[i], want)
+		}
+		if ids[i] == baseID || slices.Index(ids, ids[i]) != i {
+			t.Errorf("clone %q ID() = %s, want an ID distinct from the others", salt, ids[i])
+		}
+	}
+
+	if id, _ := base.ID(ctx); id != baseID {
+		t.Errorf("base ID() = %s after cloning, want %s", id, baseID)
+	}
+}
+
 // TestValidateWithDifferentConfiguration tests validation behavior.
 func TestValidateWithDifferentConfiguration(t *testing.T) {
 	g1 := machineid.New().WithCPU().WithSystemUUID()
@@ -385,6 +704,31 @@ func TestFormat256(t *testing.T) {
 	}
 }
 
+// TestFormatInvalid tests that an undefined FormatMode makes ID() fail with
+// ErrInvalidFormat instead of returning an ID of an unexpected length, and that
+// setting a valid mode afterwards clears the error.
+func TestFormatInvalid(t *testing.T) {
+	g := machineid.New().
+		WithCPU().
+		WithSystemUUID().
+		WithFormat(machineid.FormatMode(7))
+
+	id, err := g.ID(context.Background())
+	if !errors.Is(err, machineid.ErrInvalidFormat) {
+		t.Fatalf("ID() error = %v, want ErrInvalidFormat", err)
+	}
+	if id != "" {
+		t.Errorf("ID() = %q, want empty ID", id)
+	}
+	if !strings.Contains(err.Error(), "FormatMode(7)") {
+		t.Errorf("error %q should name the invalid mode", err)
+	}
+
+	if _, err := g.WithFormat(machineid.Format32).ID(context.Background()); err != nil {
+		t.Errorf("ID() after valid WithFormat error = %v", err)
+	}
+}
+
 // TestFormatDifference tests that different formats produce different outputs.
 func TestFormatDifference(t *testing.T) {
 	// Create providers with same config but different formats
@@ -798,3 +1142,341 @@ func TestFormatPowerOfTwo(t *testing.T) {
 		})
 	}
 }
+
+// TestWithUppercase tests that WithUppercase emits uppercase hex that validates
+// against an uppercase stored ID.
+func TestWithUppercase(t *testing.T) {
+	lower, err := machineid.New().WithCPU().WithSystemUUID().ID(context.Background())
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+
+	g := machineid.New().WithCPU().WithSystemUUID().WithUppercase()
+	upper, err := g.ID(context.Background())
+	if err != nil {
+		t.Fatalf("WithUppercase().ID() error = %v", err)
+	}
+
+	if upper != strings.ToUpper(lower) {
+		t.Errorf("WithUppercase().ID() = %q, want %q", upper, strings.ToUpper(lower))
+	}
+
+	for _, c := range upper {
+		if (c < '0' || c > '9') && (c < 'A' || c > 'F') {
+			t.Fatalf("WithUppercase().ID() contains non-uppercase-hex character: %c", c)
+		}
+	}
+
+	storedID := strings.ToUpper(lower)
+	valid, err := g.Validate(context.Background(), storedID)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !valid {
+		t.Error("Validate() should return true for uppercase stored ID")
+	}
+}
+
+// TestIDBytes tests that IDBytes returns the raw digest of the hex ID for every format.
+func TestIDBytes(t *testing.T) {
+	tests := []struct {
+		mode    machineid.FormatMode
+		wantLen int
+	}{
+		{machineid.Format32, 16},
+		{machineid.Format64, 32},
+		{machineid.Format128, 64},
+		{machineid.Format256, 128},
+	}
+
+	for _, tt := range tests {
+		g := machineid.New().WithCPU().WithSystemUUID().WithFormat(tt.mode)
+
+		b, err := g.IDBytes(context.Background())
+		if err != nil {
+			t.Fatalf("IDBytes() error = %v", err)
+		}
+		if len(b) != tt.wantLen {
+			t.Errorf("IDBytes() for mode %d returned %d bytes, expected %d", tt.mode, len(b), tt.wantLen)
+		}
+
+		id, err := g.ID(context.Background())
+		if err != nil {
+			t.Fatalf("ID() error = %v", err)
+		}
+		if hex.EncodeToString(b) != id {
+			t.Errorf("hex.EncodeToString(IDBytes()) = %q, want ID %q", hex.EncodeToString(b), id)
+		}
+	}
+
+	upper, err := machineid.New().WithCPU().WithSystemUUID().WithUppercase().IDBytes(context.Background())
+	if err != nil {
+		t.Fatalf("WithUppercase().IDBytes() error = %v", err)
+	}
+	lower, err := machineid.New().WithCPU().WithSystemUUID().IDBytes(context.Background())
+	if err != nil {
+		t.Fatalf("IDBytes() error = %v", err)
+	}
+	if !bytes.Equal(upper, lower) {
+		t.Error("IDBytes() should not depend on WithUppercase")
+	}
+}
+
+// TestIDPair tests that the short ID is the prefix of the long ID and that
+// both match the corresponding formats regardless of the configured one.
+func TestIDPair(t *testing.T) {
+	g := machineid.New().WithCPU().WithSystemUUID().WithFormat(machineid.Format128)
+
+	short, long, err := g.IDPair(context.Background())
+	if err != nil {
+		t.Fatalf("IDPair() error = %v", err)
+	}
+	if len(short) != 32 || len(long) != 64 {
+		t.Fatalf("IDPair() lengths = %d, %d, expected 32, 64", len(short), len(long))
+	}
+	if long[:len(short)] != short {
+		t.Errorf("IDPair() short %q is not a prefix of long %q", short, long)
+	}
+
+	id64, err := machineid.New().WithCPU().WithSystemUUID().ID(context.Background())
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	if long != id64 {
+		t.Errorf("IDPair() long = %q, want Format64 ID %q", long, id64)
+	}
+
+	upperShort, upperLong, err := machineid.New().WithCPU().WithSystemUUID().WithUppercase().IDPair(context.Background())
+	if err != nil {
+		t.Fatalf("WithUppercase().IDPair() error = %v", err)
+	}
+	if upperShort != strings.ToUpper(short) || upperLong != strings.ToUpper(long) {
+		t.Error("IDPair() should follow WithUppercase")
+	}
+}
+
+// TestFingerprint tests that the fingerprint is stable, redacted, and lists
+// one entry per hashed identifier after the salt.
+func TestFingerprint(t *testing.T) {
+	g := machineid.New().WithCPU().WithSystemUUID().WithSalt("app")
+
+	fingerprint, err := g.Fingerprint(context.Background())
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	again, err := g.Fingerprint(context.Background())
+	if err != nil {
+		t.Fatalf("Fingerprint() second call error = %v", err)
+	}
+	if fingerprint != again {
+		t.Errorf("Fingerprint() = %q, then %q; want stable", fingerprint, again)
+	}
+
+	parts := strings.Split(fingerprint, "|")
+	if !strings.HasPrefix(parts[0], "salt:") {
+		t.Errorf("Fingerprint() = %q, want the salt first", fingerprint)
+	}
+	if len(parts) < 2 {
+		t.Fatalf("Fingerprint() = %q, want at least one identifier", fingerprint)
+	}
+	redacted := regexp.MustCompile(`^[a-z-]+:[0-9a-f]{6}…$`)
+	for _, part := range parts {
+		if !redacted.MatchString(part) {
+			t.Errorf("Fingerprint() part %q is not redacted", part)
+		}
+	}
+	if strings.Contains(fingerprint, "app") {
+		t.Errorf("Fingerprint() = %q exposes the salt", fingerprint)
+	}
+
+	if _, err := machineid.New().Fingerprint(context.Background()); !errors.Is(err, machineid.ErrNoIdentifiers) {
+		t.Errorf("Fingerprint() with no components error = %v, want ErrNoIdentifiers", err)
+	}
+}
+
+// TestDeriveID tests that derived IDs are deterministic, distinct per label,
+// and of the requested length.
+func TestDeriveID(t *testing.T) {
+	ctx := context.Background()
+	g := machineid.New().WithCPU().WithSystemUUID()
+
+	first, err := g.DeriveID(ctx, "feature-a", 32)
+	if err != nil {
+		t.Fatalf("DeriveID() error = %v", err)
+	}
+	again, err := machineid.New().WithCPU().WithSystemUUID().DeriveID(ctx, "feature-a", 32)
+	if err != nil {
+		t.Fatalf("DeriveID() error = %v", err)
+	}
+	if first != again {
+		t.Errorf("DeriveID() not deterministic: %q != %q", first, again)
+	}
+
+	other, err := g.DeriveID(ctx, "feature-b", 32)
+	if err != nil {
+		t.Fatalf("DeriveID() error = %v", err)
+	}
+	if other == first {
+		t.Error("DeriveID() returned the same ID for different labels")
+	}
+
+	id, _ := g.ID(ctx)
+	if first == id[:32] {
+		t.Error("DeriveID() should not return a prefix of the base ID")
+	}
+
+	for _, length := range []int{1, 7, 64, 200} {
+		derived, err := g.DeriveID(ctx, "feature-a", length)
+		if err != nil {
+			t.Fatalf("DeriveID(%d) error = %v", length, err)
+		}
+		if len(derived) != length {
+			t.Errorf("DeriveID(%d) returned %d characters", length, len(derived))
+		}
+		if prefix := min(length, len(first)); derived[:prefix] != first[:prefix] {
+			t.Errorf("DeriveID(%d) = %q, want it to share a prefix with %q", length, derived, first)
+		}
+	}
+
+	upper, err := machineid.New().WithCPU().WithSystemUUID().WithUppercase().DeriveID(ctx, "feature-a", 32)
+	if err != nil {
+		t.Fatalf("WithUppercase().DeriveID() error = %v", err)
+	}
+	if upper != strings.ToUpper(first) {
+		t.Errorf("WithUppercase().DeriveID() = %q, want %q", upper, strings.ToUpper(first))
+	}
+
+	if _, err := g.DeriveID(ctx, "feature-a", 0); err == nil {
+		t.Error("DeriveID() with length 0 expected error")
+	}
+}
+
+// TestWithTimeWindow tests that IDs are stable within a time window and rotate across windows.
+func TestWithTimeWindow(t *testing.T) {
+	now := time.Date(2025, 3, 14, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	newProvider := func() *machineid.Provider {
+		return machineid.New().WithCPU().WithSystemUUID().WithSalt("token").WithTimeWindow(24*time.Hour, clock)
+	}
+
+	first, err := newProvider().ID(context.Background())
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+
+	now = now.Add(10 * time.Hour)
+	sameWindow, err := newProvider().ID(context.Background())
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	if sameWindow != first {
+		t.Error("IDs within the same window should match")
+	}
+
+	g := newProvider()
+	if _, err := g.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+
+	now = now.Add(24 * time.Hour)
+	nextWindow, err := g.ID(context.Background())
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	if nextWindow == first {
+		t.Error("IDs in different windows should differ, even from a provider with a cached ID")
+	}
+
+	plain, err := machineid.New().WithCPU().WithSystemUUID().WithSalt("token").ID(context.Background())
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	if plain == first {
+		t.Error("time-windowed ID should differ from the plain ID")
+	}
+}
+
+// TestWithStrictValidationInput tests rejection of malformed Validate input.
+func TestWithStrictValidationInput(t *testing.T) {
+	g := machineid.New().WithCPU().WithSystemUUID().WithFormat(machineid.Format32).WithStrictValidationInput()
+
+	id, err := g.ID(context.Background())
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		input     string
+		wantValid bool
+		wantErr   error
+	}{
+		{"correct", id, true, nil},
+		{"well-formed other machine", strings.Repeat("0", 32), false, nil},
+		{"too short", id[:31], false, machineid.ErrMalformedID},
+		{"too long", id + "0", false, machineid.ErrMalformedID},
+		{"non-hex", strings.Repeat("z", 32), false, machineid.ErrMalformedID},
+		{"wrong case", strings.Repeat("A", 32), false, machineid.ErrMalformedID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, err := g.Validate(context.Background(), tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+			if valid != tt.wantValid {
+				t.Errorf("Validate() = %v, want %v", valid, tt.wantValid)
+			}
+		})
+	}
+
+	lenient, err := machineid.New().WithCPU().WithSystemUUID().Validate(context.Background(), "garbage")
+	if err != nil || lenient {
+		t.Errorf("lenient Validate() = %v, %v; want false, nil", lenient, err)
+	}
+}
+
+// TestDiagnosticsPlatform tests that platform, architecture, and the optional
+// OS version probe are reported in diagnostics.
+func TestDiagnosticsPlatform(t *testing.T) {
+	g := machineid.New().WithCPU().WithSystemUUID()
+	if _, err := g.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+
+	diag := g.Diagnostics()
+	if diag.Platform != runtime.GOOS {
+		t.Errorf("Diagnostics().Platform = %q, want %q", diag.Platform, runtime.GOOS)
+	}
+	if diag.Arch != runtime.GOARCH {
+		t.Errorf("Diagnostics().Arch = %q, want %q", diag.Arch, runtime.GOARCH)
+	}
+	if diag.OSVersion != "" {
+		t.Errorf("Diagnostics().OSVersion = %q, want empty without probe", diag.OSVersion)
+	}
+
+	probed := machineid.New().WithCPU().WithSystemUUID().WithOSVersionProbe()
+	if _, err := probed.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	t.Logf("OS version: %q", probed.Diagnostics().OSVersion)
+}
+
+// BenchmarkID measures uncached ID generation with all components enabled,
+// with and without fast mode.
+func BenchmarkID(b *testing.B) {
+	ctx := context.Background()
+
+	b.Run("Default", func(b *testing.B) {
+		for b.Loop() {
+			_, _ = machineid.New().WithCPU().WithMotherboard().WithSystemUUID().WithMAC().WithDisk().ID(ctx)
+		}
+	})
+
+	b.Run("FastMode", func(b *testing.B) {
+		for b.Loop() {
+			_, _ = machineid.New().WithCPU().WithMotherboard().WithSystemUUID().WithMAC().WithDisk().WithFastMode().ID(ctx)
+		}
+	})
+}
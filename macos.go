@@ -0,0 +1,795 @@
+package machineid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Compiled regexes for ioreg output parsing.
+var (
+	ioregUUIDRe   = regexp.MustCompile(`"IOPlatformUUID"\s*=\s*"([^"]+)"`)
+	ioregSerialRe = regexp.MustCompile(`"IOPlatformSerialNumber"\s*=\s*"([^"]+)"`)
+	ioregBoardRe  = regexp.MustCompile(`"board-id"\s*=\s*<"([^"]+)">`)
+)
+
+// spThunderboltDataType represents the JSON output of `system_profiler SPThunderboltDataType -json`.
+type spThunderboltDataType struct {
+	SPThunderboltDataType []spThunderboltBus `json:"SPThunderboltDataType"`
+}
+
+// spThunderboltBus describes one Thunderbolt bus and its host controller.
+type spThunderboltBus struct {
+	Name        string `json:"_name"`
+	DomainUUID  string `json:"domain_uuid_key"`
+	RouteString string `json:"route_string_key"`
+}
+
+// spDisplaysDataType represents the JSON output of `system_profiler SPDisplaysDataType -json`.
+type spDisplaysDataType struct {
+	SPDisplaysDataType []spDisplaysEntry `json:"SPDisplaysDataType"`
+}
+
+// spDisplaysEntry describes one graphics processor. Apple Silicon GPUs report
+// no device ID.
+type spDisplaysEntry struct {
+	Name     string `json:"_name"`
+	Model    string `json:"sppci_model"`
+	DeviceID string `json:"spdisplays_device-id"`
+}
+
+// spMemoryDataType represents the JSON output of `system_profiler SPMemoryDataType -json`.
+type spMemoryDataType struct {
+	SPMemoryDataType []spMemoryEntry `json:"SPMemoryDataType"`
+}
+
+// spMemoryEntry describes the memory of a Mac: one item per DIMM slot on Intel
+// Macs, or only the total size on Apple Silicon, whose memory is in the package.
+type spMemoryEntry struct {
+	Size  string           `json:"SPMemoryDataType"`
+	Items []spMemoryModule `json:"_items"`
+}
+
+// spMemoryModule describes one DIMM slot.
+type spMemoryModule struct {
+	Name         string `json:"_name"`
+	SerialNumber string `json:"dimm_serial_number"`
+	Size         string `json:"dimm_size"`
+}
+
+// spHardwareDataType represents the JSON output of `system_profiler SPHardwareDataType -json`.
+type spHardwareDataType struct {
+	SPHardwareDataType []spHardwareEntry `json:"SPHardwareDataType"`
+}
+
+type spHardwareEntry struct {
+	PlatformUUID string `json:"platform_UUID"`
+	SerialNumber string `json:"serial_number"`
+	ChipType     string `json:"chip_type"`
+	ModelName    string `json:"machine_name"`
+	MachineModel string `json:"machine_model"`
+}
+
+// spStorageDataType represents the JSON output of `system_profiler SPStorageDataType -json`.
+type spStorageDataType struct {
+	SPStorageDataType []spStorageEntry `json:"SPStorageDataType"`
+}
+
+type spStorageEntry struct {
+	Name          string          `json:"_name"`
+	BSDName       string          `json:"bsd_name"`
+	PhysicalDrive spPhysicalDrive `json:"physical_drive"`
+	VolumeUUID    string          `json:"volume_uuid"`
+}
+
+type spPhysicalDrive struct {
+	DeviceName  string `json:"device_name"`
+	IsInternal  string `json:"is_internal_disk"`
+	MediaName   string `json:"media_name"`
+	MediumType  string `json:"medium_type"`
+	Protocol    string `json:"protocol"`
+	SmartStatus string `json:"smart_status"`
+}
+
+// spNVMeDataType represents the JSON output of `system_profiler SPNVMeDataType -json`.
+type spNVMeDataType struct {
+	SPNVMeDataType []spNVMeController `json:"SPNVMeDataType"`
+}
+
+// spNVMeController describes one NVMe controller and its drives.
+type spNVMeController struct {
+	Name  string        `json:"_name"`
+	Items []spNVMeDrive `json:"_items"`
+}
+
+// spNVMeDrive describes one NVMe drive.
+type spNVMeDrive struct {
+	Name         string `json:"_name"`
+	BSDName      string `json:"bsd_name"`
+	DeviceModel  string `json:"device_model"`
+	DeviceSerial string `json:"device_serial"`
+}
+
+// collectDarwinIdentifiers gathers macOS hardware identifiers based on provider
+// config. It only runs commands through the provider's [CommandExecutor], so
+// tests can exercise it on any host; see [collectIdentifiersFor].
+func collectDarwinIdentifiers(ctx context.Context, p *Provider, diag *DiagnosticInfo) ([]string, error) {
+	var identifiers []string
+	logger := p.logger
+
+	if p.includeSystemUUID && (p.bestUUID || p.fastMode) {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			value, err := macOSBestUUID(ctx, p.commandExecutor, logger)
+			if err == nil && p.bestUUID {
+				setUUIDSource(diag, "IOPlatformUUID")
+			}
+
+			return value, err
+		}, "uuid:", diag, ComponentSystemUUID)
+	} else if p.includeSystemUUID {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			return macOSHardwareUUID(ctx, p.commandExecutor, logger)
+		}, "uuid:", diag, ComponentSystemUUID)
+	}
+
+	if p.includeMotherboard {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			return macOSSerialNumber(ctx, p.commandExecutor, logger)
+		}, "mb:", diag, ComponentMotherboard)
+	}
+
+	if p.includeCPU {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			return macOSCPUInfoFrom(ctx, p.commandExecutor, logger, p.macCPUSource, p.cleanCPUFormat)
+		}, "cpu:", diag, ComponentCPU)
+	}
+
+	if p.includeMAC {
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(ctx context.Context) ([]string, error) {
+			isVirtual, isWireless := p.darwinMACClassifiers(ctx, logger)
+
+			return p.macAddresses(isVirtual, isWireless, logger)
+		}, "mac:", diag, ComponentMAC)
+	}
+
+	if p.includeDisk {
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(ctx context.Context) ([]string, error) {
+			return macOSDiskInfo(ctx, p.commandExecutor, p.diskFilter, logger)
+		}, "disk:", diag, ComponentDisk)
+	}
+
+	if p.includeThunderbolt {
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(ctx context.Context) ([]string, error) {
+			return macOSThunderboltUUIDs(ctx, p.commandExecutor, logger)
+		}, "thunderbolt:", diag, ComponentThunderbolt)
+	}
+
+	if p.includeModel {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			return macOSModel(ctx, p.commandExecutor, logger)
+		}, "model:", diag, ComponentModel)
+	}
+
+	if p.includeGPU {
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(ctx context.Context) ([]string, error) {
+			return macOSGPUModels(ctx, p.commandExecutor, logger)
+		}, "gpu:", diag, ComponentGPU)
+	}
+
+	if p.includeMemory {
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(ctx context.Context) ([]string, error) {
+			return macOSMemory(ctx, p.commandExecutor, logger)
+		}, "memory:", diag, ComponentMemory)
+	}
+
+	return identifiers, nil
+}
+
+// macOSVersion returns the macOS product and build version from sw_vers.
+func macOSVersion(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "sw_vers")
+	if err != nil {
+		return "", err
+	}
+
+	return parseSwVers(output)
+}
+
+// parseSwVers formats `sw_vers` output as "ProductVersion (BuildVersion)".
+func parseSwVers(output string) (string, error) {
+	var productVersion, buildVersion string
+	for line := range strings.SplitSeq(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		switch strings.TrimSpace(key) {
+		case "ProductVersion":
+			productVersion = strings.TrimSpace(value)
+		case "BuildVersion":
+			buildVersion = strings.TrimSpace(value)
+		}
+	}
+
+	if productVersion == "" {
+		return "", &ParseError{Source: "sw_vers output", Err: ErrNotFound}
+	}
+	if buildVersion == "" {
+		return productVersion, nil
+	}
+
+	return fmt.Sprintf("%s (%s)", productVersion, buildVersion), nil
+}
+
+// macOSHardwareUUID retrieves hardware UUID using system_profiler with JSON parsing.
+func macOSHardwareUUID(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "system_profiler", "SPHardwareDataType", "-json")
+	if err == nil {
+		uuid, parseErr := extractHardwareField(output, func(e spHardwareEntry) string {
+			return e.PlatformUUID
+		})
+		if parseErr == nil {
+			return uuid, nil
+		}
+
+		if logger != nil {
+			logger.Debug("system_profiler UUID parsing failed", "error", parseErr)
+		}
+	}
+
+	// Fallback to ioreg
+	if logger != nil {
+		logger.Info("falling back to ioreg for hardware UUID")
+	}
+
+	return macOSHardwareUUIDViaIOReg(ctx, executor, logger)
+}
+
+// macOSHardwareUUIDViaIOReg retrieves hardware UUID using ioreg as fallback.
+func macOSHardwareUUIDViaIOReg(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "ioreg", "-d2", "-c", "IOPlatformExpertDevice")
+	if err != nil {
+		return "", err
+	}
+
+	match := ioregUUIDRe.FindStringSubmatch(output)
+	if len(match) > 1 {
+		return match[1], nil
+	}
+
+	if logger != nil {
+		logger.Debug("hardware UUID not found in ioreg output")
+	}
+
+	return "", &ParseError{Source: "ioreg output", Err: ErrNotFound}
+}
+
+// macOSBestUUID retrieves IOPlatformUUID directly from ioreg, which is reliable
+// on every Mac, falling back to system_profiler's platform_UUID (the same value).
+func macOSBestUUID(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	uuid, err := macOSHardwareUUIDViaIOReg(ctx, executor, logger)
+	if err == nil {
+		return uuid, nil
+	}
+
+	if logger != nil {
+		logger.Info("falling back to system_profiler for IOPlatformUUID", "error", err)
+	}
+
+	output, err := executeCommand(ctx, executor, logger, "system_profiler", "SPHardwareDataType", "-json")
+	if err != nil {
+		return "", err
+	}
+
+	return extractHardwareField(output, func(e spHardwareEntry) string {
+		return e.PlatformUUID
+	})
+}
+
+// macOSSerialNumber retrieves system serial number.
+func macOSSerialNumber(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "system_profiler", "SPHardwareDataType", "-json")
+	if err == nil {
+		serial, parseErr := extractHardwareField(output, func(e spHardwareEntry) string {
+			return e.SerialNumber
+		})
+		if parseErr == nil {
+			return serial, nil
+		}
+
+		if logger != nil {
+			logger.Debug("system_profiler serial parsing failed", "error", parseErr)
+		}
+	}
+
+	// Fallback to ioreg
+	if logger != nil {
+		logger.Info("falling back to ioreg for serial number")
+	}
+
+	return macOSSerialNumberViaIOReg(ctx, executor, logger)
+}
+
+// macOSSerialNumberViaIOReg retrieves serial number using ioreg as fallback.
+func macOSSerialNumberViaIOReg(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "ioreg", "-d2", "-c", "IOPlatformExpertDevice")
+	if err != nil {
+		return "", err
+	}
+
+	match := ioregSerialRe.FindStringSubmatch(output)
+	if len(match) > 1 {
+		if isOEMPlaceholder(match[1]) {
+			return "", &ParseError{Source: "ioreg output", Err: ErrOEMPlaceholder}
+		}
+
+		return match[1], nil
+	}
+
+	if logger != nil {
+		logger.Debug("serial number not found in ioreg output")
+	}
+
+	return "", &ParseError{Source: "ioreg output", Err: ErrNotFound}
+}
+
+// macOSModel returns the hardware model identifier, followed by the board-id
+// from ioreg when the Mac has one ("MacBookPro16,1:Mac-E1008331FDC96864").
+// Apple Silicon Macs report no board-id string, so their value is the model alone.
+func macOSModel(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "system_profiler", "SPHardwareDataType", "-json")
+	if err != nil {
+		return "", err
+	}
+
+	model, err := extractHardwareField(output, func(e spHardwareEntry) string {
+		return e.MachineModel
+	})
+	if err != nil {
+		return "", err
+	}
+
+	boardID, err := macOSBoardID(ctx, executor, logger)
+	if err != nil {
+		if logger != nil {
+			logger.Debug("board-id unavailable, using model alone", "error", err)
+		}
+
+		return model, nil
+	}
+
+	return model + ":" + boardID, nil
+}
+
+// macOSBoardID retrieves the logic board's board-id from ioreg.
+func macOSBoardID(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "ioreg", "-d2", "-c", "IOPlatformExpertDevice")
+	if err != nil {
+		return "", err
+	}
+
+	match := ioregBoardRe.FindStringSubmatch(output)
+	if len(match) < 2 {
+		return "", &ParseError{Source: "ioreg output", Err: ErrNotFound}
+	}
+
+	return match[1], nil
+}
+
+// darwinMACClassifiers returns the virtual and wireless interface classifiers
+// of the macOS collector. networksetup is only run for [MACFilterWired].
+func (p *Provider) darwinMACClassifiers(ctx context.Context, logger *slog.Logger) (isVirtual, isWireless func(net.Interface) bool) {
+	if p.macFilter == MACFilterWired {
+		return isVirtualNetInterface, macOSWirelessClassifier(ctx, p.commandExecutor, logger)
+	}
+
+	return isVirtualNetInterface, isWirelessNetInterface
+}
+
+// macOSWirelessClassifier returns an interface classifier backed by
+// `networksetup -listallhardwareports`, which names the hardware port, such
+// as "Wi-Fi", of each device: macOS names wired and wireless interfaces alike
+// en0, en1 and so on. Devices it does not list, or every interface when
+// networksetup fails, fall back to the name-based heuristic.
+func macOSWirelessClassifier(ctx context.Context, executor CommandExecutor, logger *slog.Logger) func(net.Interface) bool {
+	output, err := executeCommand(ctx, executor, logger, "networksetup", "-listallhardwareports")
+	if err != nil {
+		if logger != nil {
+			logger.Info("networksetup unavailable, classifying wireless interfaces by name", "error", err)
+		}
+
+		return isWirelessNetInterface
+	}
+
+	wireless := parseHardwarePorts(output)
+
+	return func(i net.Interface) bool {
+		if isWireless, ok := wireless[i.Name]; ok {
+			return isWireless
+		}
+
+		return isWirelessNetInterface(i)
+	}
+}
+
+// parseHardwarePorts parses `networksetup -listallhardwareports` output into
+// a map from device name to whether its hardware port is Wi-Fi (or AirPort on
+// older releases).
+func parseHardwarePorts(output string) map[string]bool {
+	wireless := make(map[string]bool)
+
+	var port string
+	for line := range strings.Lines(output) {
+		line = strings.TrimSpace(line)
+		if value, ok := strings.CutPrefix(line, "Hardware Port:"); ok {
+			port = strings.TrimSpace(value)
+		} else if value, ok := strings.CutPrefix(line, "Device:"); ok && port != "" {
+			wireless[strings.TrimSpace(value)] = port == "Wi-Fi" || port == "AirPort"
+			port = ""
+		}
+	}
+
+	return wireless
+}
+
+// macOSCPUInfo retrieves CPU information.
+// Uses sysctl as primary source (consistent with existing machine IDs).
+// On Intel: returns brand_string:features.
+// On Apple Silicon: sysctl returns brand_string with empty features,
+// producing "ChipType:" — this trailing colon is preserved for backward
+// compatibility with existing license activations.
+// Falls back to system_profiler chip_type only if sysctl fails entirely.
+func macOSCPUInfo(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	return macOSCPUInfoFormatted(ctx, executor, logger, false)
+}
+
+// macOSCPUInfoFormatted retrieves CPU information like [macOSCPUInfo]. When
+// clean is true, empty features (Apple Silicon) yield the bare brand string
+// without the trailing colon; see [Provider.WithCleanCPUFormat].
+func macOSCPUInfoFormatted(ctx context.Context, executor CommandExecutor, logger *slog.Logger, clean bool) (string, error) {
+	return macOSCPUInfoFrom(ctx, executor, logger, CPUSourceAuto, clean)
+}
+
+// macOSCPUInfoFrom retrieves CPU information from the given source. With
+// [CPUSourceAuto], sysctl is tried first and system_profiler is the fallback;
+// the other sources are used exclusively.
+func macOSCPUInfoFrom(ctx context.Context, executor CommandExecutor, logger *slog.Logger, source CPUSource, clean bool) (string, error) {
+	if source != CPUSourceProfiler {
+		cpu, err := macOSCPUViaSysctl(ctx, executor, logger, clean)
+		if err == nil || source == CPUSourceSysctl {
+			return cpu, err
+		}
+
+		// Fallback: system_profiler for Apple Silicon chip type
+		if logger != nil {
+			logger.Info("falling back to system_profiler for CPU info")
+		}
+	}
+
+	cpu, err := macOSCPUViaProfiler(ctx, executor, logger)
+	if err != nil {
+		if logger != nil {
+			logger.Warn("all CPU info methods failed")
+		}
+
+		return "", ErrAllMethodsFailed
+	}
+
+	return cpu, nil
+}
+
+// macOSCPUViaSysctl retrieves the CPU brand string and features using sysctl.
+func macOSCPUViaSysctl(ctx context.Context, executor CommandExecutor, logger *slog.Logger, clean bool) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "sysctl", "-n", "machdep.cpu.brand_string")
+	if err != nil {
+		return "", err
+	}
+
+	cpuBrand := strings.TrimSpace(output)
+	if cpuBrand == "" {
+		return "", &ParseError{Source: "sysctl output", Err: ErrEmptyValue}
+	}
+
+	// Get CPU features (populated on Intel, empty on Apple Silicon)
+	featOutput, featErr := executeCommand(ctx, executor, logger, "sysctl", "-n", "machdep.cpu.features")
+	if featErr != nil {
+		return cpuBrand, nil
+	}
+
+	features := strings.TrimSpace(featOutput)
+	if clean && features == "" {
+		return cpuBrand, nil
+	}
+
+	return fmt.Sprintf("%s:%s", cpuBrand, features), nil
+}
+
+// macOSCPUViaProfiler retrieves the Apple Silicon chip type using system_profiler.
+func macOSCPUViaProfiler(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "system_profiler", "SPHardwareDataType", "-json")
+	if err != nil {
+		return "", err
+	}
+
+	var hw spHardwareDataType
+	if jsonErr := json.Unmarshal([]byte(output), &hw); jsonErr != nil || len(hw.SPHardwareDataType) == 0 {
+		if logger != nil {
+			logger.Debug("system_profiler CPU JSON parsing failed", "error", jsonErr)
+		}
+
+		return "", &ParseError{Source: "system_profiler JSON", Err: ErrNotFound}
+	}
+
+	if chipType := hw.SPHardwareDataType[0].ChipType; chipType != "" {
+		return chipType, nil
+	}
+
+	if logger != nil {
+		logger.Debug("system_profiler returned empty chip_type")
+	}
+
+	return "", &ParseError{Source: "system_profiler JSON", Err: ErrEmptyValue}
+}
+
+// macOSDiskInfo retrieves disk identifiers for stable machine identification.
+// It uses system_profiler with JSON output and keeps the disks passing filter,
+// deduplicating across volumes on the same physical disk. Each disk is
+// identified by its NVMe serial number where SPNVMeDataType reports one, and
+// by its device name, which is only a model name, otherwise.
+func macOSDiskInfo(ctx context.Context, executor CommandExecutor, filter DiskFilter, logger *slog.Logger) ([]string, error) {
+	output, err := executeCommand(ctx, executor, logger, "system_profiler", "SPStorageDataType", "-json")
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := parseStorageJSON(output, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err = executeCommand(ctx, executor, logger, "system_profiler", "SPNVMeDataType", "-json")
+	if err != nil {
+		if logger != nil {
+			logger.Debug("NVMe serials unavailable, using disk device names", "error", err)
+		}
+
+		return names, nil
+	}
+
+	serials, err := parseNVMeJSON(output)
+	if err != nil {
+		if logger != nil {
+			logger.Debug("NVMe serials unavailable, using disk device names", "error", err)
+		}
+
+		return names, nil
+	}
+
+	return preferDiskSerials(names, serials), nil
+}
+
+// preferDiskSerials replaces each device name in names with the serials of
+// the drives of that model, keeping names without a known serial, and
+// deduplicates the result.
+func preferDiskSerials(names []string, serials map[string][]string) []string {
+	var disks []string
+	for _, name := range names {
+		values, ok := serials[name]
+		if !ok {
+			values = []string{name}
+		}
+		for _, value := range values {
+			if !slices.Contains(disks, value) {
+				disks = append(disks, value)
+			}
+		}
+	}
+
+	return disks
+}
+
+// macOSThunderboltUUIDs retrieves the domain UUIDs of the Thunderbolt host controllers.
+func macOSThunderboltUUIDs(ctx context.Context, executor CommandExecutor, logger *slog.Logger) ([]string, error) {
+	output, err := executeCommand(ctx, executor, logger, "system_profiler", "SPThunderboltDataType", "-json")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseThunderboltJSON(output)
+}
+
+// parseThunderboltJSON parses system_profiler SPThunderboltDataType JSON and
+// returns the sorted, unique domain UUIDs of the host controllers (route
+// string 0). An empty list means the Mac has no Thunderbolt controller.
+func parseThunderboltJSON(jsonOutput string) ([]string, error) {
+	var thunderbolt spThunderboltDataType
+	if err := json.Unmarshal([]byte(jsonOutput), &thunderbolt); err != nil {
+		return nil, &ParseError{Source: "system_profiler Thunderbolt JSON", Err: err}
+	}
+
+	var uuids []string
+	for _, bus := range thunderbolt.SPThunderboltDataType {
+		if bus.RouteString != "" && bus.RouteString != "0" {
+			continue
+		}
+		if uuid := strings.TrimSpace(bus.DomainUUID); uuid != "" && !slices.Contains(uuids, uuid) {
+			uuids = append(uuids, uuid)
+		}
+	}
+	slices.Sort(uuids)
+
+	return uuids, nil
+}
+
+// macOSGPUModels retrieves the graphics processors using system_profiler.
+func macOSGPUModels(ctx context.Context, executor CommandExecutor, logger *slog.Logger) ([]string, error) {
+	output, err := executeCommand(ctx, executor, logger, "system_profiler", "SPDisplaysDataType", "-json")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDisplaysJSON(output)
+}
+
+// parseDisplaysJSON parses system_profiler SPDisplaysDataType JSON and returns
+// the sorted, unique GPU models, followed by ":" and the device ID where one is
+// reported ("AMD Radeon Pro 5500M:0x7340").
+func parseDisplaysJSON(jsonOutput string) ([]string, error) {
+	var displays spDisplaysDataType
+	if err := json.Unmarshal([]byte(jsonOutput), &displays); err != nil {
+		return nil, &ParseError{Source: "system_profiler displays JSON", Err: err}
+	}
+
+	var models []string
+	for _, gpu := range displays.SPDisplaysDataType {
+		model := strings.TrimSpace(gpu.Model)
+		if model == "" {
+			model = strings.TrimSpace(gpu.Name)
+		}
+		if model == "" {
+			continue
+		}
+		if deviceID := strings.ToLower(strings.TrimSpace(gpu.DeviceID)); deviceID != "" {
+			model += ":" + deviceID
+		}
+		if !slices.Contains(models, model) {
+			models = append(models, model)
+		}
+	}
+	slices.Sort(models)
+
+	return models, nil
+}
+
+// macOSMemory retrieves the memory module serials or total size using system_profiler.
+func macOSMemory(ctx context.Context, executor CommandExecutor, logger *slog.Logger) ([]string, error) {
+	output, err := executeCommand(ctx, executor, logger, "system_profiler", "SPMemoryDataType", "-json")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseMemoryJSON(output)
+}
+
+// parseMemoryJSON parses system_profiler SPMemoryDataType JSON into
+// [ComponentMemory] values. Intel Macs list their DIMMs, which often report
+// the serial "0x00000000"; Apple Silicon Macs only report the total size.
+func parseMemoryJSON(jsonOutput string) ([]string, error) {
+	var memory spMemoryDataType
+	if err := json.Unmarshal([]byte(jsonOutput), &memory); err != nil {
+		return nil, &ParseError{Source: "system_profiler memory JSON", Err: err}
+	}
+
+	var serials []string
+	var total uint64
+	for _, entry := range memory.SPMemoryDataType {
+		total += parseMemorySize(entry.Size)
+		for _, module := range entry.Items {
+			serials = append(serials, module.SerialNumber)
+			total += parseMemorySize(module.Size)
+		}
+	}
+
+	return memoryValues(serials, total, "system_profiler memory JSON")
+}
+
+// parseStorageJSON parses system_profiler SPStorageDataType JSON and extracts
+// the unique device names of the disks passing filter.
+func parseStorageJSON(jsonOutput string, filter DiskFilter) ([]string, error) {
+	var storage spStorageDataType
+	if err := json.Unmarshal([]byte(jsonOutput), &storage); err != nil {
+		return nil, &ParseError{Source: "system_profiler storage JSON", Err: err}
+	}
+
+	// Use a set to deduplicate — multiple volumes can share the same physical disk.
+	seen := make(map[string]struct{})
+	var diskNames []string
+
+	for _, entry := range storage.SPStorageDataType {
+		name := entry.PhysicalDrive.DeviceName
+		if name == "" {
+			continue
+		}
+
+		// Internal disks only by default, for stability.
+		if !filter.includes(entry.PhysicalDrive.IsInternal == "yes") {
+			continue
+		}
+
+		if _, exists := seen[name]; exists {
+			continue
+		}
+
+		seen[name] = struct{}{}
+		diskNames = append(diskNames, name)
+	}
+
+	if len(diskNames) == 0 {
+		return nil, &ParseError{Source: "system_profiler storage output", Err: ErrNotFound}
+	}
+
+	return diskNames, nil
+}
+
+// parseNVMeJSON parses system_profiler SPNVMeDataType JSON and maps the model
+// of every NVMe drive reporting a serial number to the sorted serials of the
+// drives of that model. The model, such as "APPLE SSD AP1024R", is the device
+// name reported by SPStorageDataType.
+func parseNVMeJSON(jsonOutput string) (map[string][]string, error) {
+	var nvme spNVMeDataType
+	if err := json.Unmarshal([]byte(jsonOutput), &nvme); err != nil {
+		return nil, &ParseError{Source: "system_profiler NVMe JSON", Err: err}
+	}
+
+	serials := make(map[string][]string)
+	for _, controller := range nvme.SPNVMeDataType {
+		for _, drive := range controller.Items {
+			serial := strings.TrimSpace(drive.DeviceSerial)
+			if serial == "" || isOEMPlaceholder(serial) {
+				continue
+			}
+
+			model := strings.TrimSpace(drive.DeviceModel)
+			if model == "" {
+				model = strings.TrimSpace(drive.Name)
+			}
+			if model != "" && !slices.Contains(serials[model], serial) {
+				serials[model] = append(serials[model], serial)
+			}
+		}
+	}
+	for _, values := range serials {
+		slices.Sort(values)
+	}
+
+	return serials, nil
+}
+
+// extractHardwareField extracts a field from system_profiler SPHardwareDataType JSON output.
+func extractHardwareField(jsonOutput string, fieldFn func(spHardwareEntry) string) (string, error) {
+	var hw spHardwareDataType
+	if err := json.Unmarshal([]byte(jsonOutput), &hw); err != nil {
+		return "", &ParseError{Source: "system_profiler hardware JSON", Err: err}
+	}
+
+	if len(hw.SPHardwareDataType) == 0 {
+		return "", &ParseError{Source: "system_profiler hardware JSON", Err: ErrNotFound}
+	}
+
+	value := fieldFn(hw.SPHardwareDataType[0])
+	if value == "" {
+		return "", &ParseError{Source: "system_profiler hardware JSON", Err: ErrEmptyValue}
+	}
+
+	if isOEMPlaceholder(value) {
+		return "", &ParseError{Source: "system_profiler hardware JSON", Err: ErrOEMPlaceholder}
+	}
+
+	return value, nil
+}
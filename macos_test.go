@@ -0,0 +1,1383 @@
+package machineid
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestExtractHardwareFieldValid tests successful field extraction from JSON.
+func TestExtractHardwareFieldValid(t *testing.T) {
+	jsonOutput := `{
+		"SPHardwareDataType": [{
+			"platform_UUID": "12345-67890",
+			"serial_number": "C02TEST123",
+			"chip_type": "Apple M1 Pro",
+			"machine_model": "MacBookPro18,3"
+		}]
+	}`
+	result, err := extractHardwareField(jsonOutput, func(e spHardwareEntry) string {
+		return e.PlatformUUID
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if result != "12345-67890" {
+		t.Errorf("Expected '12345-67890', got '%s'", result)
+	}
+}
+
+// TestExtractHardwareFieldEmpty tests extraction when field is empty.
+func TestExtractHardwareFieldEmpty(t *testing.T) {
+	jsonOutput := `{
+		"SPHardwareDataType": [{
+			"platform_UUID": "",
+			"serial_number": "C02TEST123"
+		}]
+	}`
+	_, err := extractHardwareField(jsonOutput, func(e spHardwareEntry) string {
+		return e.PlatformUUID
+	})
+	if err == nil {
+		t.Error("Expected error when field is empty")
+	}
+}
+
+// TestExtractHardwareFieldNoData tests extraction when no data entries exist.
+func TestExtractHardwareFieldNoData(t *testing.T) {
+	jsonOutput := `{"SPHardwareDataType": []}`
+	_, err := extractHardwareField(jsonOutput, func(e spHardwareEntry) string {
+		return e.PlatformUUID
+	})
+	if err == nil {
+		t.Error("Expected error when no data entries")
+	}
+}
+
+// TestExtractHardwareFieldInvalidJSON tests extraction with invalid JSON.
+func TestExtractHardwareFieldInvalidJSON(t *testing.T) {
+	_, err := extractHardwareField("not json", func(e spHardwareEntry) string {
+		return e.PlatformUUID
+	})
+	if err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+// TestMacOSHardwareUUIDViaIORegNotFound tests ioreg fallback when UUID not in output.
+func TestMacOSHardwareUUIDViaIORegNotFound(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutput("ioreg", "some output without UUID")
+
+	_, err := macOSHardwareUUIDViaIOReg(context.Background(), mock, nil)
+	if err == nil {
+		t.Error("Expected error when UUID not found in ioreg output")
+	}
+}
+
+// TestMacOSHardwareUUIDViaIORegError tests ioreg command error.
+func TestMacOSHardwareUUIDViaIORegError(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setError("ioreg", fmt.Errorf("command failed"))
+
+	_, err := macOSHardwareUUIDViaIOReg(context.Background(), mock, nil)
+	if err == nil {
+		t.Error("Expected error when ioreg command fails")
+	}
+}
+
+// TestMacOSHardwareUUIDViaIORegSuccess tests successful UUID extraction.
+func TestMacOSHardwareUUIDViaIORegSuccess(t *testing.T) {
+	mock := newMockExecutor()
+	ioregOutput := `
+	+-o IOPlatformExpertDevice
+	  | {
+	  |   "IOPlatformUUID" = "ABCD-1234-EFGH-5678"
+	  | }
+	`
+	mock.setOutput("ioreg", ioregOutput)
+
+	result, err := macOSHardwareUUIDViaIOReg(context.Background(), mock, nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if result != "ABCD-1234-EFGH-5678" {
+		t.Errorf("Expected 'ABCD-1234-EFGH-5678', got '%s'", result)
+	}
+}
+
+// TestMacOSSerialNumberViaIORegError tests ioreg error.
+func TestMacOSSerialNumberViaIORegError(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setError("ioreg", fmt.Errorf("command failed"))
+
+	_, err := macOSSerialNumberViaIOReg(context.Background(), mock, nil)
+	if err == nil {
+		t.Error("Expected error when ioreg command fails")
+	}
+}
+
+// TestMacOSSerialNumberViaIORegNotFound tests when serial not in output.
+func TestMacOSSerialNumberViaIORegNotFound(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutput("ioreg", "output without serial")
+
+	_, err := macOSSerialNumberViaIOReg(context.Background(), mock, nil)
+	if err == nil {
+		t.Error("Expected error when serial not found")
+	}
+}
+
+// TestMacOSSerialNumberViaIORegSuccess tests successful extraction.
+func TestMacOSSerialNumberViaIORegSuccess(t *testing.T) {
+	mock := newMockExecutor()
+	ioregOutput := `
+	"IOPlatformSerialNumber" = "C02TEST123"
+	`
+	mock.setOutput("ioreg", ioregOutput)
+
+	result, err := macOSSerialNumberViaIOReg(context.Background(), mock, nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if result != "C02TEST123" {
+		t.Errorf("Expected 'C02TEST123', got '%s'", result)
+	}
+}
+
+// TestMacOSSerialNumberFallback tests fallback to ioreg.
+func TestMacOSSerialNumberFallback(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setError("system_profiler", fmt.Errorf("system_profiler failed"))
+	mock.setOutput("ioreg", `"IOPlatformSerialNumber" = "C02FALLBACK"`)
+
+	result, err := macOSSerialNumber(context.Background(), mock, nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if result != "C02FALLBACK" {
+		t.Errorf("Expected 'C02FALLBACK', got '%s'", result)
+	}
+}
+
+// TestMacOSCPUInfoError tests CPU info error handling.
+func TestMacOSCPUInfoError(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setError("sysctl", fmt.Errorf("command failed"))
+	mock.setError("system_profiler", fmt.Errorf("command failed"))
+
+	_, err := macOSCPUInfo(context.Background(), mock, nil)
+	if err == nil {
+		t.Error("Expected error when all CPU info commands fail")
+	}
+}
+
+// TestMacOSCPUInfoAppleSiliconViaSysctl tests Apple Silicon CPU info using sysctl
+// (primary path). On Apple Silicon, sysctl -n machdep.cpu.brand_string returns
+// the chip name, and machdep.cpu.features returns empty, producing "ChipType:".
+func TestMacOSCPUInfoAppleSiliconViaSysctl(t *testing.T) {
+	mock := newMockExecutor()
+	// sysctl returns "Apple M1 Pro" as brand_string, empty features (Apple Silicon behavior)
+	mock.setOutput("sysctl", "Apple M1 Pro")
+
+	result, err := macOSCPUInfo(context.Background(), mock, nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	// NOTE: On Apple Silicon, sysctl -n machdep.cpu.features succeeds with empty output.
+	// The mock returns the same output for both sysctl calls (brand_string and features)
+	// because it keys by command name only. In production, features is empty, producing
+	// "Apple M1 Pro:" (trailing colon). The mock produces "Apple M1 Pro:Apple M1 Pro"
+	// which still exercises the code path correctly.
+	if !strings.HasPrefix(result, "Apple M1 Pro") {
+		t.Errorf("Expected result to start with 'Apple M1 Pro', got '%s'", result)
+	}
+}
+
+// TestMacOSCPUInfoFormats tests the default and clean CPU formats for Apple
+// Silicon (empty features) and Intel (populated features).
+func TestMacOSCPUInfoFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		brand    string
+		features string
+		clean    bool
+		want     string
+	}{
+		{"apple silicon default", "Apple M1 Pro", "", false, "Apple M1 Pro:"},
+		{"apple silicon clean", "Apple M1 Pro", "", true, "Apple M1 Pro"},
+		{"intel default", "Intel(R) Core(TM) i9", "FPU VME SSE", false, "Intel(R) Core(TM) i9:FPU VME SSE"},
+		{"intel clean", "Intel(R) Core(TM) i9", "FPU VME SSE", true, "Intel(R) Core(TM) i9:FPU VME SSE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockExecutor()
+			mock.setOutputForArgs("sysctl", []string{"-n", "machdep.cpu.brand_string"}, tt.brand)
+			mock.setOutputForArgs("sysctl", []string{"-n", "machdep.cpu.features"}, tt.features)
+
+			result, err := macOSCPUInfoFormatted(context.Background(), mock, nil, tt.clean)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, result)
+			}
+		})
+	}
+}
+
+// TestMacOSCPUInfoForcedSource tests that a forced CPU source is used
+// exclusively, without falling back to the other one.
+func TestMacOSCPUInfoForcedSource(t *testing.T) {
+	newMock := func() *mockExecutor {
+		mock := newMockExecutor()
+		mock.setOutputForArgs("sysctl", []string{"-n", "machdep.cpu.brand_string"}, "Apple M2 Pro")
+		mock.setOutputForArgs("sysctl", []string{"-n", "machdep.cpu.features"}, "")
+		mock.setOutput("system_profiler", `{"SPHardwareDataType": [{"chip_type": "Apple M2 Pro"}]}`)
+		return mock
+	}
+
+	t.Run("sysctl", func(t *testing.T) {
+		mock := newMock()
+		result, err := macOSCPUInfoFrom(context.Background(), mock, nil, CPUSourceSysctl, false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result != "Apple M2 Pro:" {
+			t.Errorf("Expected %q, got %q", "Apple M2 Pro:", result)
+		}
+		if mock.callCount["system_profiler"] != 0 {
+			t.Error("Expected system_profiler not to be called")
+		}
+	})
+
+	t.Run("profiler", func(t *testing.T) {
+		mock := newMock()
+		result, err := macOSCPUInfoFrom(context.Background(), mock, nil, CPUSourceProfiler, false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result != "Apple M2 Pro" {
+			t.Errorf("Expected %q, got %q", "Apple M2 Pro", result)
+		}
+		if mock.callCount["sysctl"] != 0 {
+			t.Error("Expected sysctl not to be called")
+		}
+	})
+
+	t.Run("sysctl without fallback", func(t *testing.T) {
+		mock := newMock()
+		mock.setError("sysctl", fmt.Errorf("sysctl unavailable"))
+		if _, err := macOSCPUInfoFrom(context.Background(), mock, nil, CPUSourceSysctl, false); err == nil {
+			t.Error("Expected error when the forced source fails")
+		}
+		if mock.callCount["system_profiler"] != 0 {
+			t.Error("Expected no fallback to system_profiler")
+		}
+	})
+}
+
+// TestMacOSCPUInfoFallbackToProfiler tests CPU info falls back to system_profiler
+// when sysctl is not available.
+func TestMacOSCPUInfoFallbackToProfiler(t *testing.T) {
+	mock := newMockExecutor()
+	// sysctl not configured → error → falls through to system_profiler
+	mock.setOutput("system_profiler", `{
+		"SPHardwareDataType": [{
+			"chip_type": "Apple M1 Pro",
+			"machine_model": "MacBookPro18,3",
+			"platform_UUID": "SOME-UUID",
+			"serial_number": "SERIAL123"
+		}]
+	}`)
+
+	result, err := macOSCPUInfo(context.Background(), mock, nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if result != "Apple M1 Pro" {
+		t.Errorf("Expected 'Apple M1 Pro', got '%s'", result)
+	}
+}
+
+// TestMacOSCPUInfoAllFail tests that CPU info returns error when all methods fail.
+func TestMacOSCPUInfoAllFail(t *testing.T) {
+	mock := newMockExecutor()
+	// Neither sysctl nor system_profiler configured → all fail
+	mock.setError("sysctl", fmt.Errorf("command not found"))
+	mock.setError("system_profiler", fmt.Errorf("command not found"))
+
+	_, err := macOSCPUInfo(context.Background(), mock, nil)
+	if err == nil {
+		t.Error("Expected error when all CPU methods fail")
+	}
+}
+
+// TestParseStorageJSON tests proper JSON parsing of storage data.
+func TestParseStorageJSON(t *testing.T) {
+	jsonOutput := `{
+		"SPStorageDataType": [
+			{
+				"_name": "Macintosh HD - Data",
+				"bsd_name": "disk3s1",
+				"physical_drive": {
+					"device_name": "APPLE SSD AP1024R",
+					"is_internal_disk": "yes",
+					"medium_type": "ssd"
+				}
+			},
+			{
+				"_name": "Macintosh HD",
+				"bsd_name": "disk3s3s1",
+				"physical_drive": {
+					"device_name": "APPLE SSD AP1024R",
+					"is_internal_disk": "yes",
+					"medium_type": "ssd"
+				}
+			},
+			{
+				"_name": "External Drive",
+				"bsd_name": "disk8s2",
+				"physical_drive": {
+					"device_name": "SA400S37960G",
+					"is_internal_disk": "no",
+					"medium_type": "ssd"
+				}
+			}
+		]
+	}`
+
+	tests := []struct {
+		filter DiskFilter
+		want   []string
+	}{
+		{DiskFilterInternal, []string{"APPLE SSD AP1024R"}},
+		{DiskFilterAll, []string{"APPLE SSD AP1024R", "SA400S37960G"}},
+		{DiskFilterExternal, []string{"SA400S37960G"}},
+	}
+
+	for _, tt := range tests {
+		result, err := parseStorageJSON(jsonOutput, tt.filter)
+		if err != nil {
+			t.Fatalf("parseStorageJSON(filter %d) error = %v", tt.filter, err)
+		}
+		// Volumes on the same physical disk are deduplicated.
+		if !slices.Equal(result, tt.want) {
+			t.Errorf("parseStorageJSON(filter %d) = %v, want %v", tt.filter, result, tt.want)
+		}
+	}
+}
+
+// TestParseStorageJSONNoInternal tests when no internal disks are found.
+func TestParseStorageJSONNoInternal(t *testing.T) {
+	jsonOutput := `{
+		"SPStorageDataType": [
+			{
+				"_name": "External",
+				"physical_drive": {
+					"device_name": "External SSD",
+					"is_internal_disk": "no"
+				}
+			}
+		]
+	}`
+
+	_, err := parseStorageJSON(jsonOutput, DiskFilterInternal)
+	if err == nil {
+		t.Error("Expected error when no internal disks found")
+	}
+
+	result, err := parseStorageJSON(jsonOutput, DiskFilterExternal)
+	if err != nil || !slices.Equal(result, []string{"External SSD"}) {
+		t.Errorf("parseStorageJSON(DiskFilterExternal) = %v, %v, want [External SSD]", result, err)
+	}
+}
+
+// TestParseStorageJSONInvalid tests invalid JSON.
+func TestParseStorageJSONInvalid(t *testing.T) {
+	_, err := parseStorageJSON("not json", DiskFilterInternal)
+	if err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+// TestMacOSDiskInfoError tests disk info when system_profiler fails.
+func TestMacOSDiskInfoError(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setError("system_profiler", fmt.Errorf("command failed"))
+
+	_, err := macOSDiskInfo(context.Background(), mock, DiskFilterInternal, nil)
+	if err == nil {
+		t.Error("Expected error when system_profiler fails")
+	}
+}
+
+// TestMacOSDiskInfoSuccess tests successful disk info via JSON.
+func TestMacOSDiskInfoSuccess(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutput("system_profiler", `{
+		"SPStorageDataType": [
+			{
+				"_name": "Macintosh HD",
+				"physical_drive": {
+					"device_name": "APPLE SSD AP1024R",
+					"is_internal_disk": "yes"
+				}
+			}
+		]
+	}`)
+
+	result, err := macOSDiskInfo(context.Background(), mock, DiskFilterInternal, nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(result) != 1 {
+		t.Errorf("Expected 1 disk entry, got %d", len(result))
+	}
+}
+
+// nvmeJSONFixture is SPNVMeDataType output of a Mac with an internal Apple
+// SSD and an external NVMe enclosure.
+const nvmeJSONFixture = `{
+	"SPNVMeDataType": [
+		{
+			"_name": "Generic SSD Controller",
+			"_items": [
+				{
+					"_name": "APPLE SSD AP1024R",
+					"bsd_name": "disk0",
+					"device_model": "APPLE SSD AP1024R",
+					"device_serial": "0ba0123456789abc",
+					"detachable_drive": "no"
+				}
+			]
+		},
+		{
+			"_name": "NVMe Controller",
+			"_items": [
+				{
+					"_name": "Samsung SSD 980 PRO 1TB",
+					"bsd_name": "disk8",
+					"device_serial": "S5GXNF0R123456"
+				},
+				{
+					"_name": "Unknown NVMe",
+					"bsd_name": "disk9",
+					"device_serial": ""
+				}
+			]
+		}
+	]
+}`
+
+// TestParseNVMeJSON tests mapping NVMe drive models to their serials.
+func TestParseNVMeJSON(t *testing.T) {
+	serials, err := parseNVMeJSON(nvmeJSONFixture)
+	if err != nil {
+		t.Fatalf("parseNVMeJSON() error = %v", err)
+	}
+
+	want := map[string][]string{
+		"APPLE SSD AP1024R":       {"0ba0123456789abc"},
+		"Samsung SSD 980 PRO 1TB": {"S5GXNF0R123456"},
+	}
+	if len(serials) != len(want) {
+		t.Errorf("parseNVMeJSON() = %v, want %v", serials, want)
+	}
+	for model, values := range want {
+		if !slices.Equal(serials[model], values) {
+			t.Errorf("parseNVMeJSON()[%q] = %v, want %v", model, serials[model], values)
+		}
+	}
+
+	if _, err := parseNVMeJSON("not json"); err == nil {
+		t.Error("parseNVMeJSON() expected error for invalid JSON")
+	}
+}
+
+// TestMacOSDiskInfoNVMeSerials tests that internal disks are identified by
+// their NVMe serial, falling back to the device name without one.
+func TestMacOSDiskInfoNVMeSerials(t *testing.T) {
+	storage := `{
+		"SPStorageDataType": [
+			{"_name": "Macintosh HD - Data", "physical_drive": {"device_name": "APPLE SSD AP1024R", "is_internal_disk": "yes"}},
+			{"_name": "Macintosh HD", "physical_drive": {"device_name": "APPLE SSD AP1024R", "is_internal_disk": "yes"}},
+			{"_name": "Fusion", "physical_drive": {"device_name": "APPLE HDD ST1000DM003", "is_internal_disk": "yes"}},
+			{"_name": "External", "physical_drive": {"device_name": "Samsung SSD 980 PRO 1TB", "is_internal_disk": "no"}}
+		]
+	}`
+
+	tests := []struct {
+		name    string
+		filter  DiskFilter
+		nvme    string
+		nvmeErr bool
+		want    []string
+	}{
+		{"internal", DiskFilterInternal, nvmeJSONFixture, false, []string{"0ba0123456789abc", "APPLE HDD ST1000DM003"}},
+		{"all", DiskFilterAll, nvmeJSONFixture, false, []string{"0ba0123456789abc", "APPLE HDD ST1000DM003", "S5GXNF0R123456"}},
+		{"nvme unavailable", DiskFilterInternal, "", true, []string{"APPLE SSD AP1024R", "APPLE HDD ST1000DM003"}},
+		{"nvme invalid", DiskFilterInternal, "not json", false, []string{"APPLE SSD AP1024R", "APPLE HDD ST1000DM003"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockExecutor()
+			mock.setOutputForArgs("system_profiler", []string{"SPStorageDataType", "-json"}, storage)
+			if !tt.nvmeErr {
+				mock.setOutputForArgs("system_profiler", []string{"SPNVMeDataType", "-json"}, tt.nvme)
+			}
+
+			result, err := macOSDiskInfo(context.Background(), mock, tt.filter, nil)
+			if err != nil {
+				t.Fatalf("macOSDiskInfo() error = %v", err)
+			}
+			if !slices.Equal(result, tt.want) {
+				t.Errorf("macOSDiskInfo() = %v, want %v", result, tt.want)
+			}
+		})
+	}
+}
+
+// TestMacOSHardwareUUIDWithLogger tests UUID fallback with logger enabled.
+func TestMacOSHardwareUUIDWithLogger(t *testing.T) {
+	t.Run("system_profiler parse error falls back to ioreg with logging", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		mock := newMockExecutor()
+		mock.setOutput("system_profiler", "not json") // Will cause parse error
+		mock.setOutput("ioreg", `"IOPlatformUUID" = "FALLBACK-UUID-123"`)
+
+		result, err := macOSHardwareUUID(context.Background(), mock, logger)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result != "FALLBACK-UUID-123" {
+			t.Errorf("Expected 'FALLBACK-UUID-123', got %q", result)
+		}
+		if !bytes.Contains(buf.Bytes(), []byte("system_profiler UUID parsing failed")) {
+			t.Error("Expected 'system_profiler UUID parsing failed' in log output")
+		}
+		if !bytes.Contains(buf.Bytes(), []byte("falling back to ioreg for hardware UUID")) {
+			t.Error("Expected 'falling back to ioreg' in log output")
+		}
+	})
+
+	t.Run("system_profiler command error falls back with logging", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		mock := newMockExecutor()
+		mock.setError("system_profiler", fmt.Errorf("command failed"))
+		mock.setOutput("ioreg", `"IOPlatformUUID" = "FALLBACK-UUID-456"`)
+
+		result, err := macOSHardwareUUID(context.Background(), mock, logger)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result != "FALLBACK-UUID-456" {
+			t.Errorf("Expected 'FALLBACK-UUID-456', got %q", result)
+		}
+		if !bytes.Contains(buf.Bytes(), []byte("falling back to ioreg for hardware UUID")) {
+			t.Error("Expected fallback log message")
+		}
+	})
+}
+
+// TestMacOSSerialNumberWithLogger tests serial fallback with logger enabled.
+func TestMacOSSerialNumberWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	mock := newMockExecutor()
+	mock.setOutput("system_profiler", "not json") // Will cause parse error
+	mock.setOutput("ioreg", `"IOPlatformSerialNumber" = "SERIAL-LOG"`)
+
+	result, err := macOSSerialNumber(context.Background(), mock, logger)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "SERIAL-LOG" {
+		t.Errorf("Expected 'SERIAL-LOG', got %q", result)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("system_profiler serial parsing failed")) {
+		t.Error("Expected 'system_profiler serial parsing failed' in log output")
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("falling back to ioreg for serial number")) {
+		t.Error("Expected 'falling back to ioreg' in log output")
+	}
+}
+
+// TestMacOSHardwareUUIDViaIORegNotFoundWithLogger tests ioreg not-found with logger.
+func TestMacOSHardwareUUIDViaIORegNotFoundWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	mock := newMockExecutor()
+	mock.setOutput("ioreg", "output without UUID pattern")
+
+	_, err := macOSHardwareUUIDViaIOReg(context.Background(), mock, logger)
+	if err == nil {
+		t.Error("Expected error")
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("hardware UUID not found in ioreg output")) {
+		t.Error("Expected 'hardware UUID not found in ioreg output' in log")
+	}
+}
+
+// TestMacOSSerialNumberViaIORegNotFoundWithLogger tests serial not-found with logger.
+func TestMacOSSerialNumberViaIORegNotFoundWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	mock := newMockExecutor()
+	mock.setOutput("ioreg", "output without serial pattern")
+
+	_, err := macOSSerialNumberViaIOReg(context.Background(), mock, logger)
+	if err == nil {
+		t.Error("Expected error")
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("serial number not found in ioreg output")) {
+		t.Error("Expected 'serial number not found in ioreg output' in log")
+	}
+}
+
+// TestMacOSCPUInfoEmptyBrand tests sysctl returning empty brand string.
+func TestMacOSCPUInfoEmptyBrand(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutput("sysctl", "") // Empty brand string
+	mock.setOutput("system_profiler", `{
+		"SPHardwareDataType": [{
+			"chip_type": "Apple M2",
+			"machine_model": "Mac",
+			"platform_UUID": "UUID",
+			"serial_number": "SER"
+		}]
+	}`)
+
+	result, err := macOSCPUInfo(context.Background(), mock, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "Apple M2" {
+		t.Errorf("Expected 'Apple M2', got %q", result)
+	}
+}
+
+// TestMacOSCPUInfoEmptyBrandWithLogger tests empty brand path with logger.
+func TestMacOSCPUInfoEmptyBrandWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	mock := newMockExecutor()
+	mock.setOutput("sysctl", "") // Empty brand → falls to system_profiler
+	mock.setOutput("system_profiler", `{
+		"SPHardwareDataType": [{
+			"chip_type": "Apple M2 Pro",
+			"machine_model": "Mac",
+			"platform_UUID": "UUID",
+			"serial_number": "SER"
+		}]
+	}`)
+
+	result, err := macOSCPUInfo(context.Background(), mock, logger)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "Apple M2 Pro" {
+		t.Errorf("Expected 'Apple M2 Pro', got %q", result)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("falling back to system_profiler for CPU info")) {
+		t.Error("Expected fallback log message")
+	}
+}
+
+// TestMacOSCPUInfoProfilerJSONParseFailWithLogger tests JSON parse failure with logger.
+func TestMacOSCPUInfoProfilerJSONParseFailWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	mock := newMockExecutor()
+	mock.setError("sysctl", fmt.Errorf("not found"))
+	mock.setOutput("system_profiler", "not valid json")
+
+	_, err := macOSCPUInfo(context.Background(), mock, logger)
+	if err == nil {
+		t.Error("Expected error when all methods fail")
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("system_profiler CPU JSON parsing failed")) {
+		t.Error("Expected 'system_profiler CPU JSON parsing failed' in log")
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("all CPU info methods failed")) {
+		t.Error("Expected 'all CPU info methods failed' in log")
+	}
+}
+
+// TestMacOSCPUInfoEmptyChipTypeWithLogger tests empty chip_type with logger.
+func TestMacOSCPUInfoEmptyChipTypeWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	mock := newMockExecutor()
+	mock.setError("sysctl", fmt.Errorf("not found"))
+	mock.setOutput("system_profiler", `{
+		"SPHardwareDataType": [{
+			"chip_type": "",
+			"machine_model": "Mac",
+			"platform_UUID": "UUID",
+			"serial_number": "SER"
+		}]
+	}`)
+
+	_, err := macOSCPUInfo(context.Background(), mock, logger)
+	if err == nil {
+		t.Error("Expected error when chip_type is empty")
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("system_profiler returned empty chip_type")) {
+		t.Error("Expected 'system_profiler returned empty chip_type' in log")
+	}
+}
+
+// TestMacOSCPUInfoSysctlBrandWithFeaturesFail tests brand success but features fail.
+func TestMacOSCPUInfoSysctlBrandWithFeaturesFail(t *testing.T) {
+	mock := newMockExecutor()
+	// The mock returns same output for all "sysctl" calls. To test the
+	// features-fail path, we set sysctl to error on the second call.
+	// Since mockExecutor doesn't support per-arg differentiation, we test
+	// the code path where sysctl succeeds for brand but the features call
+	// also "succeeds" (same mock behavior). The brand-only path is tested
+	// by making sysctl return error after first call isn't straightforward,
+	// so we test that a non-empty brand with features returns formatted output.
+	mock.setOutput("sysctl", "Intel Core i7")
+
+	result, err := macOSCPUInfo(context.Background(), mock, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Both brand and features calls go to same mock → "Intel Core i7:Intel Core i7"
+	if !strings.Contains(result, "Intel Core i7") {
+		t.Errorf("Expected result to contain 'Intel Core i7', got %q", result)
+	}
+}
+
+// TestParseStorageJSONEmptyDeviceName tests entries with empty device_name are skipped.
+func TestParseStorageJSONEmptyDeviceName(t *testing.T) {
+	jsonOutput := `{
+		"SPStorageDataType": [
+			{
+				"_name": "Volume 1",
+				"physical_drive": {
+					"device_name": "",
+					"is_internal_disk": "yes"
+				}
+			},
+			{
+				"_name": "Volume 2",
+				"physical_drive": {
+					"device_name": "APPLE SSD",
+					"is_internal_disk": "yes"
+				}
+			}
+		]
+	}`
+
+	result, err := parseStorageJSON(jsonOutput, DiskFilterInternal)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result) != 1 {
+		t.Errorf("Expected 1 disk entry (empty name skipped), got %d", len(result))
+	}
+	if result[0] != "APPLE SSD" {
+		t.Errorf("Expected 'APPLE SSD', got %q", result[0])
+	}
+}
+
+// TestParseStorageJSONAllEmpty tests when all entries have empty device_name.
+func TestParseStorageJSONAllEmpty(t *testing.T) {
+	jsonOutput := `{
+		"SPStorageDataType": [
+			{
+				"_name": "Volume 1",
+				"physical_drive": {
+					"device_name": "",
+					"is_internal_disk": "yes"
+				}
+			}
+		]
+	}`
+
+	_, err := parseStorageJSON(jsonOutput, DiskFilterInternal)
+	if err == nil {
+		t.Error("Expected error when all disk entries have empty device_name")
+	}
+}
+
+// TestParseStorageJSONEmptyArray tests empty storage array.
+func TestParseStorageJSONEmptyArray(t *testing.T) {
+	jsonOutput := `{"SPStorageDataType": []}`
+	_, err := parseStorageJSON(jsonOutput, DiskFilterInternal)
+	if err == nil {
+		t.Error("Expected error for empty storage array")
+	}
+}
+
+// TestCollectMACAddressesWithLogger tests that MAC collection logs why each
+// interface was skipped or included.
+func TestCollectMACAddressesWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	p := New().WithMAC(MACFilterPhysical)
+	p.listInterfaces = fakeInterfaces
+	if _, err := p.macAddresses(isVirtualNetInterface, isWirelessNetInterface, logger); err != nil {
+		t.Fatalf("macAddresses() error = %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		`"including interface" interface=eth0`,
+		`"skipping virtual interface" interface=docker0`,
+		`"skipping interface (not up)" interface=eth1`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("log output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+// TestExtractHardwareFieldErrorTypes tests that extractHardwareField returns correct error types.
+func TestExtractHardwareFieldErrorTypes(t *testing.T) {
+	t.Run("invalid JSON returns ParseError", func(t *testing.T) {
+		_, err := extractHardwareField("not json", func(e spHardwareEntry) string {
+			return e.PlatformUUID
+		})
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("Expected ParseError, got %T: %v", err, err)
+		}
+		if parseErr.Source != "system_profiler hardware JSON" {
+			t.Errorf("ParseError.Source = %q, want %q", parseErr.Source, "system_profiler hardware JSON")
+		}
+	})
+
+	t.Run("no data returns ParseError with ErrNotFound", func(t *testing.T) {
+		_, err := extractHardwareField(`{"SPHardwareDataType": []}`, func(e spHardwareEntry) string {
+			return e.PlatformUUID
+		})
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("Expected ParseError, got %T: %v", err, err)
+		}
+		if !errors.Is(err, ErrNotFound) {
+			t.Error("Expected ErrNotFound in error chain")
+		}
+	})
+
+	t.Run("empty field returns ParseError with ErrEmptyValue", func(t *testing.T) {
+		_, err := extractHardwareField(`{"SPHardwareDataType": [{"platform_UUID": ""}]}`, func(e spHardwareEntry) string {
+			return e.PlatformUUID
+		})
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("Expected ParseError, got %T: %v", err, err)
+		}
+		if !errors.Is(err, ErrEmptyValue) {
+			t.Error("Expected ErrEmptyValue in error chain")
+		}
+	})
+
+	t.Run("placeholder returns ParseError with ErrOEMPlaceholder", func(t *testing.T) {
+		_, err := extractHardwareField(`{"SPHardwareDataType": [{"serial_number": "System Serial Number"}]}`, func(e spHardwareEntry) string {
+			return e.SerialNumber
+		})
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("Expected ParseError, got %T: %v", err, err)
+		}
+		if !errors.Is(err, ErrOEMPlaceholder) {
+			t.Error("Expected ErrOEMPlaceholder in error chain")
+		}
+	})
+}
+
+// TestMacOSHardwareUUIDViaIORegErrorType tests error type from ioreg not-found.
+func TestMacOSHardwareUUIDViaIORegErrorType(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutput("ioreg", "no UUID here")
+
+	_, err := macOSHardwareUUIDViaIOReg(context.Background(), mock, nil)
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected ParseError, got %T: %v", err, err)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound in error chain")
+	}
+}
+
+// TestMacOSCPUInfoAllFailErrorType tests error type when all CPU methods fail.
+func TestMacOSCPUInfoAllFailErrorType(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setError("sysctl", fmt.Errorf("not found"))
+	mock.setError("system_profiler", fmt.Errorf("not found"))
+
+	_, err := macOSCPUInfo(context.Background(), mock, nil)
+	if !errors.Is(err, ErrAllMethodsFailed) {
+		t.Errorf("Expected ErrAllMethodsFailed, got %v", err)
+	}
+}
+
+// TestParseSwVers tests formatting of sw_vers output.
+func TestParseSwVers(t *testing.T) {
+	output := "ProductName:\t\tmacOS\nProductVersion:\t\t15.1\nBuildVersion:\t\t24B83\n"
+
+	got, err := parseSwVers(output)
+	if err != nil {
+		t.Fatalf("parseSwVers() error = %v", err)
+	}
+	if got != "15.1 (24B83)" {
+		t.Errorf("parseSwVers() = %q, want %q", got, "15.1 (24B83)")
+	}
+
+	if _, err := parseSwVers(""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("parseSwVers() error = %v, want ErrNotFound", err)
+	}
+}
+
+// TestCollectIdentifiersForDarwin tests that the macOS collector runs on any
+// host through a mock executor.
+func TestCollectIdentifiersForDarwin(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutputForArgs("sysctl", []string{"-n", "machdep.cpu.brand_string"}, "Apple M1 Pro")
+	mock.setOutputForArgs("sysctl", []string{"-n", "machdep.cpu.features"}, "")
+	mock.setOutput("system_profiler", `{"SPHardwareDataType": [{"platform_UUID": "UUID-1234", "serial_number": "C02TEST123"}]}`)
+
+	p := New().WithExecutor(mock).WithCPU().WithSystemUUID().WithMotherboard()
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	identifiers, err := collectIdentifiersFor(context.Background(), "darwin", p, diag)
+	if err != nil {
+		t.Fatalf("collectIdentifiersFor(darwin) error = %v", err)
+	}
+
+	want := []string{"uuid:UUID-1234", "mb:C02TEST123", "cpu:Apple M1 Pro:"}
+	if !slices.Equal(identifiers, want) {
+		t.Errorf("collectIdentifiersFor(darwin) = %v, want %v", identifiers, want)
+	}
+	if len(diag.Errors) != 0 {
+		t.Errorf("diag.Errors = %v, want none", diag.Errors)
+	}
+}
+
+// TestWithComponentPrefix tests that the motherboard prefix is the same on
+// macOS and FreeBSD, and that WithComponentPrefix pins a legacy prefix.
+func TestWithComponentPrefix(t *testing.T) {
+	const serial = "C02TEST123"
+	darwin := newMockExecutor()
+	darwin.setOutput("system_profiler", `{"SPHardwareDataType": [{"serial_number": "`+serial+`"}]}`)
+	freebsd := newMockExecutor()
+	freebsd.setOutputForArgs("kenv", []string{"-q", "smbios.planar.serial"}, serial)
+
+	tests := []struct {
+		name     string
+		platform string
+		p        *Provider
+		want     string
+	}{
+		{"darwin", "darwin", New().WithExecutor(darwin).WithMotherboard(), "mb:" + serial},
+		{"freebsd", "freebsd", New().WithExecutor(freebsd).WithMotherboard(), "mb:" + serial},
+		{"legacy darwin", "darwin", New().WithExecutor(darwin).WithMotherboard().WithComponentPrefix(ComponentMotherboard, "serial:"), "serial:" + serial},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identifiers, err := collectIdentifiersFor(context.Background(), tt.platform, tt.p, &DiagnosticInfo{Errors: make(map[string]error)})
+			if err != nil {
+				t.Fatalf("collectIdentifiersFor(%s) error = %v", tt.platform, err)
+			}
+			if !slices.Equal(identifiers, []string{tt.want}) {
+				t.Errorf("identifiers = %v, want [%s]", identifiers, tt.want)
+			}
+			if values := tt.p.componentValues[ComponentMotherboard]; !slices.Equal(values, []string{serial}) {
+				t.Errorf("component values = %v, want [%s]", values, serial)
+			}
+		})
+	}
+
+	if New().WithComponentPrefix(ComponentMotherboard, "serial:").configHash() == New().configHash() {
+		t.Error("configHash() should change with a component prefix")
+	}
+}
+
+// TestCollectIdentifiersForUnavailable tests that collectors of other
+// platforms are reported as unavailable.
+func TestCollectIdentifiersForUnavailable(t *testing.T) {
+	if _, err := collectIdentifiersFor(context.Background(), "plan9", New().WithCPU(), nil); err == nil {
+		t.Error("collectIdentifiersFor(plan9) expected error")
+	}
+}
+
+// spThunderboltJSON is captured `system_profiler SPThunderboltDataType -json`
+// output of a MacBook Pro with two Thunderbolt buses and a connected dock.
+const spThunderboltJSON = `{
+  "SPThunderboltDataType" : [
+    {
+      "_items" : [
+        {
+          "_name" : "TS4 Dock",
+          "device_id_key" : "0x0015",
+          "route_string_key" : "1",
+          "switch_uid_key" : "0x00D3F1A2B3C4D5E6",
+          "vendor_name_key" : "CalDigit, Inc."
+        }
+      ],
+      "_name" : "thunderbolt_bus_0",
+      "device_name_key" : "MacBook Pro",
+      "domain_uuid_key" : "6B2F3C1E-8D4A-4E5B-9C7D-0A1B2C3D4E5F",
+      "receptacle_1_tag" : {
+        "current_speed_key" : "Up to 40 Gb/s",
+        "receptacle_id_key" : "1",
+        "receptacle_status_key" : "receptacle_connected"
+      },
+      "route_string_key" : "0",
+      "switch_uid_key" : "0x05AC000000000001",
+      "vendor_name_key" : "Apple Inc."
+    },
+    {
+      "_name" : "thunderbolt_bus_1",
+      "device_name_key" : "MacBook Pro",
+      "domain_uuid_key" : "1A2B3C4D-5E6F-4A7B-8C9D-0E1F2A3B4C5D",
+      "route_string_key" : "0",
+      "switch_uid_key" : "0x05AC000000000002",
+      "vendor_name_key" : "Apple Inc."
+    }
+  ]
+}`
+
+// TestParseThunderboltJSON tests extraction of the host controller domain UUIDs.
+func TestParseThunderboltJSON(t *testing.T) {
+	got, err := parseThunderboltJSON(spThunderboltJSON)
+	if err != nil {
+		t.Fatalf("parseThunderboltJSON() error = %v", err)
+	}
+
+	want := []string{"1A2B3C4D-5E6F-4A7B-8C9D-0E1F2A3B4C5D", "6B2F3C1E-8D4A-4E5B-9C7D-0A1B2C3D4E5F"}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseThunderboltJSON() = %v, want %v", got, want)
+	}
+
+	if got, err := parseThunderboltJSON(`{"SPThunderboltDataType" : []}`); err != nil || len(got) != 0 {
+		t.Errorf("parseThunderboltJSON(no Thunderbolt) = %v, %v; want no UUIDs", got, err)
+	}
+
+	var parseErr *ParseError
+	if _, err := parseThunderboltJSON("not json"); !errors.As(err, &parseErr) {
+		t.Errorf("parseThunderboltJSON(invalid) error = %v, want ParseError", err)
+	}
+}
+
+// TestWithThunderbolt tests that Thunderbolt contributes the host controller
+// UUIDs, and that a Mac without Thunderbolt reports it as absent.
+func TestWithThunderbolt(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		wantIDs    []string
+		wantAbsent bool
+	}{
+		{"thunderbolt", spThunderboltJSON, []string{
+			"thunderbolt:1A2B3C4D-5E6F-4A7B-8C9D-0E1F2A3B4C5D",
+			"thunderbolt:6B2F3C1E-8D4A-4E5B-9C7D-0A1B2C3D4E5F",
+		}, false},
+		{"no thunderbolt", `{"SPThunderboltDataType" : []}`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockExecutor()
+			mock.setOutput("system_profiler", tt.output)
+			p := New().WithExecutor(mock).WithThunderbolt()
+			diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+			identifiers, err := collectIdentifiersFor(context.Background(), "darwin", p, diag)
+			if err != nil {
+				t.Fatalf("collectIdentifiersFor(darwin) error = %v", err)
+			}
+			if !slices.Equal(identifiers, tt.wantIDs) {
+				t.Errorf("identifiers = %v, want %v", identifiers, tt.wantIDs)
+			}
+			if len(diag.Errors) != 0 {
+				t.Errorf("diag.Errors = %v, want none", diag.Errors)
+			}
+			if got := slices.Contains(diag.Absent, ComponentThunderbolt); got != tt.wantAbsent {
+				t.Errorf("thunderbolt absent = %v, want %v", got, tt.wantAbsent)
+			}
+		})
+	}
+}
+
+// spDisplaysIntelJSON is abridged `system_profiler SPDisplaysDataType -json`
+// output of an Intel MacBook Pro with integrated and discrete graphics.
+const spDisplaysIntelJSON = `{
+  "SPDisplaysDataType" : [
+    {
+      "_name" : "Intel UHD Graphics 630",
+      "spdisplays_device-id" : "0x3e9b",
+      "spdisplays_vendor" : "Intel",
+      "sppci_bus" : "spdisplays_builtin",
+      "sppci_model" : "Intel UHD Graphics 630"
+    },
+    {
+      "_name" : "AMD Radeon Pro 5500M",
+      "spdisplays_device-id" : "0x7340",
+      "spdisplays_vendor" : "sppci_vendor_amd",
+      "sppci_bus" : "spdisplays_pcie_device",
+      "sppci_model" : "AMD Radeon Pro 5500M"
+    }
+  ]
+}`
+
+// TestParseDisplaysJSON tests extraction of the GPU models and device IDs.
+func TestParseDisplaysJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{"intel dual GPU", spDisplaysIntelJSON, []string{"AMD Radeon Pro 5500M:0x7340", "Intel UHD Graphics 630:0x3e9b"}},
+		{"apple silicon", `{"SPDisplaysDataType" : [{"_name" : "Apple M1 Pro", "sppci_cores" : "16", "sppci_model" : "Apple M1 Pro"}]}`, []string{"Apple M1 Pro"}},
+		{"name only", `{"SPDisplaysDataType" : [{"_name" : "Display Adapter"}, {"_name" : ""}]}`, []string{"Display Adapter"}},
+		{"no GPU", `{"SPDisplaysDataType" : []}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDisplaysJSON(tt.output)
+			if err != nil {
+				t.Fatalf("parseDisplaysJSON() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseDisplaysJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var parseErr *ParseError
+	if _, err := parseDisplaysJSON("not json"); !errors.As(err, &parseErr) {
+		t.Errorf("parseDisplaysJSON(invalid) error = %v, want ParseError", err)
+	}
+}
+
+// TestWithGPUDarwin tests that each GPU contributes its own identifier.
+func TestWithGPUDarwin(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutputForArgs("system_profiler", []string{"SPDisplaysDataType", "-json"}, spDisplaysIntelJSON)
+	p := New().WithExecutor(mock).WithGPU()
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	identifiers, err := collectIdentifiersFor(context.Background(), "darwin", p, diag)
+	if err != nil {
+		t.Fatalf("collectIdentifiersFor(darwin) error = %v", err)
+	}
+	want := []string{"gpu:AMD Radeon Pro 5500M:0x7340", "gpu:Intel UHD Graphics 630:0x3e9b"}
+	if !slices.Equal(identifiers, want) {
+		t.Errorf("identifiers = %v, want %v", identifiers, want)
+	}
+	if !slices.Equal(diag.Collected, []string{ComponentGPU}) {
+		t.Errorf("Collected = %v, want [%s]", diag.Collected, ComponentGPU)
+	}
+}
+
+// TestParseMemoryJSON tests the memory values of Intel Macs with and without
+// DIMM serials, and of Apple Silicon Macs, which only report the total.
+func TestParseMemoryJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{"intel with serials", `{"SPMemoryDataType" : [{"_items" : [
+			{"_name" : "BANK 0/ChannelA-DIMM0", "dimm_serial_number" : "0x12345678", "dimm_size" : "16 GB"},
+			{"_name" : "BANK 2/ChannelB-DIMM0", "dimm_serial_number" : "0x9ABCDEF0", "dimm_size" : "16 GB"}
+		], "_name" : "SPMemoryDataType"}]}`, []string{"0x12345678", "0x9ABCDEF0"}},
+		{"intel without serials", `{"SPMemoryDataType" : [{"_items" : [
+			{"_name" : "BANK 0/ChannelA-DIMM0", "dimm_serial_number" : "0x00000000", "dimm_size" : "8 GB"},
+			{"_name" : "BANK 2/ChannelB-DIMM0", "dimm_serial_number" : "-", "dimm_size" : "8 GB"}
+		], "_name" : "SPMemoryDataType"}]}`, []string{"total:17179869184"}},
+		{"apple silicon", `{"SPMemoryDataType" : [{"SPMemoryDataType" : "16 GB", "dimm_manufacturer" : "Hynix", "dimm_type" : "LPDDR5"}]}`, []string{"total:17179869184"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMemoryJSON(tt.output)
+			if err != nil {
+				t.Fatalf("parseMemoryJSON() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseMemoryJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseMemoryJSON(`{"SPMemoryDataType" : []}`); !errors.Is(err, ErrNotFound) {
+		t.Errorf("parseMemoryJSON(empty) error = %v, want ErrNotFound", err)
+	}
+}
+
+// spHardwareIntelJSON is captured `system_profiler SPHardwareDataType -json`
+// output of an Intel MacBook Pro.
+const spHardwareIntelJSON = `{
+  "SPHardwareDataType" : [
+    {
+      "_name" : "hardware_overview",
+      "boot_rom_version" : "2020.41.1.0.0 (iBridge: 21.16.365.0.0,0)",
+      "cpu_type" : "8-Core Intel Core i9",
+      "current_processor_speed" : "2,3 GHz",
+      "machine_model" : "MacBookPro16,1",
+      "machine_name" : "MacBook Pro",
+      "number_processors" : 8,
+      "physical_memory" : "32 GB",
+      "platform_UUID" : "5A1B2C3D-4E5F-6A7B-8C9D-0E1F2A3B4C5D",
+      "serial_number" : "C02ZK0XXMD6T"
+    }
+  ]
+}`
+
+// ioregIntelOutput is captured `ioreg -d2 -c IOPlatformExpertDevice` output
+// of the same Mac, trimmed to the relevant properties.
+const ioregIntelOutput = `+-o Root  <class IORegistryEntry, id 0x100000100, retain 29>
+  +-o MacBookPro16,1  <class IOPlatformExpertDevice, id 0x100000110, registered, matched, active, busy 0 (212 ms), retain 45>
+      {
+        "IOPlatformSerialNumber" = "C02ZK0XXMD6T"
+        "board-id" = <"Mac-E1008331FDC96864">
+        "model" = <"MacBookPro16,1">
+        "IOPlatformUUID" = "5A1B2C3D-4E5F-6A7B-8C9D-0E1F2A3B4C5D"
+      }
+`
+
+// ioregAppleSiliconOutput is captured ioreg output of an Apple Silicon Mac,
+// which has no board-id string.
+const ioregAppleSiliconOutput = `+-o Root  <class IORegistryEntry, id 0x100000100, retain 33>
+  +-o J316sAP  <class IOPlatformExpertDevice, id 0x100000111, registered, matched, active, busy 0 (0 ms), retain 38>
+      {
+        "IOPlatformSerialNumber" = "FVFHK0XXQ6L4"
+        "target-type" = <"J316s">
+        "model" = <"MacBookPro18,1">
+        "IOPlatformUUID" = "8E7D6C5B-4A39-4281-9F0E-1D2C3B4A5968"
+      }
+`
+
+// TestWithMacModel tests the model component on Intel and Apple Silicon Macs.
+func TestWithMacModel(t *testing.T) {
+	tests := []struct {
+		name     string
+		hardware string
+		ioreg    string
+		ioregErr error
+		want     string
+	}{
+		{"intel", spHardwareIntelJSON, ioregIntelOutput, nil, "model:MacBookPro16,1:Mac-E1008331FDC96864"},
+		{"apple silicon", strings.Replace(spHardwareIntelJSON, "MacBookPro16,1", "MacBookPro18,1", 1), ioregAppleSiliconOutput, nil, "model:MacBookPro18,1"},
+		{"ioreg failure", spHardwareIntelJSON, "", fmt.Errorf("command failed"), "model:MacBookPro16,1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockExecutor()
+			mock.setOutput("system_profiler", tt.hardware)
+			if tt.ioregErr != nil {
+				mock.setError("ioreg", tt.ioregErr)
+			} else {
+				mock.setOutput("ioreg", tt.ioreg)
+			}
+			p := New().WithExecutor(mock).WithMacModel()
+			diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+			identifiers, err := collectIdentifiersFor(context.Background(), "darwin", p, diag)
+			if err != nil {
+				t.Fatalf("collectIdentifiersFor(darwin) error = %v", err)
+			}
+			if !slices.Equal(identifiers, []string{tt.want}) {
+				t.Errorf("identifiers = %v, want [%s]", identifiers, tt.want)
+			}
+		})
+	}
+}
+
+// TestMacOSModelMissing tests that a hardware report without a model fails.
+func TestMacOSModelMissing(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutput("system_profiler", `{"SPHardwareDataType": [{"serial_number": "C02ZK0XXMD6T"}]}`)
+	mock.setOutput("ioreg", ioregIntelOutput)
+
+	if _, err := macOSModel(context.Background(), mock, nil); !errors.Is(err, ErrEmptyValue) {
+		t.Errorf("macOSModel() error = %v, want ErrEmptyValue", err)
+	}
+}
+
+// networksetupHardwarePorts is captured `networksetup -listallhardwareports`
+// output of a MacBook Pro with a Thunderbolt Ethernet adapter.
+const networksetupHardwarePorts = `
+Hardware Port: Thunderbolt Ethernet Slot 1
+Device: en5
+Ethernet Address: 00:1b:21:00:00:02
+
+Hardware Port: Wi-Fi
+Device: en0
+Ethernet Address: a4:83:e7:00:00:01
+
+Hardware Port: Thunderbolt Bridge
+Device: bridge0
+Ethernet Address: 36:c2:9a:00:00:03
+
+VLAN Configurations
+===================
+`
+
+// TestParseHardwarePorts tests that only the Wi-Fi port is wireless.
+func TestParseHardwarePorts(t *testing.T) {
+	got := parseHardwarePorts(networksetupHardwarePorts)
+	want := map[string]bool{"en5": false, "en0": true, "bridge0": false}
+	if len(got) != len(want) {
+		t.Fatalf("parseHardwarePorts() = %v, want %v", got, want)
+	}
+	for device, wireless := range want {
+		if got[device] != wireless {
+			t.Errorf("parseHardwarePorts()[%q] = %v, want %v", device, got[device], wireless)
+		}
+	}
+}
+
+// TestCollectDarwinMACFilterWired tests that the Wi-Fi interface en0 is
+// excluded under MACFilterWired, using networksetup to classify it.
+func TestCollectDarwinMACFilterWired(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutput("networksetup", networksetupHardwarePorts)
+
+	p := New().WithExecutor(mock).WithMAC(MACFilterWired)
+	p.listInterfaces = func() ([]net.Interface, error) {
+		return []net.Interface{
+			{Index: 1, Name: "en0", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0xa4, 0x83, 0xe7, 0, 0, 1}},
+			{Index: 2, Name: "en5", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x00, 0x1b, 0x21, 0, 0, 2}},
+		}, nil
+	}
+	diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+	identifiers, err := collectIdentifiersFor(context.Background(), "darwin", p, diag)
+	if err != nil {
+		t.Fatalf("collectIdentifiersFor(darwin) error = %v", err)
+	}
+	if want := []string{"mac:00:1b:21:00:00:02"}; !slices.Equal(identifiers, want) {
+		t.Errorf("identifiers = %v, want %v", identifiers, want)
+	}
+}
@@ -0,0 +1,101 @@
+package machineid
+
+import (
+	"strconv"
+	"strings"
+)
+
+// memoryValues returns the DIMM serials for [ComponentMemory], or the total
+// capacity as "total:<bytes>" when no module reports a usable serial, as on
+// most laptops and virtual machines. source names the tool for errors.
+func memoryValues(serials []string, totalBytes uint64, source string) ([]string, error) {
+	var values []string
+	for _, serial := range serials {
+		serial = strings.TrimSpace(serial)
+		if isUsableDIMMSerial(serial) {
+			values = append(values, serial)
+		}
+	}
+	if len(values) > 0 {
+		return values, nil
+	}
+
+	if totalBytes == 0 {
+		return nil, &ParseError{Source: source, Err: ErrNotFound}
+	}
+
+	return []string{"total:" + strconv.FormatUint(totalBytes, 10)}, nil
+}
+
+// isUsableDIMMSerial reports whether serial identifies a memory module, rather
+// than being empty, "-", an OEM placeholder, "Unknown", or all zeros ("0x00000000").
+func isUsableDIMMSerial(serial string) bool {
+	if serial == "" || serial == "-" || isOEMPlaceholder(serial) || strings.EqualFold(serial, "unknown") || strings.EqualFold(serial, "no dimm") {
+		return false
+	}
+
+	digits := strings.TrimPrefix(strings.ToLower(serial), "0x")
+
+	return strings.Trim(digits, "0") != ""
+}
+
+// parseMemorySize parses a module size such as "16 GB", "8192 MB" or
+// "16GB" into bytes. Sizes that are not a number followed by a unit, such as
+// "No Module Installed", parse as 0.
+func parseMemorySize(size string) uint64 {
+	size = strings.TrimSpace(size)
+	i := strings.IndexFunc(size, func(r rune) bool { return r < '0' || r > '9' })
+	if i <= 0 {
+		return 0
+	}
+
+	n, err := strconv.ParseUint(size[:i], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(size[i:])) {
+	case "KB":
+		return n << 10
+	case "MB":
+		return n << 20
+	case "GB":
+		return n << 30
+	case "TB":
+		return n << 40
+	default:
+		return 0
+	}
+}
+
+// parseDmidecodeMemory parses `dmidecode -t memory` output, returning the
+// serial numbers and total size of the installed Memory Device entries.
+func parseDmidecodeMemory(output string) (serials []string, totalBytes uint64) {
+	inDevice := false
+	for line := range strings.SplitSeq(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "\t") {
+			inDevice = trimmed == "Memory Device"
+
+			continue
+		}
+		if !inDevice {
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "Size":
+			totalBytes += parseMemorySize(value)
+		case "Serial Number":
+			serials = append(serials, value)
+		}
+	}
+
+	return serials, totalBytes
+}
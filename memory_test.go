@@ -0,0 +1,102 @@
+package machineid
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// dmidecodeMemoryOutput is abridged `dmidecode -t memory` output of a server
+// with two populated slots and one empty slot.
+const dmidecodeMemoryOutput = `# dmidecode 3.3
+Getting SMBIOS data from sysfs.
+SMBIOS 3.2.0 present.
+
+Handle 0x0040, DMI type 16, 23 bytes
+Physical Memory Array
+	Location: System Board Or Motherboard
+	Maximum Capacity: 128 GB
+	Number Of Devices: 3
+
+Handle 0x0041, DMI type 17, 84 bytes
+Memory Device
+	Size: 16 GB
+	Locator: DIMM_A1
+	Serial Number: 1A2B3C4D
+	Part Number: M393A2K43DB3-CWE
+
+Handle 0x0042, DMI type 17, 84 bytes
+Memory Device
+	Size: 16384 MB
+	Locator: DIMM_B1
+	Serial Number: 5E6F7A8B
+
+Handle 0x0043, DMI type 17, 84 bytes
+Memory Device
+	Size: No Module Installed
+	Locator: DIMM_C1
+	Serial Number: Not Specified
+`
+
+// TestParseDmidecodeMemory tests extraction of the module serials and sizes.
+func TestParseDmidecodeMemory(t *testing.T) {
+	serials, total := parseDmidecodeMemory(dmidecodeMemoryOutput)
+
+	if want := []string{"1A2B3C4D", "5E6F7A8B", "Not Specified"}; !slices.Equal(serials, want) {
+		t.Errorf("serials = %v, want %v", serials, want)
+	}
+	if want := uint64(32 << 30); total != want {
+		t.Errorf("total = %d, want %d", total, want)
+	}
+}
+
+// TestMemoryValues tests that usable DIMM serials are preferred and that the
+// total capacity is used when no module reports one.
+func TestMemoryValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		serials []string
+		total   uint64
+		want    []string
+		wantErr error
+	}{
+		{"serials", []string{"1A2B3C4D", " 5E6F7A8B ", "Not Specified"}, 32 << 30, []string{"1A2B3C4D", "5E6F7A8B"}, nil},
+		{"placeholder serials", []string{"0x00000000", "Unknown", "NO DIMM", "", "00000000"}, 16 << 30, []string{"total:17179869184"}, nil},
+		{"no modules", nil, 8 << 30, []string{"total:8589934592"}, nil},
+		{"nothing", []string{"Unknown"}, 0, nil, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := memoryValues(tt.serials, tt.total, "test")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("memoryValues() error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("memoryValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseMemorySize tests the module size units.
+func TestParseMemorySize(t *testing.T) {
+	tests := []struct {
+		size string
+		want uint64
+	}{
+		{"16 GB", 16 << 30},
+		{"16GB", 16 << 30},
+		{"8192 MB", 8 << 30},
+		{"1 TB", 1 << 40},
+		{"No Module Installed", 0},
+		{"16 GiB", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseMemorySize(tt.size); got != tt.want {
+			t.Errorf("parseMemorySize(%q) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package machineid
 
 import (
+	"context"
+	"encoding/hex"
 	"log/slog"
 	"net"
+	"runtime"
+	"slices"
 	"strings"
 )
 
@@ -26,19 +30,209 @@ var virtualInterfacePrefixes = []string{
 	"vnic", "vboxnet",
 }
 
-// collectMACAddresses retrieves MAC addresses from network interfaces filtered
-// by the given [MACFilter]. Loopback and down interfaces are always excluded.
-func collectMACAddresses(filter MACFilter, logger *slog.Logger) ([]string, error) {
-	interfaces, err := net.Interfaces()
+// windowsVirtualInterfaceMarkers lists case-insensitive substrings of Windows
+// adapter names, such as "vEthernet (Default Switch)", that identify virtual
+// adapters. Windows names describe the adapter rather than the driver, so the
+// Unix-centric prefixes above rarely match them.
+var windowsVirtualInterfaceMarkers = []string{
+	"vethernet", "hyper-v", "virtualbox host-only", "vmware", "loopback pseudo", "tap-",
+}
+
+// wirelessInterfacePrefixes lists case-insensitive interface name prefixes of
+// wireless interfaces, used where the platform offers no better source:
+// Linux wl*, FreeBSD wlan* and driver names, and Windows adapter names.
+var wirelessInterfacePrefixes = []string{
+	"wl", "wi-fi", "wireless", "ath", "iwm", "iwn", "rtwn",
+}
+
+// MACOption configures MAC address collection in [Provider.WithMAC].
+// [MACFilter] values are MAC options, as is the result of [MACMaxCount].
+type MACOption interface {
+	applyMAC(p *Provider)
+}
+
+// applyMAC sets f as the provider's MAC filter.
+func (f MACFilter) applyMAC(p *Provider) {
+	p.macFilter = f
+}
+
+// macMaxCount is the [MACOption] returned by [MACMaxCount].
+type macMaxCount int
+
+// applyMAC sets the provider's MAC count cap.
+func (n macMaxCount) applyMAC(p *Provider) {
+	p.macMaxCount = int(n)
+}
+
+// MACMaxCount caps MAC collection to the n lexicographically smallest addresses
+// that pass filtering. On hosts with many ephemeral interfaces, such as
+// Kubernetes nodes with hundreds of cali* or veth* interfaces under
+// [MACFilterAll], this keeps the MAC contribution bounded, and stable as long as
+// the smallest addresses persist. The cap applies after all filtering. A value
+// of n <= 0 means no cap.
+func MACMaxCount(n int) MACOption {
+	return macMaxCount(n)
+}
+
+// WithMACExclude drops MAC addresses that start with one of prefixes, such as
+// the VMware (00:50:56) and Hyper-V (00:15:5D) OUIs, whose addresses change
+// when a virtual machine migrates. Prefixes are matched case-insensitively,
+// with or without ':', '-' or '.' separators. Exclusion applies after the
+// [MACFilter] and before [MACMaxCount], so the physical filter can be kept
+// while hypervisor-assigned addresses are still dropped. Calling it again
+// replaces the earlier prefixes.
+func (p *Provider) WithMACExclude(prefixes ...string) *Provider {
+	p.macExclude = nil
+	for _, prefix := range prefixes {
+		if normalized := normalizeMAC(prefix); normalized != "" {
+			p.macExclude = append(p.macExclude, normalized)
+		}
+	}
+
+	return p
+}
+
+// WithMACAllow restricts the MAC component to the interfaces whose names
+// exactly match one of names, such as the onboard "eno1", ignoring every
+// other interface. The allowlist applies after the loopback and up checks and
+// before the [MACFilter], so an allowed interface must still pass the filter:
+// combine it with [MACFilterAll] to pin an interface classified as virtual.
+// [Provider.WithMACExclude] and [MACMaxCount] still apply to the allowed
+// interfaces. Calling it again replaces the earlier names; no names removes
+// the restriction.
+func (p *Provider) WithMACAllow(names ...string) *Provider {
+	p.macAllow = slices.Clone(names)
+
+	return p
+}
+
+// normalizeMAC lowercases a MAC address or prefix and strips its separators.
+func normalizeMAC(mac string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "").Replace(strings.TrimSpace(mac)))
+}
+
+// isExcludedMAC reports whether addr starts with one of the prefixes set by
+// [Provider.WithMACExclude].
+func (p *Provider) isExcludedMAC(addr net.HardwareAddr) bool {
+	mac := hex.EncodeToString(addr)
+	for _, prefix := range p.macExclude {
+		if strings.HasPrefix(mac, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// InterfaceInfo describes a network interface considered for the MAC
+// component, as returned by [Provider.NetworkInterfaces].
+type InterfaceInfo struct {
+	Name         string           // Interface name, e.g. "eth0" or "en0"
+	HardwareAddr net.HardwareAddr // MAC address
+	Virtual      bool             // Whether the interface is classified as virtual
+	Included     bool             // Whether its MAC address contributes to the ID under the active MAC options
+}
+
+// NetworkInterfaces returns the network interfaces with a MAC address, in
+// enumeration order, reporting for each whether it is classified as virtual
+// and whether its address is included in the MAC component under the active
+// [MACFilter] and [MACMaxCount]. Interfaces are classified exactly as during
+// collection, so the included addresses are the ones [Provider.ID] uses. It
+// is meant for auditing and neither changes nor freezes the configuration.
+func (p *Provider) NetworkInterfaces(ctx context.Context) ([]InterfaceInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.resolveLogger()
+	isVirtual, isWireless := macClassifiers(p.commandContext(ctx), p, p.logger)
+
+	return p.interfaceInfos(isVirtual, isWireless, p.logger)
+}
+
+// macAddresses collects MAC addresses according to the provider's MAC options,
+// classifying interfaces with isVirtual and, for [MACFilterWired], isWireless.
+func (p *Provider) macAddresses(isVirtual, isWireless func(net.Interface) bool, logger *slog.Logger) ([]string, error) {
+	infos, err := p.interfaceInfos(isVirtual, isWireless, logger)
 	if err != nil {
 		return nil, err
 	}
 
+	return includedMACs(infos), nil
+}
+
+// interfaceInfos classifies the provider's interfaces under its MAC options,
+// dropping excluded addresses and capping the rest to [MACMaxCount].
+func (p *Provider) interfaceInfos(isVirtual, isWireless func(net.Interface) bool, logger *slog.Logger) ([]InterfaceInfo, error) {
+	infos, err := classifyInterfaces(p.interfaceList, p.macAllow, p.macFilter, isVirtual, isWireless, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var included []int
+	for i, info := range infos {
+		if !info.Included {
+			continue
+		}
+		if p.isExcludedMAC(info.HardwareAddr) {
+			if logger != nil {
+				logger.Debug("skipping excluded MAC address", "interface", info.Name, "mac", info.HardwareAddr.String())
+			}
+			infos[i].Included = false
+
+			continue
+		}
+		included = append(included, i)
+	}
+
+	if p.macMaxCount > 0 && len(included) > p.macMaxCount {
+		if logger != nil {
+			logger.Debug("capping MAC addresses", "found", len(included), "max", p.macMaxCount)
+		}
+		slices.SortStableFunc(included, func(a, b int) int {
+			return strings.Compare(infos[a].HardwareAddr.String(), infos[b].HardwareAddr.String())
+		})
+		for _, i := range included[p.macMaxCount:] {
+			infos[i].Included = false
+		}
+	}
+
+	return infos, nil
+}
+
+// includedMACs returns the addresses of the included interfaces.
+func includedMACs(infos []InterfaceInfo) []string {
 	var macs []string
+	for _, info := range infos {
+		if info.Included {
+			macs = append(macs, info.HardwareAddr.String())
+		}
+	}
+
+	return macs
+}
+
+// classifyInterfaces enumerates interfaces with list and describes each one
+// with a MAC address, marking it included if it is named in allow, when allow
+// is not empty, and passes filter. Loopback and down interfaces are never
+// included.
+func classifyInterfaces(list func() ([]net.Interface, error), allow []string, filter MACFilter, isVirtual, isWireless func(net.Interface) bool, logger *slog.Logger) ([]InterfaceInfo, error) {
+	interfaces, err := list()
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []InterfaceInfo
 
 	for _, i := range interfaces {
-		// Skip loopback interfaces and those without MAC addresses.
-		if i.Flags&net.FlagLoopback != 0 || len(i.HardwareAddr) == 0 {
+		// Skip interfaces without MAC addresses.
+		if len(i.HardwareAddr) == 0 {
+			continue
+		}
+
+		virtual := isVirtual(i)
+		infos = append(infos, InterfaceInfo{Name: i.Name, HardwareAddr: i.HardwareAddr, Virtual: virtual})
+
+		if i.Flags&net.FlagLoopback != 0 {
 			continue
 		}
 
@@ -51,7 +245,13 @@ func collectMACAddresses(filter MACFilter, logger *slog.Logger) ([]string, error
 			continue
 		}
 
-		virtual := isVirtualInterface(i.Name)
+		if len(allow) > 0 && !slices.Contains(allow, i.Name) {
+			if logger != nil {
+				logger.Debug("skipping interface (not allowed)", "interface", i.Name)
+			}
+
+			continue
+		}
 
 		switch filter {
 		case MACFilterPhysical:
@@ -68,6 +268,14 @@ func collectMACAddresses(filter MACFilter, logger *slog.Logger) ([]string, error
 					logger.Debug("skipping physical interface", "interface", i.Name)
 				}
 
+				continue
+			}
+		case MACFilterWired:
+			if virtual || isWireless(i) {
+				if logger != nil {
+					logger.Debug("skipping virtual or wireless interface", "interface", i.Name)
+				}
+
 				continue
 			}
 		case MACFilterAll:
@@ -78,15 +286,48 @@ func collectMACAddresses(filter MACFilter, logger *slog.Logger) ([]string, error
 			logger.Debug("including interface", "interface", i.Name, "mac", i.HardwareAddr.String(), "virtual", virtual)
 		}
 
-		macs = append(macs, i.HardwareAddr.String())
+		infos[len(infos)-1].Included = true
 	}
 
-	return macs, nil
+	return infos, nil
+}
+
+// interfaceList enumerates network interfaces, using the provider's interface
+// lister when one is set (in tests) and [net.Interfaces] otherwise.
+func (p *Provider) interfaceList() ([]net.Interface, error) {
+	if p.listInterfaces != nil {
+		return p.listInterfaces()
+	}
+
+	return net.Interfaces()
+}
+
+// isVirtualNetInterface classifies an interface by name using [isVirtualInterface].
+func isVirtualNetInterface(i net.Interface) bool {
+	return isVirtualInterface(i.Name)
+}
+
+// isWirelessNetInterface classifies an interface as wireless by name using
+// [wirelessInterfacePrefixes].
+func isWirelessNetInterface(i net.Interface) bool {
+	lower := strings.ToLower(i.Name)
+	for _, prefix := range wirelessInterfacePrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // isVirtualInterface reports whether the interface name matches a known
-// virtual, VPN, or bridge prefix.
+// virtual, VPN, or bridge prefix, or on Windows a virtual adapter marker.
 func isVirtualInterface(name string) bool {
+	return isVirtualInterfaceOn(runtime.GOOS, name)
+}
+
+// isVirtualInterfaceOn is [isVirtualInterface] for the given GOOS.
+func isVirtualInterfaceOn(goos, name string) bool {
 	lower := strings.ToLower(name)
 	for _, prefix := range virtualInterfacePrefixes {
 		if strings.HasPrefix(lower, prefix) {
@@ -94,5 +335,13 @@ func isVirtualInterface(name string) bool {
 		}
 	}
 
+	if goos == "windows" {
+		for _, marker := range windowsVirtualInterfaceMarkers {
+			if strings.Contains(lower, marker) {
+				return true
+			}
+		}
+	}
+
 	return false
 }
@@ -1,49 +1,85 @@
 package machineid
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"slices"
 	"testing"
 )
 
-// TestCollectMACAddresses tests network interface MAC address collection with default filter.
-func TestCollectMACAddresses(t *testing.T) {
-	macs, err := collectMACAddresses(MACFilterPhysical, nil)
-	if err != nil {
-		t.Logf("collectMACAddresses error (might be expected in some environments): %v", err)
-	}
-	t.Logf("Found %d physical MAC addresses", len(macs))
+// fakeInterfaces lists a fixed mix of physical, virtual, loopback and down
+// interfaces for deterministic MAC filter tests.
+func fakeInterfaces() ([]net.Interface, error) {
+	return []net.Interface{
+		{Index: 1, Name: "lo", Flags: net.FlagUp | net.FlagLoopback},
+		{Index: 2, Name: "eth0", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x3c, 0x7c, 0x3f, 0x1a, 0x2b, 0x3c}},
+		{Index: 3, Name: "wlan0", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x3c, 0x22, 0xfb, 0x10, 0x20, 0x30}},
+		{Index: 4, Name: "docker0", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x02, 0x42, 0xac, 0x11, 0x00, 0x02}},
+		{Index: 5, Name: "veth1a2b3c", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x4a, 0x1f, 0x2e, 0x3d, 0x4c, 0x5b}},
+		{Index: 6, Name: "eth1", HardwareAddr: net.HardwareAddr{0x3c, 0x7c, 0x3f, 0x1a, 0x2b, 0x3d}},
+		{Index: 7, Name: "utun0", Flags: net.FlagUp},
+	}, nil
 }
 
-// TestCollectMACAddressesAllFilter tests that MACFilterAll returns >= physical count.
-func TestCollectMACAddressesAllFilter(t *testing.T) {
-	physical, err := collectMACAddresses(MACFilterPhysical, nil)
-	if err != nil {
-		t.Logf("physical filter error: %v", err)
+// TestCollectMACAddressesFilters tests that each MAC filter selects the
+// expected addresses of a fixed set of interfaces, never including loopback,
+// down or address-less interfaces.
+func TestCollectMACAddressesFilters(t *testing.T) {
+	tests := []struct {
+		filter MACFilter
+		want   []string
+	}{
+		{MACFilterPhysical, []string{"3c:7c:3f:1a:2b:3c", "3c:22:fb:10:20:30"}},
+		{MACFilterVirtual, []string{"02:42:ac:11:00:02", "4a:1f:2e:3d:4c:5b"}},
+		{MACFilterAll, []string{"3c:7c:3f:1a:2b:3c", "3c:22:fb:10:20:30", "02:42:ac:11:00:02", "4a:1f:2e:3d:4c:5b"}},
+		{MACFilterWired, []string{"3c:7c:3f:1a:2b:3c"}},
 	}
 
-	all, err := collectMACAddresses(MACFilterAll, nil)
-	if err != nil {
-		t.Logf("all filter error: %v", err)
+	for _, tt := range tests {
+		t.Run(tt.filter.String(), func(t *testing.T) {
+			p := New().WithMAC(tt.filter)
+			p.listInterfaces = fakeInterfaces
+			macs, err := p.macAddresses(isVirtualNetInterface, isWirelessNetInterface, nil)
+			if err != nil {
+				t.Fatalf("macAddresses() error = %v", err)
+			}
+			if !slices.Equal(macs, tt.want) {
+				t.Errorf("macAddresses(%s) = %v, want %v", tt.filter, macs, tt.want)
+			}
+		})
 	}
+}
 
-	if len(all) < len(physical) {
-		t.Errorf("MACFilterAll (%d) should return >= MACFilterPhysical (%d)", len(all), len(physical))
-	}
+// TestMACAddressesOptions tests that the allowlist, exclusion and cap combine
+// with the filter on the collection path.
+func TestMACAddressesOptions(t *testing.T) {
+	p := New().WithMAC(MACFilterAll, MACMaxCount(2)).
+		WithMACAllow("eth0", "wlan0", "docker0", "veth1a2b3c").
+		WithMACExclude("02:42")
+	p.listInterfaces = fakeInterfaces
 
-	t.Logf("Physical: %d, All: %d", len(physical), len(all))
+	macs, err := p.macAddresses(isVirtualNetInterface, isWirelessNetInterface, nil)
+	if err != nil {
+		t.Fatalf("macAddresses() error = %v", err)
+	}
+	if want := []string{"3c:7c:3f:1a:2b:3c", "3c:22:fb:10:20:30"}; !slices.Equal(macs, want) {
+		t.Errorf("macAddresses() = %v, want %v", macs, want)
+	}
 }
 
-// TestCollectMACAddressesVirtualFilter tests that virtual filter excludes physical interfaces.
-func TestCollectMACAddressesVirtualFilter(t *testing.T) {
-	physical, _ := collectMACAddresses(MACFilterPhysical, nil)
-	virtual, _ := collectMACAddresses(MACFilterVirtual, nil)
-	all, _ := collectMACAddresses(MACFilterAll, nil)
+// TestCollectMACAddressesListError tests that an enumeration failure is returned.
+func TestCollectMACAddressesListError(t *testing.T) {
+	listErr := errors.New("netlink unavailable")
+	list := func() ([]net.Interface, error) { return nil, listErr }
 
-	// Virtual + physical should equal all (no overlap since classification is binary)
-	if len(virtual)+len(physical) != len(all) {
-		t.Errorf("virtual (%d) + physical (%d) != all (%d)", len(virtual), len(physical), len(all))
+	p := New().WithMAC(MACFilterAll)
+	p.listInterfaces = list
+	if _, err := p.macAddresses(isVirtualNetInterface, isWirelessNetInterface, nil); !errors.Is(err, listErr) {
+		t.Errorf("macAddresses() error = %v, want %v", err, listErr)
 	}
-
-	t.Logf("Physical: %d, Virtual: %d, All: %d", len(physical), len(virtual), len(all))
 }
 
 // TestMACFilterString tests the String() method on MACFilter.
@@ -55,6 +91,7 @@ func TestMACFilterString(t *testing.T) {
 		{MACFilterPhysical, "physical"},
 		{MACFilterAll, "all"},
 		{MACFilterVirtual, "virtual"},
+		{MACFilterWired, "wired"},
 		{MACFilter(99), "physical"}, // unknown defaults to physical
 	}
 
@@ -97,4 +134,283 @@ func TestIsVirtualInterface(t *testing.T) {
 			}
 		})
 	}
+
+	windowsTests := []struct {
+		name     string
+		expected bool
+	}{
+		{"vEthernet (Default Switch)", true},
+		{"vEthernet (WSL)", true},
+		{"Hyper-V Virtual Ethernet Adapter", true},
+		{"VirtualBox Host-Only Network", true},
+		{"VMware Network Adapter VMnet8", true},
+		{"Loopback Pseudo-Interface 1", true},
+		{"Local Area Connection (TAP-Windows Adapter V9)", true},
+		{"Ethernet", false},
+		{"Ethernet 2", false},
+		{"Wi-Fi", false},
+	}
+
+	for _, tt := range windowsTests {
+		t.Run("windows/"+tt.name, func(t *testing.T) {
+			result := isVirtualInterfaceOn("windows", tt.name)
+			if result != tt.expected {
+				t.Errorf("isVirtualInterfaceOn(\"windows\", %q) = %v, want %v", tt.name, result, tt.expected)
+			}
+		})
+	}
+}
+
+// syntheticInterfaces returns count up interfaces named like Kubernetes pod
+// interfaces, with distinct MACs derived from seed.
+func syntheticInterfaces(count, seed int) []net.Interface {
+	interfaces := make([]net.Interface, 0, count)
+	for i := range count {
+		n := seed + i
+		interfaces = append(interfaces, net.Interface{
+			Index:        n + 1,
+			Name:         fmt.Sprintf("cali%08x", n),
+			Flags:        net.FlagUp,
+			HardwareAddr: net.HardwareAddr{0x02, 0x00, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)},
+		})
+	}
+
+	return interfaces
+}
+
+// TestMACMaxCount tests that the MAC cap yields the smallest addresses and
+// stays stable as ephemeral interfaces churn.
+func TestMACMaxCount(t *testing.T) {
+	stable := syntheticInterfaces(10, 0)
+	churn := syntheticInterfaces(490, 1000)
+
+	p := New().WithMAC(MACFilterAll, MACMaxCount(8))
+	p.listInterfaces = func() ([]net.Interface, error) {
+		return append(slices.Clone(churn), stable...), nil
+	}
+
+	first, err := p.macAddresses(isVirtualNetInterface, isWirelessNetInterface, nil)
+	if err != nil {
+		t.Fatalf("macAddresses() error = %v", err)
+	}
+	if len(first) != 8 {
+		t.Fatalf("macAddresses() returned %d MACs, want 8", len(first))
+	}
+	for i, mac := range first {
+		if want := stable[i].HardwareAddr.String(); mac != want {
+			t.Errorf("macAddresses()[%d] = %s, want %s", i, mac, want)
+		}
+	}
+
+	// Replace the ephemeral interfaces and reverse enumeration order.
+	churn = syntheticInterfaces(490, 5000)
+	slices.Reverse(churn)
+	second, err := p.macAddresses(isVirtualNetInterface, isWirelessNetInterface, nil)
+	if err != nil {
+		t.Fatalf("macAddresses() error = %v", err)
+	}
+	if !slices.Equal(first, second) {
+		t.Errorf("capped MACs changed with interface churn: %v vs %v", first, second)
+	}
+
+	uncapped := New().WithMAC(MACFilterAll)
+	uncapped.listInterfaces = p.listInterfaces
+	all, err := uncapped.macAddresses(isVirtualNetInterface, isWirelessNetInterface, nil)
+	if err != nil {
+		t.Fatalf("macAddresses() error = %v", err)
+	}
+	if len(all) != 500 {
+		t.Errorf("uncapped macAddresses() returned %d MACs, want 500", len(all))
+	}
+}
+
+// TestMACFilterWired tests that the wired filter drops virtual interfaces and
+// those the wireless classifier reports, whatever their names.
+func TestMACFilterWired(t *testing.T) {
+	interfaces := []net.Interface{
+		{Index: 1, Name: "en0", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0xa4, 0x83, 0xe7, 0, 0, 1}},
+		{Index: 2, Name: "en5", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x00, 0x1b, 0x21, 0, 0, 2}},
+		{Index: 3, Name: "wlp2s0", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x3c, 0x22, 0xfb, 0, 0, 3}},
+		{Index: 4, Name: "docker0", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x02, 0x42, 0xac, 0, 0, 4}},
+	}
+	list := func() ([]net.Interface, error) { return interfaces, nil }
+	isWireless := func(i net.Interface) bool { return i.Name == "en0" || isWirelessNetInterface(i) }
+
+	p := New().WithMAC(MACFilterWired)
+	p.listInterfaces = list
+	wired, err := p.macAddresses(isVirtualNetInterface, isWireless, nil)
+	if err != nil {
+		t.Fatalf("macAddresses() error = %v", err)
+	}
+	if want := []string{"00:1b:21:00:00:02"}; !slices.Equal(wired, want) {
+		t.Errorf("wired MACs = %v, want %v", wired, want)
+	}
+
+	p = New().WithMAC(MACFilterPhysical)
+	p.listInterfaces = list
+	physical, _ := p.macAddresses(isVirtualNetInterface, isWireless, nil)
+	if len(physical) != 3 {
+		t.Errorf("physical MACs = %v, want the wireless interfaces too", physical)
+	}
+}
+
+// TestNetworkInterfaces tests that the reported interfaces mark exactly the
+// MAC addresses that the ID uses under the active filter and cap.
+func TestNetworkInterfaces(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setError("powershell", errors.New("powershell not available"))
+	p := New().WithExecutor(mock).WithMAC(MACFilterPhysical, MACMaxCount(2))
+	p.listInterfaces = func() ([]net.Interface, error) {
+		return []net.Interface{
+			{Index: 1, Name: "lo", Flags: net.FlagUp | net.FlagLoopback},
+			{Index: 2, Name: "eth1", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x3c, 0x7c, 0x3f, 0, 0, 3}},
+			{Index: 3, Name: "docker0", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x02, 0x42, 0xac, 0, 0, 1}},
+			{Index: 4, Name: "eth0", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x3c, 0x7c, 0x3f, 0, 0, 2}},
+			{Index: 5, Name: "eth2", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x3c, 0x7c, 0x3f, 0, 0, 1}},
+			{Index: 6, Name: "eth3", HardwareAddr: net.HardwareAddr{0x3c, 0x7c, 0x3f, 0, 0, 0}},
+		}, nil
+	}
+
+	infos, err := p.NetworkInterfaces(context.Background())
+	if err != nil {
+		t.Fatalf("NetworkInterfaces() error = %v", err)
+	}
+
+	want := []InterfaceInfo{
+		{Name: "eth1", HardwareAddr: net.HardwareAddr{0x3c, 0x7c, 0x3f, 0, 0, 3}},
+		{Name: "docker0", HardwareAddr: net.HardwareAddr{0x02, 0x42, 0xac, 0, 0, 1}, Virtual: true},
+		{Name: "eth0", HardwareAddr: net.HardwareAddr{0x3c, 0x7c, 0x3f, 0, 0, 2}, Included: true},
+		{Name: "eth2", HardwareAddr: net.HardwareAddr{0x3c, 0x7c, 0x3f, 0, 0, 1}, Included: true},
+		{Name: "eth3", HardwareAddr: net.HardwareAddr{0x3c, 0x7c, 0x3f, 0, 0, 0}},
+	}
+	if !reflect.DeepEqual(infos, want) {
+		t.Errorf("NetworkInterfaces() = %+v, want %+v", infos, want)
+	}
+	if p.Diagnostics() != nil {
+		t.Error("NetworkInterfaces() should not generate an ID")
+	}
+
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	included := slices.Sorted(slices.Values(includedMACs(infos)))
+	if got := p.componentValues[ComponentMAC]; !slices.Equal(got, included) {
+		t.Errorf("collected MACs = %v, want the included interfaces %v", got, included)
+	}
+}
+
+// TestWithMACExclude tests that hypervisor OUIs are dropped after the
+// physical filter, with prefixes in any case and separator style.
+func TestWithMACExclude(t *testing.T) {
+	interfaces := func() ([]net.Interface, error) {
+		return []net.Interface{
+			{Index: 1, Name: "eth0", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x00, 0x50, 0x56, 0xa1, 0xb2, 0xc3}},
+			{Index: 2, Name: "eth1", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x00, 0x15, 0x5d, 0x01, 0x02, 0x03}},
+			{Index: 3, Name: "eth2", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x3c, 0x7c, 0x3f, 0x1a, 0x2b, 0x3c}},
+			{Index: 4, Name: "docker0", Flags: net.FlagUp, HardwareAddr: net.HardwareAddr{0x02, 0x42, 0xac, 0x11, 0x00, 0x02}},
+		}, nil
+	}
+
+	tests := []struct {
+		name     string
+		prefixes []string
+		want     []string
+	}{
+		{"none", nil, []string{"00:15:5d:01:02:03", "00:50:56:a1:b2:c3", "3c:7c:3f:1a:2b:3c"}},
+		{"colon", []string{"00:50:56", "00:15:5D"}, []string{"3c:7c:3f:1a:2b:3c"}},
+		{"bare uppercase", []string{"00155D"}, []string{"00:50:56:a1:b2:c3", "3c:7c:3f:1a:2b:3c"}},
+		{"dashes", []string{"00-50-56"}, []string{"00:15:5d:01:02:03", "3c:7c:3f:1a:2b:3c"}},
+		{"virtual only", []string{"02:42"}, []string{"00:15:5d:01:02:03", "00:50:56:a1:b2:c3", "3c:7c:3f:1a:2b:3c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New().WithMAC().WithMACExclude(tt.prefixes...)
+			p.listInterfaces = interfaces
+
+			macs, err := p.macAddresses(isVirtualNetInterface, isWirelessNetInterface, nil)
+			if err != nil {
+				t.Fatalf("macAddresses() error = %v", err)
+			}
+			if got := slices.Sorted(slices.Values(macs)); !slices.Equal(got, tt.want) {
+				t.Errorf("macAddresses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	capped := New().WithMAC(MACMaxCount(1)).WithMACExclude("00:50:56", "00:15:5d")
+	capped.listInterfaces = interfaces
+	macs, _ := capped.macAddresses(isVirtualNetInterface, isWirelessNetInterface, nil)
+	if want := []string{"3c:7c:3f:1a:2b:3c"}; !slices.Equal(macs, want) {
+		t.Errorf("capped macAddresses() = %v, want %v; exclusion must precede the cap", macs, want)
+	}
+}
+
+// TestWithMACAllow tests that the allowlist keeps only the named interfaces
+// that pass the loopback and up checks and the MAC filter.
+func TestWithMACAllow(t *testing.T) {
+	tests := []struct {
+		name   string
+		allow  []string
+		filter MACFilter
+		want   []string
+	}{
+		{"none", nil, MACFilterPhysical, []string{"3c:22:fb:10:20:30", "3c:7c:3f:1a:2b:3c"}},
+		{"one", []string{"eth0"}, MACFilterPhysical, []string{"3c:7c:3f:1a:2b:3c"}},
+		{"exact name", []string{"eth"}, MACFilterPhysical, nil},
+		{"down", []string{"eth1"}, MACFilterPhysical, nil},
+		{"loopback", []string{"lo"}, MACFilterAll, nil},
+		{"virtual filtered", []string{"docker0"}, MACFilterPhysical, nil},
+		{"virtual with all", []string{"docker0"}, MACFilterAll, []string{"02:42:ac:11:00:02"}},
+		{"wired", []string{"eth0", "wlan0"}, MACFilterWired, []string{"3c:7c:3f:1a:2b:3c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New().WithMAC(tt.filter).WithMACAllow(tt.allow...)
+			p.listInterfaces = fakeInterfaces
+
+			macs, err := p.macAddresses(isVirtualNetInterface, isWirelessNetInterface, nil)
+			if err != nil {
+				t.Fatalf("macAddresses() error = %v", err)
+			}
+			if got := slices.Sorted(slices.Values(macs)); !slices.Equal(got, tt.want) {
+				t.Errorf("macAddresses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	excluded := New().WithMAC().WithMACAllow("eth0", "wlan0").WithMACExclude("3c:7c")
+	excluded.listInterfaces = fakeInterfaces
+	macs, _ := excluded.macAddresses(isVirtualNetInterface, isWirelessNetInterface, nil)
+	if want := []string{"3c:22:fb:10:20:30"}; !slices.Equal(macs, want) {
+		t.Errorf("macAddresses() = %v, want %v; exclusion must apply to allowed interfaces", macs, want)
+	}
+
+	if New().WithMACAllow("eno1").configHash() == New().configHash() {
+		t.Error("configHash() should change with a MAC allowlist")
+	}
+}
+
+// TestIsWirelessNetInterface tests the name-based wireless classification.
+func TestIsWirelessNetInterface(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"wlan0", true},
+		{"wlp2s0", true},
+		{"Wi-Fi", true},
+		{"Wireless Network Connection", true},
+		{"eth0", false},
+		{"enp3s0", false},
+		{"en0", false},
+		{"Ethernet", false},
+	}
+
+	for _, tt := range tests {
+		if got := isWirelessNetInterface(net.Interface{Name: tt.name}); got != tt.expected {
+			t.Errorf("isWirelessNetInterface(%q) = %v, want %v", tt.name, got, tt.expected)
+		}
+	}
 }
@@ -0,0 +1,190 @@
+package machineid
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxParallelComponents bounds the number of components collected at once by
+// [Provider.WithParallelCollection], unless [Provider.WithProbeConcurrency] sets
+// another limit.
+const maxParallelComponents = 4
+
+// WithParallelCollection collects the enabled components concurrently, at most
+// four at a time or as set by [Provider.WithProbeConcurrency], instead of one
+// after another. On Windows, where every
+// component starts wmic or PowerShell, this cuts startup latency to roughly
+// that of the slowest component.
+//
+// Only the hardware probes run concurrently: their results are then processed
+// in the same order as in a sequential collection, so IDs, diagnostics,
+// duplicate detection and [Provider.IDStream] events are unchanged. Component
+// durations are still measured per probe. A custom [CommandExecutor] must be
+// safe for concurrent use. [Provider.WithProfile] keeps collection sequential,
+// so that command spans can be attributed to their components.
+func (p *Provider) WithParallelCollection() *Provider {
+	p.parallel = true
+
+	return p
+}
+
+// WithProbeConcurrency collects the enabled components concurrently, like
+// [Provider.WithParallelCollection], with at most n component probes running
+// at once. Results are still processed in canonical component order, so the
+// ID, diagnostics and events are the same for every n. A value of n <= 0
+// restores the default limit of four.
+func (p *Provider) WithProbeConcurrency(n int) *Provider {
+	p.parallel = true
+	p.probeConcurrency = n
+
+	return p
+}
+
+// parallelLimit returns the number of component probes run at once.
+func (p *Provider) parallelLimit() int {
+	if p.probeConcurrency > 0 {
+		return p.probeConcurrency
+	}
+
+	return maxParallelComponents
+}
+
+// prefetchResult is the outcome of a component probe run ahead of processing.
+type prefetchResult struct {
+	value   any
+	err     error
+	elapsed time.Duration
+}
+
+// prefetchGroup runs component probes concurrently, bounded by a semaphore.
+type prefetchGroup struct {
+	wg      sync.WaitGroup
+	sem     chan struct{}
+	mu      sync.Mutex
+	results map[string]prefetchResult
+}
+
+// start runs fetch for component in a new goroutine once a slot is free.
+func (g *prefetchGroup) start(component string, fetch func() (any, error)) {
+	g.wg.Go(func() {
+		g.sem <- struct{}{}
+		defer func() { <-g.sem }()
+
+		start := time.Now()
+		value, err := fetch()
+
+		g.mu.Lock()
+		g.results[component] = prefetchResult{value: value, err: err, elapsed: time.Since(start)}
+		g.mu.Unlock()
+	})
+}
+
+// runCollector runs collector. With [Provider.WithParallelCollection], it
+// first runs collector in prefetch mode, where every component probe starts
+// concurrently and nothing is recorded, then again to process the prefetched
+// results in order. The caller must hold p.mu.
+func (p *Provider) runCollector(ctx context.Context, diag *DiagnosticInfo, collector collectorFunc) ([]string, error) {
+	if !p.parallel || p.profile {
+		return collector(ctx, p, diag)
+	}
+
+	group := &prefetchGroup{
+		sem:     make(chan struct{}, p.parallelLimit()),
+		results: make(map[string]prefetchResult),
+	}
+	p.prefetch = group
+	diag.probeMu = &sync.Mutex{}
+	_, err := collector(ctx, p, diag)
+	p.prefetch = nil
+	group.wg.Wait()
+	diag.probeMu = nil
+	if err != nil {
+		return nil, err
+	}
+
+	p.prefetched = group.results
+	defer func() { p.prefetched = nil }()
+
+	return collector(ctx, p, diag)
+}
+
+// prefetchComponent starts the probe of component in prefetch mode and
+// reports whether prefetch mode is active, in which case the caller must not
+// process the component yet.
+func (p *Provider) prefetchComponent(ctx context.Context, component string, fetch func() (any, error)) bool {
+	if p.prefetch == nil {
+		return false
+	}
+
+	if ctx.Err() == nil && !p.isSkipped(component) {
+		p.prefetch.start(component, fetch)
+	}
+
+	return true
+}
+
+// componentStart returns the time collection of component began: now, or for
+// a prefetched component, as long before now as its probe took.
+func (p *Provider) componentStart(component string) time.Time {
+	if result, ok := p.prefetched[component]; ok {
+		return time.Now().Add(-result.elapsed)
+	}
+
+	return time.Now()
+}
+
+// fetchValue runs get for component under the component's context, or returns
+// its prefetched result. Errors caused by the component's own deadline wrap
+// [ErrComponentTimeout].
+func fetchValue[T any](ctx context.Context, p *Provider, component string, get func(context.Context) (T, error)) (T, error) {
+	if result, ok := p.prefetched[component]; ok {
+		value, _ := result.value.(T)
+
+		return value, result.err
+	}
+
+	componentCtx, cancel := p.componentContext(ctx, component)
+	defer cancel()
+
+	value, err := get(componentCtx)
+	if err != nil {
+		err = componentTimeoutError(ctx, componentCtx, err)
+	}
+
+	return value, err
+}
+
+// lockProbes locks diag against concurrent component probes, if they are
+// running, and returns the matching unlock function.
+func (d *DiagnosticInfo) lockProbes() func() {
+	if d.probeMu == nil {
+		return func() {}
+	}
+
+	d.probeMu.Lock()
+
+	return d.probeMu.Unlock
+}
+
+// addNote appends note to diag.Notes from inside a component probe.
+func addNote(diag *DiagnosticInfo, note string) {
+	if diag == nil {
+		return
+	}
+
+	defer diag.lockProbes()()
+
+	diag.Notes = append(diag.Notes, note)
+}
+
+// setUUIDSource records the UUID source chosen by a component probe.
+func setUUIDSource(diag *DiagnosticInfo, source string) {
+	if diag == nil {
+		return
+	}
+
+	defer diag.lockProbes()()
+
+	diag.UUIDSource = source
+}
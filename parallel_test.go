@@ -0,0 +1,174 @@
+package machineid
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// delayedExecutor is a mock executor safe for concurrent use that takes delay
+// to run each command.
+type delayedExecutor struct {
+	mu    sync.Mutex
+	mock  *mockExecutor
+	delay time.Duration
+}
+
+// Execute waits for delay, then runs the command on the mock executor.
+func (e *delayedExecutor) Execute(ctx context.Context, name string, args ...string) (string, error) {
+	time.Sleep(e.delay)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.mock.Execute(ctx, name, args...)
+}
+
+// TestParallelCollectionMatchesSerial tests that parallel collection yields
+// the identifiers and diagnostics of a serial collection, running each
+// command once, in less time.
+func TestParallelCollectionMatchesSerial(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	freebsd := func(ctx context.Context, p *Provider, diag *DiagnosticInfo) ([]string, error) {
+		return collectIdentifiersFor(ctx, "freebsd", p, diag)
+	}
+
+	run := func(parallel bool) ([]string, *DiagnosticInfo, *mockExecutor, time.Duration) {
+		t.Helper()
+		mock := newFreeBSDMock()
+		p := New().WithExecutor(&delayedExecutor{mock: mock, delay: delay}).
+			WithCPU().WithSystemUUID().WithMotherboard().WithDisk()
+		if parallel {
+			p.WithParallelCollection()
+		}
+		p.rootFS = fstest.MapFS{
+			"etc/hostid": {Data: []byte("8d3c2a1b-5e4f-11ee-9a8b-0cc47a123456\n")},
+		}
+		diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+		start := time.Now()
+		identifiers, err := p.runCollector(context.Background(), diag, freebsd)
+		if err != nil {
+			t.Fatalf("runCollector(parallel=%v) error = %v", parallel, err)
+		}
+
+		return identifiers, diag, mock, time.Since(start)
+	}
+
+	wantIDs, wantDiag, wantMock, serial := run(false)
+	gotIDs, gotDiag, gotMock, parallel := run(true)
+
+	if !slices.Equal(gotIDs, wantIDs) {
+		t.Errorf("parallel identifiers = %v, want %v", gotIDs, wantIDs)
+	}
+	if !slices.Equal(gotDiag.Collected, wantDiag.Collected) {
+		t.Errorf("parallel Collected = %v, want %v", gotDiag.Collected, wantDiag.Collected)
+	}
+	if len(gotDiag.Errors) != len(wantDiag.Errors) {
+		t.Errorf("parallel Errors = %v, want %v", gotDiag.Errors, wantDiag.Errors)
+	}
+	for name, count := range wantMock.callCount {
+		if gotMock.callCount[name] != count {
+			t.Errorf("parallel callCount[%s] = %d, want %d", name, gotMock.callCount[name], count)
+		}
+	}
+	if parallel >= serial {
+		t.Errorf("parallel collection took %v, serial %v", parallel, serial)
+	}
+}
+
+// concurrencyExecutor is a delayedExecutor that records the largest number of
+// commands running at once.
+type concurrencyExecutor struct {
+	delayedExecutor
+	running, peak atomic.Int32
+}
+
+// Execute tracks the running commands around the delayed execution.
+func (e *concurrencyExecutor) Execute(ctx context.Context, name string, args ...string) (string, error) {
+	running := e.running.Add(1)
+	defer e.running.Add(-1)
+	for peak := e.peak.Load(); running > peak && !e.peak.CompareAndSwap(peak, running); peak = e.peak.Load() {
+	}
+
+	return e.delayedExecutor.Execute(ctx, name, args...)
+}
+
+// TestWithProbeConcurrency tests that probes stay within the limit and that
+// the results are complete and in canonical order for every limit.
+func TestWithProbeConcurrency(t *testing.T) {
+	freebsd := func(ctx context.Context, p *Provider, diag *DiagnosticInfo) ([]string, error) {
+		return collectIdentifiersFor(ctx, "freebsd", p, diag)
+	}
+	run := func(n int) ([]string, *DiagnosticInfo, int32) {
+		t.Helper()
+		executor := &concurrencyExecutor{delayedExecutor: delayedExecutor{mock: newFreeBSDMock(), delay: 20 * time.Millisecond}}
+		p := New().WithExecutor(executor).WithCPU().WithSystemUUID().WithMotherboard().WithDisk()
+		if n != 0 {
+			p.WithProbeConcurrency(n)
+		}
+		p.rootFS = fstest.MapFS{}
+		diag := &DiagnosticInfo{Errors: make(map[string]error)}
+
+		identifiers, err := p.runCollector(context.Background(), diag, freebsd)
+		if err != nil {
+			t.Fatalf("runCollector(n=%d) error = %v", n, err)
+		}
+
+		return identifiers, diag, executor.peak.Load()
+	}
+
+	wantIDs, wantDiag, _ := run(0)
+	for _, n := range []int{1, 2, 8} {
+		gotIDs, gotDiag, peak := run(n)
+		if !slices.Equal(gotIDs, wantIDs) {
+			t.Errorf("identifiers with concurrency %d = %v, want %v", n, gotIDs, wantIDs)
+		}
+		if !slices.Equal(gotDiag.Collected, wantDiag.Collected) {
+			t.Errorf("Collected with concurrency %d = %v, want %v", n, gotDiag.Collected, wantDiag.Collected)
+		}
+		if peak > int32(n) {
+			t.Errorf("%d commands ran at once, want at most %d", peak, n)
+		}
+	}
+
+	if New().WithProbeConcurrency(3).Clone().parallelLimit() != 3 {
+		t.Error("Clone() should copy the probe concurrency")
+	}
+	if New().WithProbeConcurrency(0).parallelLimit() != maxParallelComponents {
+		t.Error("a non-positive concurrency should restore the default limit")
+	}
+}
+
+// TestWithParallelCollectionID tests that the ID of the host does not depend
+// on whether components are collected in parallel.
+func TestWithParallelCollectionID(t *testing.T) {
+	ctx := context.Background()
+	newProvider := func() *Provider {
+		p := New().WithCPU().WithSystemUUID().WithMotherboard().WithMAC()
+		p.listInterfaces = physicalOnlyInterfaces
+
+		return p
+	}
+
+	want, err := newProvider().ID(ctx)
+	if err != nil {
+		t.Skipf("ID() error = %v", err)
+	}
+
+	p := newProvider().WithParallelCollection()
+	got, err := p.ID(ctx)
+	if err != nil {
+		t.Fatalf("parallel ID() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("parallel ID() = %q, want %q", got, want)
+	}
+	if p.prefetched != nil || p.prefetch != nil {
+		t.Error("prefetch state should be cleared after collection")
+	}
+}
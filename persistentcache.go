@@ -0,0 +1,128 @@
+package machineid
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// persistentCacheVersion is the format version written to the header of a
+// [Provider.WithPersistentCache] file. Files with another version are ignored.
+const persistentCacheVersion = 1
+
+// persistentCacheFile is the document stored by [Provider.WithPersistentCache].
+type persistentCacheFile struct {
+	Version     int      `json:"version"`
+	Config      string   `json:"config"`
+	Identifiers []string `json:"identifiers"`
+}
+
+// WithPersistentCache stores the raw identifiers of each successful collection
+// in the file at path, and falls back to them when a later collection yields
+// no identifiers at all, for example on embedded devices that boot before
+// udev or DMI is populated. The fallback is recorded in
+// [DiagnosticInfo.Notes]; [ErrNoIdentifiers] is only returned if the file is
+// missing or unusable.
+//
+// Unlike [Provider.WithComponentCache], which substitutes single failed
+// components, the whole identifier set is replaced. The file is rewritten
+// atomically, with mode 0600, only when the identifiers change. It carries a
+// format version and a digest of the configuration, so identifiers collected
+// under a different configuration are never reused.
+func (p *Provider) WithPersistentCache(path string) *Provider {
+	p.persistentCachePath = path
+
+	return p
+}
+
+// storePersistentIdentifiers saves identifiers to the persistent cache, if
+// enabled and changed. They are compared and stored as a sorted set, so a
+// change in enumeration order alone does not rewrite the file. Failures are
+// logged and otherwise ignored. The caller must hold p.mu.
+func (p *Provider) storePersistentIdentifiers(identifiers []string) {
+	if p.persistentCachePath == "" {
+		return
+	}
+
+	identifiers = slices.Sorted(slices.Values(identifiers))
+	if cached, ok := p.readPersistentCache(); ok && slices.Equal(slices.Sorted(slices.Values(cached)), identifiers) {
+		return
+	}
+
+	data, err := json.Marshal(persistentCacheFile{
+		Version:     persistentCacheVersion,
+		Config:      p.configHash(),
+		Identifiers: identifiers,
+	})
+	if err == nil {
+		err = writeFileAtomic(p.persistentCachePath, append(data, '\n'))
+	}
+	if err != nil {
+		p.logDebug("failed to write persistent cache", "path", p.persistentCachePath, "error", err)
+	}
+}
+
+// loadPersistentIdentifiers returns the identifiers of the persistent cache,
+// recording the fallback in diag. It reports false if the cache is disabled or
+// holds no usable entry. The caller must hold p.mu.
+func (p *Provider) loadPersistentIdentifiers(diag *DiagnosticInfo) ([]string, bool) {
+	if p.persistentCachePath == "" {
+		return nil, false
+	}
+
+	identifiers, ok := p.readPersistentCache()
+	if !ok {
+		return nil, false
+	}
+
+	p.logInfo("using persistent cache after collection failure", "path", p.persistentCachePath)
+	diag.Notes = append(diag.Notes, "no identifiers collected, using persistent cache")
+
+	return identifiers, true
+}
+
+// readPersistentCache reads the persistent cache, reporting false if it is
+// missing, malformed, of another version, or written under another configuration.
+func (p *Provider) readPersistentCache() ([]string, bool) {
+	data, err := os.ReadFile(p.persistentCachePath)
+	if err != nil {
+		return nil, false
+	}
+
+	var file persistentCacheFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Version != persistentCacheVersion ||
+		file.Config != p.configHash() || len(file.Identifiers) == 0 {
+		p.logDebug("ignoring unusable persistent cache", "path", p.persistentCachePath, "error", err)
+
+		return nil, false
+	}
+
+	return file.Identifiers, true
+}
+
+// writeFileAtomic writes data to a temporary file with mode 0600 next to path
+// and renames it over path, so readers never observe a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
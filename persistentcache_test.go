@@ -0,0 +1,124 @@
+package machineid
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// failingInterfaces simulates a network stack that is not up yet.
+func failingInterfaces() ([]net.Interface, error) {
+	return nil, errors.New("netlink unavailable")
+}
+
+// TestWithPersistentCacheFallback tests that a collection yielding no
+// identifiers after a successful prime falls back to the cached identifiers.
+func TestWithPersistentCacheFallback(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "machineid.json")
+
+	primed := New().WithMAC().WithPersistentCache(path)
+	primed.listInterfaces = physicalOnlyInterfaces
+	want, err := primed.ID(ctx)
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("persistent cache not written: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("cache mode = %v, want 0600", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), `{"version":1,`) {
+		t.Errorf("cache = %s, want a version header", data)
+	}
+
+	booting := New().WithMAC().WithPersistentCache(path)
+	booting.listInterfaces = failingInterfaces
+	got, err := booting.ID(ctx)
+	if err != nil {
+		t.Fatalf("ID() after failed collection error = %v", err)
+	}
+	if got != want {
+		t.Errorf("ID() = %q, want cached %q", got, want)
+	}
+	if !slices.Contains(booting.Diagnostics().Notes, "no identifiers collected, using persistent cache") {
+		t.Errorf("Notes = %v, want persistent cache note", booting.Diagnostics().Notes)
+	}
+	if booting.Diagnostics().Errors[ComponentMAC] == nil {
+		t.Error("MAC error should still be reported")
+	}
+}
+
+// TestWithPersistentCacheUnusable tests that ErrNoIdentifiers is returned
+// when the cache is missing, of another version, or written under another
+// configuration.
+func TestWithPersistentCacheUnusable(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	primedPath := filepath.Join(dir, "primed.json")
+
+	primed := New().WithMAC().WithPersistentCache(primedPath)
+	primed.listInterfaces = physicalOnlyInterfaces
+	if _, err := primed.ID(ctx); err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+
+	otherVersion := filepath.Join(dir, "v0.json")
+	if err := os.WriteFile(otherVersion, []byte(`{"version":0,"identifiers":["mac:3c:7c:3f:1a:2b:3c"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		p    *Provider
+	}{
+		{"missing", New().WithMAC().WithPersistentCache(filepath.Join(dir, "missing.json"))},
+		{"other version", New().WithMAC().WithPersistentCache(otherVersion)},
+		{"other configuration", New().WithMAC().WithCanonicalDiskSerials().WithPersistentCache(primedPath)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.p.listInterfaces = failingInterfaces
+			if _, err := tt.p.ID(ctx); !errors.Is(err, ErrNoIdentifiers) {
+				t.Errorf("ID() error = %v, want ErrNoIdentifiers", err)
+			}
+		})
+	}
+}
+
+// TestWithPersistentCacheOrder tests that identifiers enumerated in another
+// order do not rewrite the cache.
+func TestWithPersistentCacheOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "machineid.json")
+	p := New().WithDisk().WithPersistentCache(path)
+
+	p.storePersistentIdentifiers([]string{"disk:B", "disk:A"})
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("persistent cache not written: %v", err)
+	}
+
+	p.storePersistentIdentifiers([]string{"disk:A", "disk:B"})
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("persistent cache missing: %v", err)
+	}
+	if !os.SameFile(before, after) {
+		t.Error("persistent cache rewritten for reordered identifiers")
+	}
+
+	p.storePersistentIdentifiers([]string{"disk:A", "disk:C"})
+	if changed, _ := os.Stat(path); os.SameFile(before, changed) {
+		t.Error("persistent cache not rewritten for changed identifiers")
+	}
+}
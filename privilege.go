@@ -0,0 +1,73 @@
+package machineid
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// WithUnprivilegedOnly disables the enabled components that cannot be
+// collected without elevated privileges on the current machine, as reported
+// by [Provider.UnprivilegedComponents]. Desktop applications can then use a
+// perfectly good unprivileged fingerprint instead of prompting for admin
+// rights. Each disabled component is recorded in [DiagnosticInfo].Notes.
+func (p *Provider) WithUnprivilegedOnly() *Provider {
+	p.unprivilegedOnly = true
+
+	return p
+}
+
+// UnprivilegedComponents reports which of the enabled components can be
+// collected without elevated privileges on the current machine:
+//
+//   - on Linux, it checks that the files backing each component are readable,
+//     for example the root-only DMI product_uuid and board_serial;
+//   - on macOS, every component is collected through unprivileged tools;
+//   - on Windows, every component except the TPM, whose cmdlets need
+//     administrator rights;
+//   - on FreeBSD, every component except the disk for non-root users, since
+//     camcontrol needs the CAM pass-through devices.
+//
+// The probe does not collect any values, so a listed component may still fail
+// for other reasons.
+func (p *Provider) UnprivilegedComponents(ctx context.Context) []string {
+	var components []string
+	for _, component := range p.enabledComponents() {
+		if ctx.Err() != nil {
+			break
+		}
+		if canCollectUnprivileged(p, component) {
+			components = append(components, component)
+		}
+	}
+
+	return components
+}
+
+// filesystem returns the file system privilege probes read from.
+func (p *Provider) filesystem() fs.FS {
+	if p.rootFS != nil {
+		return p.rootFS
+	}
+
+	return os.DirFS("/")
+}
+
+// canReadAny reports whether any of the absolute paths can be opened.
+func (p *Provider) canReadAny(paths ...string) bool {
+	fsys := p.filesystem()
+	for _, path := range paths {
+		f, err := fsys.Open(strings.TrimPrefix(path, "/"))
+		if err != nil {
+			p.logDebug("privilege probe cannot read file", "path", path, "error", err)
+
+			continue
+		}
+		_ = f.Close()
+
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,88 @@
+package machineid
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// spanCollect is the [Span] method of a span covering a whole component.
+const spanCollect = "collect"
+
+// Span is one step of a collection timeline recorded by [Provider.WithProfile].
+type Span struct {
+	Component string    // Component being collected
+	Method    string    // "collect" for the whole component, or the command run for it
+	Start     time.Time // When the step began
+	End       time.Time // When the step ended
+}
+
+// Duration returns the length of the span.
+func (s Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// WithProfile records a timeline of the last collection, retrievable with
+// [Provider.Profile]. Each component contributes a "collect" span, and every
+// command run for it, including fallback attempts after a failed primary
+// method, contributes a nested span named after the command. Profiling is off
+// by default and costs nothing when disabled.
+func (p *Provider) WithProfile() *Provider {
+	p.profile = true
+
+	return p
+}
+
+// Profile returns the spans recorded by the last collection, ordered by start
+// time, or nil if profiling is disabled or no collection has run yet.
+func (p *Provider) Profile() []Span {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.spans)
+}
+
+// startProfile resets the timeline and routes commands through a recording
+// executor until the returned function is called. The caller must hold p.mu.
+func (p *Provider) startProfile() func() {
+	if !p.profile {
+		return func() {}
+	}
+
+	p.spans = nil
+	executor := p.commandExecutor
+	p.commandExecutor = &profilingExecutor{CommandExecutor: executor, p: p}
+
+	return func() {
+		p.commandExecutor = executor
+		// A component span encloses its command spans, so it sorts first on ties.
+		slices.SortStableFunc(p.spans, func(a, b Span) int {
+			if c := a.Start.Compare(b.Start); c != 0 {
+				return c
+			}
+
+			return b.End.Compare(a.End)
+		})
+	}
+}
+
+// recordSpan records a span of method for component from start until now.
+func (p *Provider) recordSpan(component, method string, start time.Time) {
+	if p.profile {
+		p.spans = append(p.spans, Span{Component: component, Method: method, Start: start, End: time.Now()})
+	}
+}
+
+// profilingExecutor records a span for every command it runs, attributed to
+// the component being collected.
+type profilingExecutor struct {
+	CommandExecutor
+	p *Provider
+}
+
+// Execute implements [CommandExecutor].
+func (e *profilingExecutor) Execute(ctx context.Context, name string, args ...string) (string, error) {
+	defer e.p.recordSpan(e.p.profiledComponent, name, time.Now())
+
+	return e.CommandExecutor.Execute(ctx, name, args...)
+}
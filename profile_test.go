@@ -0,0 +1,79 @@
+package machineid
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestWithProfile tests that a two-component collection records a component
+// span enclosing each command span, in start order.
+func TestWithProfile(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setError("ioreg", errors.New("ioreg failed"))
+	mock.setOutput("system_profiler", "C02TEST123")
+
+	p := New().WithExecutor(mock).WithProfile()
+	ctx := context.Background()
+
+	stop := p.startProfile()
+	identifiers := p.appendIdentifier(ctx, nil, func(ctx context.Context) (string, error) {
+		// Primary method fails, fallback succeeds.
+		if _, err := executeCommand(ctx, p.commandExecutor, nil, "ioreg", "-l"); err == nil {
+			t.Error("ioreg should fail")
+		}
+
+		return executeCommand(ctx, p.commandExecutor, nil, "system_profiler", "SPHardwareDataType")
+	}, "mb:", nil, ComponentMotherboard)
+	identifiers = p.appendIdentifier(ctx, identifiers, func(context.Context) (string, error) {
+		return "GenuineIntel", nil
+	}, "cpu:", nil, ComponentCPU)
+	stop()
+
+	if len(identifiers) != 2 {
+		t.Fatalf("identifiers = %v, want 2", identifiers)
+	}
+	if p.commandExecutor != mock {
+		t.Error("executor not restored after profiling")
+	}
+
+	spans := p.Profile()
+	want := []struct{ component, method string }{
+		{ComponentMotherboard, "collect"},
+		{ComponentMotherboard, "ioreg"},
+		{ComponentMotherboard, "system_profiler"},
+		{ComponentCPU, "collect"},
+	}
+	if len(spans) != len(want) {
+		t.Fatalf("Profile() = %+v, want %d spans", spans, len(want))
+	}
+	for i, w := range want {
+		if spans[i].Component != w.component || spans[i].Method != w.method {
+			t.Errorf("span %d = %s/%s, want %s/%s", i, spans[i].Component, spans[i].Method, w.component, w.method)
+		}
+		if spans[i].End.Before(spans[i].Start) {
+			t.Errorf("span %d ends before it starts", i)
+		}
+	}
+
+	board := spans[0]
+	for _, span := range spans[1:3] {
+		if span.Start.Before(board.Start) || span.End.After(board.End) {
+			t.Errorf("command span %s not within its component span", span.Method)
+		}
+	}
+	if spans[3].Start.Before(board.End) {
+		t.Error("second component started before the first finished")
+	}
+}
+
+// TestProfileDisabled tests that no spans are recorded by default.
+func TestProfileDisabled(t *testing.T) {
+	p := New().WithCPU()
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	if spans := p.Profile(); spans != nil {
+		t.Errorf("Profile() = %v, want nil", spans)
+	}
+}
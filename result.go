@@ -0,0 +1,118 @@
+package machineid
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+)
+
+// Result is the outcome of an ID generation by [Provider.Generate] or
+// [Provider.IDStream]: the ID together with how it was produced. It is
+// JSON-serializable with stable snake_case field names, so it can be logged
+// directly for telemetry correlation.
+type Result struct {
+	ID          string          `json:"id"`          // The machine ID, as returned by [Provider.ID]
+	Err         error           `json:"-"`           // The generation error, for [Provider.IDStream] results; may accompany an ID with [Provider.WithStrict] or [Provider.WithMinimumComponents]
+	Format      FormatMode      `json:"format"`      // The format of ID
+	Components  []string        `json:"components"`  // Components that contributed to ID, in canonical component order
+	Diagnostics *DiagnosticInfo `json:"diagnostics"` // A copy of the diagnostics of the collection
+}
+
+// Generate generates the machine ID like [Provider.ID] and returns it with
+// the format used, the components that contributed to it and a copy of the
+// diagnostics. It shares the collection and cache of [Provider.ID], so calling
+// both does not probe the hardware twice. With [Provider.WithStrict], a partial
+// collection returns both the result and an error wrapping
+// [ErrPartialCollection], and with [Provider.WithMinimumComponents] too few
+// components one wrapping [ErrInsufficientComponents].
+func (p *Provider) Generate(ctx context.Context) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id, err := p.id(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.result(id), p.collectionError()
+}
+
+// result returns the [Result] of the successful generation of id. The caller
+// must hold p.mu.
+func (p *Provider) result(id string) *Result {
+	diag := p.diagnostics.clone()
+
+	return &Result{
+		ID:          id,
+		Format:      p.formatMode,
+		Components:  slices.Clone(diag.Collected),
+		Diagnostics: diag,
+	}
+}
+
+// clone returns a deep copy of d, so callers can keep it while the provider
+// regenerates.
+func (d *DiagnosticInfo) clone() *DiagnosticInfo {
+	c := *d
+	c.Errors = maps.Clone(d.Errors)
+	c.Collected = slices.Clone(d.Collected)
+	c.Notes = slices.Clone(d.Notes)
+	c.Absent = slices.Clone(d.Absent)
+	c.Cached = slices.Clone(d.Cached)
+	if d.Duplicates != nil {
+		c.Duplicates = make(map[string][]string, len(d.Duplicates))
+		for component, others := range d.Duplicates {
+			c.Duplicates[component] = slices.Clone(others)
+		}
+	}
+
+	return &c
+}
+
+// diagnosticJSON is the JSON form of [DiagnosticInfo].
+type diagnosticJSON struct {
+	Platform   string              `json:"platform"`
+	Arch       string              `json:"arch"`
+	OSVersion  string              `json:"os_version,omitempty"`
+	Collected  []string            `json:"collected"`
+	Errors     map[string]string   `json:"errors,omitempty"`
+	UUIDSource string              `json:"uuid_source,omitempty"`
+	Duplicates map[string][]string `json:"duplicates,omitempty"`
+	Notes      []string            `json:"notes,omitempty"`
+	Absent     []string            `json:"absent,omitempty"`
+	Cached     []string            `json:"cached,omitempty"`
+}
+
+// MarshalJSON implements [json.Marshaler] with snake_case field names.
+// collected is always an array, and errors maps each failed component to its
+// error message, which for a [ComponentError] names the component. Map keys
+// are sorted, so equal diagnostics always encode to the same bytes.
+func (d *DiagnosticInfo) MarshalJSON() ([]byte, error) {
+	out := diagnosticJSON{
+		Platform:   d.Platform,
+		Arch:       d.Arch,
+		OSVersion:  d.OSVersion,
+		Collected:  d.Collected,
+		UUIDSource: d.UUIDSource,
+		Duplicates: d.Duplicates,
+		Notes:      d.Notes,
+		Absent:     d.Absent,
+		Cached:     d.Cached,
+	}
+
+	if out.Collected == nil {
+		out.Collected = []string{}
+	}
+
+	if len(d.Errors) > 0 {
+		out.Errors = make(map[string]string, len(d.Errors))
+		for component, err := range d.Errors {
+			if err != nil {
+				out.Errors[component] = err.Error()
+			}
+		}
+	}
+
+	return json.Marshal(out)
+}
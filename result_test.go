@@ -0,0 +1,122 @@
+package machineid_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/slashdevops/machineid"
+)
+
+// TestGenerate tests that the result matches ID() and the diagnostics, and
+// that its diagnostics are a copy.
+func TestGenerate(t *testing.T) {
+	p := machineid.New().WithCPU().WithFormat(machineid.Format32)
+
+	result, err := p.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	id, err := p.ID(context.Background())
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+	if result.ID != id {
+		t.Errorf("Generate().ID = %q, want ID() = %q", result.ID, id)
+	}
+	if result.Format != machineid.Format32 {
+		t.Errorf("Generate().Format = %d, want %d", result.Format, machineid.Format32)
+	}
+	if !slices.Equal(result.Components, []string{machineid.ComponentCPU}) {
+		t.Errorf("Generate().Components = %v, want [%s]", result.Components, machineid.ComponentCPU)
+	}
+
+	result.Diagnostics.Collected[0] = "changed"
+	if p.Diagnostics().Collected[0] != machineid.ComponentCPU {
+		t.Error("modifying Generate().Diagnostics changed the provider's diagnostics")
+	}
+}
+
+// TestGenerateNoIdentifiers tests that a failed generation returns the error.
+func TestGenerateNoIdentifiers(t *testing.T) {
+	result, err := machineid.New().Generate(context.Background())
+	if !errors.Is(err, machineid.ErrNoIdentifiers) || result != nil {
+		t.Errorf("Generate() = %v, %v; want nil, ErrNoIdentifiers", result, err)
+	}
+}
+
+// TestResultJSON tests the JSON field names of a result and its diagnostics.
+func TestResultJSON(t *testing.T) {
+	result := &machineid.Result{
+		ID:         "abcd",
+		Format:     machineid.Format32,
+		Components: []string{machineid.ComponentCPU},
+		Diagnostics: &machineid.DiagnosticInfo{
+			Platform:  "linux",
+			Arch:      "amd64",
+			Collected: []string{machineid.ComponentCPU},
+			Errors:    map[string]error{machineid.ComponentDisk: machineid.ErrNotFound},
+		},
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	want := `{"id":"abcd","format":1,"components":["cpu"],"diagnostics":{"platform":"linux","arch":"amd64","collected":["cpu"],"errors":{"disk":"value not found"}}}`
+	if string(data) != want {
+		t.Errorf("json.Marshal() = %s\nwant %s", data, want)
+	}
+}
+
+// TestDiagnosticInfoJSON compares the encoding of diagnostics against golden
+// JSON, checking that collected is always an array, that component errors keep
+// their component name, and that repeated encodings are identical.
+func TestDiagnosticInfoJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		diag   *machineid.DiagnosticInfo
+		golden string
+	}{
+		{
+			name:   "empty",
+			diag:   &machineid.DiagnosticInfo{Platform: "darwin", Arch: "arm64"},
+			golden: `{"platform":"darwin","arch":"arm64","collected":[]}`,
+		},
+		{
+			name: "errors",
+			diag: &machineid.DiagnosticInfo{
+				Platform:  "windows",
+				Arch:      "amd64",
+				Collected: []string{machineid.ComponentCPU, machineid.ComponentSystemUUID},
+				Errors: map[string]error{
+					machineid.ComponentMotherboard: &machineid.ComponentError{Component: machineid.ComponentMotherboard, Err: machineid.ErrOEMPlaceholder},
+					machineid.ComponentDisk:        &machineid.ComponentError{Component: machineid.ComponentDisk, Err: machineid.ErrNoValues},
+					machineid.ComponentMAC:         errors.New("netlink unavailable"),
+				},
+				Notes: []string{"wmic unavailable, using PowerShell"},
+			},
+			golden: `{"platform":"windows","arch":"amd64","collected":["cpu","uuid"],` +
+				`"errors":{"disk":"component \"disk\": no values found","mac":"netlink unavailable","motherboard":"component \"motherboard\": value is OEM placeholder"},` +
+				`"notes":["wmic unavailable, using PowerShell"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 10 {
+				data, err := json.Marshal(tt.diag)
+				if err != nil {
+					t.Fatalf("json.Marshal() error = %v", err)
+				}
+				if string(data) != tt.golden {
+					t.Fatalf("json.Marshal() = %s\nwant %s", data, tt.golden)
+				}
+			}
+		})
+	}
+}
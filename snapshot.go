@@ -0,0 +1,90 @@
+package machineid
+
+import (
+	"context"
+	"maps"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// Snapshot records the raw values of every collected hardware component at a
+// point in time. Snapshots are JSON-serializable, so a monitoring agent can
+// store them and later use [Snapshot.Diff] to find exactly what changed on a
+// machine, such as a replaced disk or NIC.
+//
+// Snapshots contain raw serial numbers and MAC addresses; store them with the
+// same care as other sensitive inventory data.
+type Snapshot struct {
+	Platform   string              `json:"platform"`
+	TakenAt    time.Time           `json:"taken_at"`
+	Components map[string][]string `json:"components"`
+}
+
+// ComponentChange describes how a component's values differ between two snapshots.
+// Before is empty for a component that appeared and After is empty for one that
+// disappeared.
+type ComponentChange struct {
+	Component string   `json:"component"`
+	Before    []string `json:"before,omitempty"`
+	After     []string `json:"after,omitempty"`
+}
+
+// Snapshot collects the configured components afresh, bypassing the cached ID,
+// and returns their raw values. It returns [ErrNoIdentifiers] when no component
+// could be collected. The snapshot collection does not replace the diagnostics,
+// values and timings of the last ID generation, so [Provider.Diagnostics] and
+// [Provider.WriteFingerprintBundle] keep describing the cached ID.
+func (p *Provider) Snapshot(ctx context.Context) (Snapshot, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.resolveLogger()
+
+	values, durations, spans := p.componentValues, p.componentDurations, p.spans
+	defer func() {
+		p.componentValues, p.componentDurations, p.spans = values, durations, spans
+	}()
+
+	takenAt := time.Now()
+
+	identifiers, _, err := p.collect(ctx)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	if len(identifiers) == 0 {
+		return Snapshot{}, ErrNoIdentifiers
+	}
+
+	snapshot := Snapshot{
+		Platform:   runtime.GOOS,
+		TakenAt:    takenAt,
+		Components: make(map[string][]string, len(p.componentValues)),
+	}
+	for component, values := range p.componentValues {
+		snapshot.Components[component] = slices.Sorted(slices.Values(values))
+	}
+
+	return snapshot, nil
+}
+
+// Diff returns the components whose values differ between s and later, sorted
+// by component name. Values of multi-value components are compared as sets, so
+// enumeration order does not register as a change.
+func (s Snapshot) Diff(later Snapshot) []ComponentChange {
+	components := slices.Collect(maps.Keys(s.Components))
+	components = append(components, slices.Collect(maps.Keys(later.Components))...)
+	slices.Sort(components)
+
+	var changes []ComponentChange
+	for _, component := range slices.Compact(components) {
+		before := slices.Sorted(slices.Values(s.Components[component]))
+		after := slices.Sorted(slices.Values(later.Components[component]))
+		if !slices.Equal(before, after) {
+			changes = append(changes, ComponentChange{Component: component, Before: before, After: after})
+		}
+	}
+
+	return changes
+}
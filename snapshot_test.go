@@ -0,0 +1,103 @@
+package machineid_test
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/slashdevops/machineid"
+)
+
+// TestSnapshot tests that a snapshot captures the collected components.
+func TestSnapshot(t *testing.T) {
+	snapshot, err := machineid.New().WithCPU().Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+
+	if snapshot.Platform != runtime.GOOS {
+		t.Errorf("Snapshot().Platform = %q, want %q", snapshot.Platform, runtime.GOOS)
+	}
+	if len(snapshot.Components[machineid.ComponentCPU]) != 1 {
+		t.Errorf("Snapshot().Components[cpu] = %v, want one value", snapshot.Components[machineid.ComponentCPU])
+	}
+	if snapshot.TakenAt.IsZero() {
+		t.Error("Snapshot().TakenAt should be set")
+	}
+
+	if diff := snapshot.Diff(snapshot); len(diff) != 0 {
+		t.Errorf("Diff() of a snapshot with itself = %v, want none", diff)
+	}
+}
+
+// TestSnapshotNoComponents tests that a snapshot without components fails.
+func TestSnapshotNoComponents(t *testing.T) {
+	if _, err := machineid.New().Snapshot(context.Background()); err == nil {
+		t.Error("Snapshot() expected error with no components")
+	}
+}
+
+// TestSnapshotDiff tests diffing stored snapshots with one changed component.
+func TestSnapshotDiff(t *testing.T) {
+	before := machineid.Snapshot{
+		Platform: "linux",
+		TakenAt:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Components: map[string][]string{
+			machineid.ComponentCPU:  {"GenuineIntel"},
+			machineid.ComponentDisk: {"DISK-A", "DISK-B"},
+			machineid.ComponentMAC:  {"aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02"},
+		},
+	}
+	after := machineid.Snapshot{
+		Platform: "linux",
+		TakenAt:  time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC),
+		Components: map[string][]string{
+			machineid.ComponentCPU:  {"GenuineIntel"},
+			machineid.ComponentDisk: {"DISK-A", "DISK-C"},
+			machineid.ComponentMAC:  {"aa:bb:cc:dd:ee:02", "aa:bb:cc:dd:ee:01"},
+		},
+	}
+
+	// Round-trip through JSON, as a monitoring agent storing snapshots would.
+	data, err := json.Marshal(before)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var stored machineid.Snapshot
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	changes := stored.Diff(after)
+	if len(changes) != 1 {
+		t.Fatalf("Diff() = %+v, want exactly one change", changes)
+	}
+
+	change := changes[0]
+	if change.Component != machineid.ComponentDisk {
+		t.Errorf("Diff() component = %q, want %q", change.Component, machineid.ComponentDisk)
+	}
+	if !slices.Equal(change.Before, []string{"DISK-A", "DISK-B"}) || !slices.Equal(change.After, []string{"DISK-A", "DISK-C"}) {
+		t.Errorf("Diff() change = %+v", change)
+	}
+}
+
+// TestSnapshotDiffAddedRemoved tests components appearing and disappearing.
+func TestSnapshotDiffAddedRemoved(t *testing.T) {
+	before := machineid.Snapshot{Components: map[string][]string{machineid.ComponentMotherboard: {"MB-1"}}}
+	after := machineid.Snapshot{Components: map[string][]string{machineid.ComponentDisk: {"DISK-A"}}}
+
+	changes := before.Diff(after)
+	if len(changes) != 2 {
+		t.Fatalf("Diff() = %+v, want two changes", changes)
+	}
+	if changes[0].Component != machineid.ComponentDisk || len(changes[0].Before) != 0 {
+		t.Errorf("Diff()[0] = %+v, want added disk", changes[0])
+	}
+	if changes[1].Component != machineid.ComponentMotherboard || len(changes[1].After) != 0 {
+		t.Errorf("Diff()[1] = %+v, want removed motherboard", changes[1])
+	}
+}
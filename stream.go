@@ -0,0 +1,128 @@
+package machineid
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// CollectionEventKind distinguishes the events sent by [Provider.IDStream].
+type CollectionEventKind int
+
+const (
+	// ComponentStarted is sent when collection of a component begins.
+	ComponentStarted CollectionEventKind = iota
+	// ComponentFinished is sent when collection of a component ends, successfully or not.
+	ComponentFinished
+)
+
+// String returns the string representation of the CollectionEventKind.
+func (k CollectionEventKind) String() string {
+	switch k {
+	case ComponentStarted:
+		return "started"
+	case ComponentFinished:
+		return "finished"
+	default:
+		return "unknown"
+	}
+}
+
+// CollectionEvent reports progress of a single component during [Provider.IDStream].
+type CollectionEvent struct {
+	Component string              // Component name, e.g. [ComponentCPU]
+	Kind      CollectionEventKind // Whether collection started or finished
+	Err       error               // Component error, for a failed ComponentFinished event
+	Duration  time.Duration       // Collection time, for ComponentFinished events
+}
+
+// IDStream generates the machine ID like [Provider.ID] while reporting the
+// progress of each component, for example to drive a progress bar. It returns
+// immediately; a [CollectionEvent] is sent on the first channel as each
+// component starts and finishes, and the final [Result], which matches what
+// [Provider.ID] would return, is sent on the second. Both channels are closed
+// afterwards, the event channel first. No events are sent when the ID is cached.
+// The result is built under the provider lock together with the ID, so a
+// concurrent [Provider.ID] cannot replace its diagnostics; the generation
+// waits for one already in progress on the same provider.
+//
+// The event channel has room for two events per component that can report
+// progress: the enabled ones, and those collected implicitly, such as the
+// machine-id collected with the system UUID, fallback alternates and the
+// [ComponentOSMachineID] fallback. A consumer
+// that falls behind further blocks collection, which holds the provider lock,
+// until it reads again or ctx is cancelled; events that cannot be delivered
+// after cancellation are dropped. Cancelling ctx also cancels collection.
+func (p *Provider) IDStream(ctx context.Context) (<-chan CollectionEvent, <-chan Result) {
+	p.mu.Lock()
+	size := 2 * p.eventComponentCount()
+	p.mu.Unlock()
+
+	events := make(chan CollectionEvent, size)
+	results := make(chan Result, 1)
+
+	go func() {
+		defer close(results)
+
+		p.mu.Lock()
+		id, err := p.id(ctx, func(event CollectionEvent) {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+		})
+		result := &Result{Err: err}
+		if err == nil {
+			result = p.result(id)
+			result.Err = p.collectionError()
+		}
+		p.mu.Unlock()
+
+		close(events)
+		results <- *result
+	}()
+
+	return events, results
+}
+
+// eventComponentCount returns an upper bound on the number of components that
+// report events in one collection. The caller must hold p.mu.
+func (p *Provider) eventComponentCount() int {
+	n := len(p.enabledComponents())
+	if p.includeSystemUUID {
+		n++ // machine-id
+	}
+	for _, chain := range p.fallbackChains {
+		n += len(chain.alternates)
+		if slices.Contains(chain.alternates, ComponentSystemUUID) {
+			n++ // machine-id, collected again with the alternate
+		}
+	}
+	if p.fallbackToMachineID {
+		n++
+	}
+
+	return n
+}
+
+// observeStarted reports that collection of component began.
+func (p *Provider) observeStarted(component string) {
+	if p.observer != nil {
+		p.observer(CollectionEvent{Component: component, Kind: ComponentStarted})
+	}
+}
+
+// observeFinished reports that collection of component, begun at start, ended
+// with the error recorded in diag, if any.
+func (p *Provider) observeFinished(diag *DiagnosticInfo, component string, start time.Time) {
+	if p.observer == nil {
+		return
+	}
+
+	event := CollectionEvent{Component: component, Kind: ComponentFinished, Duration: time.Since(start)}
+	if diag != nil {
+		event.Err = diag.Errors[component]
+	}
+
+	p.observer(event)
+}
@@ -0,0 +1,142 @@
+package machineid_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slashdevops/machineid"
+)
+
+// TestIDStream tests that every component reports a start and a finish event
+// and that the final result matches ID().
+func TestIDStream(t *testing.T) {
+	p := machineid.New().WithCPU().WithMAC()
+
+	events, results := p.IDStream(context.Background())
+
+	started := make(map[string]bool)
+	finished := make(map[string]bool)
+	for event := range events {
+		switch event.Kind {
+		case machineid.ComponentStarted:
+			if started[event.Component] {
+				t.Errorf("component %q started twice", event.Component)
+			}
+			started[event.Component] = true
+		case machineid.ComponentFinished:
+			if !started[event.Component] {
+				t.Errorf("component %q finished before it started", event.Component)
+			}
+			finished[event.Component] = true
+		}
+	}
+
+	result, ok := <-results
+	if !ok {
+		t.Fatal("result channel closed without a result")
+	}
+	if _, ok := <-results; ok {
+		t.Error("result channel not closed after the result")
+	}
+
+	for _, component := range []string{machineid.ComponentCPU, machineid.ComponentMAC} {
+		if !started[component] || !finished[component] {
+			t.Errorf("component %q: started = %v, finished = %v, want both", component, started[component], finished[component])
+		}
+	}
+
+	id, err := p.ID(context.Background())
+	if result.ID != id || result.Err != err {
+		t.Errorf("IDStream() result = %+v, want ID() = %q, %v", result, id, err)
+	}
+}
+
+// TestIDStreamCached tests that a cached ID is delivered without events.
+func TestIDStreamCached(t *testing.T) {
+	p := machineid.New().WithCPU()
+	id, err := p.ID(context.Background())
+	if err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+
+	events, results := p.IDStream(context.Background())
+	for event := range events {
+		t.Errorf("unexpected event for cached ID: %+v", event)
+	}
+	if result := <-results; result.ID != id || result.Err != nil {
+		t.Errorf("IDStream() result = %+v, want %q", result, id)
+	}
+}
+
+// TestIDStreamCancelled tests that both channels are closed when the context
+// is already cancelled.
+func TestIDStreamCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	events, results := machineid.New().WithCPU().WithDisk().IDStream(ctx)
+	for range events {
+	}
+	<-results
+	if _, ok := <-results; ok {
+		t.Error("result channel not closed")
+	}
+}
+
+// TestIDStreamConcurrentID tests that IDStream can run alongside ID on the
+// same provider and that both return the same ID. Run with -race.
+func TestIDStreamConcurrentID(t *testing.T) {
+	p := machineid.New().WithCPU().WithMAC()
+
+	var wg sync.WaitGroup
+	ids := make([]string, 4)
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			id, err := p.ID(context.Background())
+			if err != nil {
+				t.Errorf("ID() error = %v", err)
+			}
+			ids[i] = id
+		}()
+	}
+
+	events, results := p.IDStream(context.Background())
+	for range events {
+	}
+	result := <-results
+	wg.Wait()
+
+	if result.Err != nil {
+		t.Fatalf("IDStream() error = %v", result.Err)
+	}
+	for _, id := range ids {
+		if id != result.ID {
+			t.Errorf("ID() = %q, IDStream() = %q, want equal", id, result.ID)
+		}
+	}
+	if len(result.Components) != len(result.Diagnostics.Collected) {
+		t.Errorf("result components = %v, diagnostics collected = %v", result.Components, result.Diagnostics.Collected)
+	}
+}
+
+// TestIDStreamUnreadEvents tests that collection completes without a consumer
+// when implicit and fallback components report events too.
+func TestIDStreamUnreadEvents(t *testing.T) {
+	p := machineid.New().WithSystemUUID().WithMotherboard().
+		WithFallbackChain(machineid.ComponentMotherboard, machineid.ComponentSystemUUID, machineid.ComponentCPU).
+		WithFallbackToMachineID()
+
+	events, results := p.IDStream(context.Background())
+	select {
+	case <-results:
+	case <-time.After(10 * time.Second):
+		t.Fatal("IDStream() blocked on unread events")
+	}
+	for range events {
+	}
+}
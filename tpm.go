@@ -0,0 +1,43 @@
+package machineid
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"log/slog"
+	"strings"
+)
+
+// linuxTPMEKHash returns the SHA-256 digest of the TPM Endorsement Key
+// certificate printed by tpm2_getekcertificate. The TPM is located through
+// sysfs, so machines without one fail with [ErrNotFound] before any command runs.
+func linuxTPMEKHash(ctx context.Context, fsys fs.FS, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	if _, err := fs.Stat(fsys, "sys/class/tpm/tpm0"); err != nil {
+		return "", &ParseError{Source: "/sys/class/tpm/tpm0", Err: ErrNotFound}
+	}
+
+	if description, err := fs.ReadFile(fsys, "sys/class/tpm/tpm0/device/description"); err == nil && logger != nil {
+		logger.Debug("found TPM", "description", strings.TrimSpace(string(description)))
+	}
+
+	output, err := executeCommand(ctx, executor, logger, "tpm2_getekcertificate")
+	if err != nil {
+		return "", err
+	}
+
+	return tpmEKHash(output, "tpm2_getekcertificate output")
+}
+
+// tpmEKHash returns the hex SHA-256 digest of an Endorsement Key certificate
+// or public key read from source, ignoring surrounding whitespace.
+func tpmEKHash(output, source string) (string, error) {
+	ek := strings.TrimSpace(output)
+	if ek == "" {
+		return "", &ParseError{Source: source, Err: ErrNotFound}
+	}
+
+	sum := sha256.Sum256([]byte(ek))
+
+	return hex.EncodeToString(sum[:]), nil
+}
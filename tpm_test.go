@@ -0,0 +1,64 @@
+package machineid
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+// ekCertificate is tpm2_getekcertificate output for an RSA EK, abbreviated.
+const ekCertificate = `-----BEGIN CERTIFICATE-----
+MIIEnDCCA4SgAwIBAgIEQ2ZqRjANBgkqhkiG9w0BAQsFADCBgzELMAkGA1UEBhMC
+REUxITAfBgNVBAoMGEluZmluZW9uIFRlY2hub2xvZ2llcyBBRzEaMBgGA1UECwwR
+-----END CERTIFICATE-----
+`
+
+// TestLinuxTPMEKHash tests hashing the EK certificate of a TPM found in sysfs.
+func TestLinuxTPMEKHash(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutput("tpm2_getekcertificate", ekCertificate)
+	fsys := fstest.MapFS{
+		"sys/class/tpm/tpm0/device/description": {Data: []byte("TPM 2.0 Device\n")},
+	}
+
+	got, err := linuxTPMEKHash(context.Background(), fsys, mock, nil)
+	if err != nil {
+		t.Fatalf("linuxTPMEKHash() error = %v", err)
+	}
+
+	sum := sha256.Sum256([]byte(ekCertificate[:len(ekCertificate)-1]))
+	if want := hex.EncodeToString(sum[:]); got != want {
+		t.Errorf("linuxTPMEKHash() = %q, want %q", got, want)
+	}
+}
+
+// TestLinuxTPMEKHashNotFound tests that a missing TPM or EK certificate fails
+// with ErrNotFound, without running tpm2-tools when there is no TPM.
+func TestLinuxTPMEKHashNotFound(t *testing.T) {
+	tests := []struct {
+		name      string
+		fsys      fstest.MapFS
+		output    string
+		wantCalls int
+	}{
+		{"no TPM", fstest.MapFS{}, ekCertificate, 0},
+		{"no EK certificate", fstest.MapFS{"sys/class/tpm/tpm0": {}}, "\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockExecutor()
+			mock.setOutput("tpm2_getekcertificate", tt.output)
+
+			if _, err := linuxTPMEKHash(context.Background(), tt.fsys, mock, nil); !errors.Is(err, ErrNotFound) {
+				t.Errorf("linuxTPMEKHash() error = %v, want ErrNotFound", err)
+			}
+			if mock.callCount["tpm2_getekcertificate"] != tt.wantCalls {
+				t.Errorf("tpm2_getekcertificate calls = %d, want %d", mock.callCount["tpm2_getekcertificate"], tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package machineid
+
+import "strings"
+
+// normalizeNFC composes base letters followed by combining marks into their
+// precomposed (NFC) form, so that values reported in decomposed (NFD) form
+// by one tool hash identically to the precomposed form reported by another.
+//
+// This is a deliberately minimal normalizer that keeps the package free of
+// third-party dependencies: it covers the precomposed Latin letters found in
+// OEM fields, not the full Unicode composition algorithm. ASCII input is
+// returned unchanged without allocation.
+func normalizeNFC(value string) string {
+	if isASCII(value) {
+		return value
+	}
+
+	runes := []rune(value)
+	var b strings.Builder
+	b.Grow(len(value))
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		for i+1 < len(runes) {
+			composed, ok := nfcCompositions[[2]rune{r, runes[i+1]}]
+			if !ok {
+				break
+			}
+
+			r = composed
+			i++
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// isASCII reports whether value contains only ASCII characters.
+func isASCII(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] >= 0x80 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nfcCompositions maps a base letter followed by a combining mark to its
+// precomposed form, covering the Latin-1 Supplement and Latin Extended-A blocks.
+var nfcCompositions = map[[2]rune]rune{
+	// U+0300 combining grave
+	{'A', 0x0300}: 'À', {'E', 0x0300}: 'È', {'I', 0x0300}: 'Ì', {'O', 0x0300}: 'Ò',
+	{'U', 0x0300}: 'Ù', {'a', 0x0300}: 'à', {'e', 0x0300}: 'è', {'i', 0x0300}: 'ì',
+	{'o', 0x0300}: 'ò', {'u', 0x0300}: 'ù',
+	// U+0301 combining acute
+	{'A', 0x0301}: 'Á', {'E', 0x0301}: 'É', {'I', 0x0301}: 'Í', {'O', 0x0301}: 'Ó',
+	{'U', 0x0301}: 'Ú', {'Y', 0x0301}: 'Ý', {'a', 0x0301}: 'á', {'e', 0x0301}: 'é',
+	{'i', 0x0301}: 'í', {'o', 0x0301}: 'ó', {'u', 0x0301}: 'ú', {'y', 0x0301}: 'ý',
+	{'C', 0x0301}: 'Ć', {'c', 0x0301}: 'ć', {'L', 0x0301}: 'Ĺ', {'l', 0x0301}: 'ĺ',
+	{'N', 0x0301}: 'Ń', {'n', 0x0301}: 'ń', {'R', 0x0301}: 'Ŕ', {'r', 0x0301}: 'ŕ',
+	{'S', 0x0301}: 'Ś', {'s', 0x0301}: 'ś', {'Z', 0x0301}: 'Ź', {'z', 0x0301}: 'ź',
+	// U+0302 combining circumflex
+	{'A', 0x0302}: 'Â', {'E', 0x0302}: 'Ê', {'I', 0x0302}: 'Î', {'O', 0x0302}: 'Ô',
+	{'U', 0x0302}: 'Û', {'a', 0x0302}: 'â', {'e', 0x0302}: 'ê', {'i', 0x0302}: 'î',
+	{'o', 0x0302}: 'ô', {'u', 0x0302}: 'û', {'C', 0x0302}: 'Ĉ', {'c', 0x0302}: 'ĉ',
+	{'G', 0x0302}: 'Ĝ', {'g', 0x0302}: 'ĝ', {'H', 0x0302}: 'Ĥ', {'h', 0x0302}: 'ĥ',
+	{'J', 0x0302}: 'Ĵ', {'j', 0x0302}: 'ĵ', {'S', 0x0302}: 'Ŝ', {'s', 0x0302}: 'ŝ',
+	{'W', 0x0302}: 'Ŵ', {'w', 0x0302}: 'ŵ', {'Y', 0x0302}: 'Ŷ', {'y', 0x0302}: 'ŷ',
+	// U+0303 combining tilde
+	{'A', 0x0303}: 'Ã', {'N', 0x0303}: 'Ñ', {'O', 0x0303}: 'Õ', {'a', 0x0303}: 'ã',
+	{'n', 0x0303}: 'ñ', {'o', 0x0303}: 'õ', {'I', 0x0303}: 'Ĩ', {'i', 0x0303}: 'ĩ',
+	{'U', 0x0303}: 'Ũ', {'u', 0x0303}: 'ũ',
+	// U+0304 combining macron
+	{'A', 0x0304}: 'Ā', {'a', 0x0304}: 'ā', {'E', 0x0304}: 'Ē', {'e', 0x0304}: 'ē',
+	{'I', 0x0304}: 'Ī', {'i', 0x0304}: 'ī', {'O', 0x0304}: 'Ō', {'o', 0x0304}: 'ō',
+	{'U', 0x0304}: 'Ū', {'u', 0x0304}: 'ū',
+	// U+0306 combining breve
+	{'A', 0x0306}: 'Ă', {'a', 0x0306}: 'ă', {'E', 0x0306}: 'Ĕ', {'e', 0x0306}: 'ĕ',
+	{'G', 0x0306}: 'Ğ', {'g', 0x0306}: 'ğ', {'I', 0x0306}: 'Ĭ', {'i', 0x0306}: 'ĭ',
+	{'O', 0x0306}: 'Ŏ', {'o', 0x0306}: 'ŏ', {'U', 0x0306}: 'Ŭ', {'u', 0x0306}: 'ŭ',
+	// U+0307 combining dot above
+	{'C', 0x0307}: 'Ċ', {'c', 0x0307}: 'ċ', {'E', 0x0307}: 'Ė', {'e', 0x0307}: 'ė',
+	{'G', 0x0307}: 'Ġ', {'g', 0x0307}: 'ġ', {'I', 0x0307}: 'İ', {'Z', 0x0307}: 'Ż',
+	{'z', 0x0307}: 'ż',
+	// U+0308 combining diaeresis
+	{'A', 0x0308}: 'Ä', {'E', 0x0308}: 'Ë', {'I', 0x0308}: 'Ï', {'O', 0x0308}: 'Ö',
+	{'U', 0x0308}: 'Ü', {'a', 0x0308}: 'ä', {'e', 0x0308}: 'ë', {'i', 0x0308}: 'ï',
+	{'o', 0x0308}: 'ö', {'u', 0x0308}: 'ü', {'y', 0x0308}: 'ÿ', {'Y', 0x0308}: 'Ÿ',
+	// U+030A combining ring above
+	{'A', 0x030A}: 'Å', {'a', 0x030A}: 'å', {'U', 0x030A}: 'Ů', {'u', 0x030A}: 'ů',
+	// U+030B combining double acute
+	{'O', 0x030B}: 'Ő', {'o', 0x030B}: 'ő', {'U', 0x030B}: 'Ű', {'u', 0x030B}: 'ű',
+	// U+030C combining caron
+	{'C', 0x030C}: 'Č', {'c', 0x030C}: 'č', {'D', 0x030C}: 'Ď', {'d', 0x030C}: 'ď',
+	{'E', 0x030C}: 'Ě', {'e', 0x030C}: 'ě', {'L', 0x030C}: 'Ľ', {'l', 0x030C}: 'ľ',
+	{'N', 0x030C}: 'Ň', {'n', 0x030C}: 'ň', {'R', 0x030C}: 'Ř', {'r', 0x030C}: 'ř',
+	{'S', 0x030C}: 'Š', {'s', 0x030C}: 'š', {'T', 0x030C}: 'Ť', {'t', 0x030C}: 'ť',
+	{'Z', 0x030C}: 'Ž', {'z', 0x030C}: 'ž',
+	// U+0327 combining cedilla
+	{'C', 0x0327}: 'Ç', {'c', 0x0327}: 'ç', {'G', 0x0327}: 'Ģ', {'g', 0x0327}: 'ģ',
+	{'K', 0x0327}: 'Ķ', {'k', 0x0327}: 'ķ', {'L', 0x0327}: 'Ļ', {'l', 0x0327}: 'ļ',
+	{'N', 0x0327}: 'Ņ', {'n', 0x0327}: 'ņ', {'R', 0x0327}: 'Ŗ', {'r', 0x0327}: 'ŗ',
+	{'S', 0x0327}: 'Ş', {'s', 0x0327}: 'ş', {'T', 0x0327}: 'Ţ', {'t', 0x0327}: 'ţ',
+	// U+0328 combining ogonek
+	{'A', 0x0328}: 'Ą', {'a', 0x0328}: 'ą', {'E', 0x0328}: 'Ę', {'e', 0x0328}: 'ę',
+	{'I', 0x0328}: 'Į', {'i', 0x0328}: 'į', {'U', 0x0328}: 'Ų', {'u', 0x0328}: 'ų',
+}
@@ -0,0 +1,56 @@
+package machineid
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+)
+
+// TestNormalizeNFC tests composition of decomposed Latin characters.
+func TestNormalizeNFC(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"ascii unchanged", "C02TEST123", "C02TEST123"},
+		{"already composed", "SÉRIE-Ü1", "SÉRIE-Ü1"},
+		{"decomposed acute", "SE\u0301RIE", "SÉRIE"},
+		{"decomposed diaeresis", "U\u0308NIT-7", "ÜNIT-7"},
+		{"decomposed caron", "C\u030cESKY", "ČESKY"},
+		{"unknown mark kept", "X\u0301", "X\u0301"},
+		{"trailing mark kept", "\u0301A", "\u0301A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeNFC(tt.input); got != tt.want {
+				t.Errorf("normalizeNFC(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWithUnicodeNormalizationSameID tests that NFD and NFC serials produce the same ID.
+func TestWithUnicodeNormalizationSameID(t *testing.T) {
+	idFor := func(p *Provider, serial string) string {
+		identifiers := p.appendIdentifier(context.Background(), nil, func(context.Context) (string, error) {
+			return serial, nil
+		}, "mb:", nil, ComponentMotherboard)
+
+		return hashIdentifiers(sha256.New(), identifiers, "")
+	}
+
+	nfc := "SÉRIAL-Ñ42"
+	nfd := "SE\u0301RIAL-N\u030342"
+
+	normalized := New().WithUnicodeNormalization()
+	if idFor(normalized, nfc) != idFor(normalized, nfd) {
+		t.Error("NFC and NFD serials should produce the same ID with normalization enabled")
+	}
+
+	plain := New()
+	if idFor(plain, nfc) == idFor(plain, nfd) {
+		t.Error("NFC and NFD serials should differ without normalization (default off)")
+	}
+}
@@ -0,0 +1,52 @@
+package machineid
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+)
+
+// uuidNamespace is the namespace of [Provider.UUID]: the version-5 UUID
+// 5d55b58d-11d6-58c9-9c5b-37e37ff6d2d0 of the name
+// "https://github.com/slashdevops/machineid" in the RFC 4122 URL namespace.
+var uuidNamespace = [16]byte{
+	0x5d, 0x55, 0xb5, 0x8d, 0x11, 0xd6, 0x58, 0xc9,
+	0x9c, 0x5b, 0x37, 0xe3, 0x7f, 0xf6, 0xd2, 0xd0,
+}
+
+// UUID returns the machine ID as a canonical, lowercase RFC 4122 version-5
+// UUID, such as "425ce295-3fbc-5b3d-8100-a9c088ceff51", for license servers
+// and databases that expect one. The name is the identifier string hashed
+// into [Provider.ID], including the salt, in a fixed namespace, so any
+// version-5 implementation reproduces it from the same input.
+//
+// Version-5 UUIDs are defined over SHA-1: [Provider.WithHasher] and
+// [Provider.WithHMAC] do not apply, and the 122-bit UUID is no secret to
+// anyone who knows the hardware identifiers and salt. It is independent of
+// [Provider.WithFormat] and [Provider.WithEncoding], and shares the collection
+// and cache of [Provider.ID].
+func (p *Provider) UUID(ctx context.Context) (string, error) {
+	if _, err := p.ID(ctx); err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.cachedUUID, nil
+}
+
+// nameBasedUUID returns the version-5 UUID in [uuidNamespace] whose name is
+// the message [writeIdentifiers] builds from identifiers and salt.
+func nameBasedUUID(identifiers []string, salt string) string {
+	h := sha1.New()
+	h.Write(uuidNamespace[:])
+	writeIdentifiers(h, identifiers, salt)
+
+	var u [16]byte
+	copy(u[:], h.Sum(nil))
+	u[6] = u[6]&0x0f | 0x50 // version 5
+	u[8] = u[8]&0x3f | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
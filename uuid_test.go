@@ -0,0 +1,60 @@
+package machineid
+
+import (
+	"context"
+	"regexp"
+	"testing"
+)
+
+// TestNameBasedUUID tests the version-5 UUID of fixed identifiers against
+// values computed with Python's uuid.uuid5.
+func TestNameBasedUUID(t *testing.T) {
+	identifiers := []string{"uuid:4c4c4544-0042-3510-8052-b4c04f384833", "cpu:GenuineIntel"}
+
+	tests := []struct {
+		salt string
+		want string
+	}{
+		{"", "425ce295-3fbc-5b3d-8100-a9c088ceff51"},
+		{"my-app", "42022d43-0502-50df-86ec-ae199b9118b8"},
+	}
+
+	for _, tt := range tests {
+		for range 2 {
+			if got := nameBasedUUID(identifiers, tt.salt); got != tt.want {
+				t.Errorf("nameBasedUUID(salt %q) = %q, want %q", tt.salt, got, tt.want)
+			}
+		}
+	}
+}
+
+// TestUUID tests that UUID returns a deterministic, canonical version-5 UUID
+// with the RFC 4122 variant that changes with the salt.
+func TestUUID(t *testing.T) {
+	ctx := context.Background()
+	canonical := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	newProvider := func(salt string) *Provider {
+		p := New().WithMAC().WithSalt(salt).WithFormat(Format256).WithEncoding(EncodingBase32)
+		p.listInterfaces = physicalOnlyInterfaces
+
+		return p
+	}
+
+	p := newProvider("")
+	got, err := p.UUID(ctx)
+	if err != nil {
+		t.Fatalf("UUID() error = %v", err)
+	}
+	if !canonical.MatchString(got) {
+		t.Errorf("UUID() = %q, want a canonical version-5 UUID", got)
+	}
+	if again, _ := newProvider("").UUID(ctx); again != got {
+		t.Errorf("UUID() = %q, then %q", got, again)
+	}
+	if salted, _ := newProvider("my-app").UUID(ctx); salted == got {
+		t.Errorf("UUID() with salt = %q, want it to differ", salted)
+	}
+	if p.Diagnostics() == nil {
+		t.Error("UUID() should populate diagnostics")
+	}
+}
@@ -0,0 +1,56 @@
+package machineid
+
+import (
+	"runtime/debug"
+
+	"github.com/slashdevops/machineid/internal/version"
+)
+
+// modulePath is the import path of this module, used to find its version in
+// the build information of the binary that embeds it.
+const modulePath = "github.com/slashdevops/machineid"
+
+// Version returns the version of the machineid library compiled into the
+// running binary, so applications can store it alongside each persisted ID
+// and decide later whether an ID must be regenerated after an upgrade. It is
+// the version set at build time with
+//
+//	go build -ldflags "-X 'github.com/slashdevops/machineid/internal/version.Version=1.0.0'"
+//
+// if any, else the module version recorded in the binary's build information,
+// else "(devel)".
+func Version() string {
+	if version.Version != "0.0.0" {
+		return version.Version
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+
+	return buildInfoVersion(info)
+}
+
+// buildInfoVersion returns the version of this module in info: the main
+// module's version when building this repository, or the version of the
+// dependency, honoring replace directives, when embedded in an application.
+func buildInfoVersion(info *debug.BuildInfo) string {
+	if info.Main.Path == modulePath && info.Main.Version != "" {
+		return info.Main.Version
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path != modulePath {
+			continue
+		}
+		if dep.Replace != nil && dep.Replace.Version != "" {
+			return dep.Replace.Version
+		}
+		if dep.Version != "" {
+			return dep.Version
+		}
+	}
+
+	return "(devel)"
+}
@@ -0,0 +1,68 @@
+package machineid
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/slashdevops/machineid/internal/version"
+)
+
+// TestBuildInfoVersion tests that the module version is found as the main
+// module, as a dependency, and through a replace directive.
+func TestBuildInfoVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		info *debug.BuildInfo
+		want string
+	}{
+		{
+			name: "main module",
+			info: &debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "v1.2.0"}},
+			want: "v1.2.0",
+		},
+		{
+			name: "dependency",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/app", Version: "v0.1.0"},
+				Deps: []*debug.Module{
+					{Path: "example.com/other", Version: "v9.9.9"},
+					{Path: modulePath, Version: "v1.3.1"},
+				},
+			},
+			want: "v1.3.1",
+		},
+		{
+			name: "replaced dependency",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/app"},
+				Deps: []*debug.Module{
+					{Path: modulePath, Version: "v1.3.1", Replace: &debug.Module{Path: "example.com/fork", Version: "v1.3.2"}},
+				},
+			},
+			want: "v1.3.2",
+		},
+		{
+			name: "not found",
+			info: &debug.BuildInfo{Main: debug.Module{Path: "example.com/app", Version: "v0.1.0"}},
+			want: "(devel)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildInfoVersion(tt.info); got != tt.want {
+				t.Errorf("buildInfoVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestVersionLdflags tests that a version set at build time takes precedence.
+func TestVersionLdflags(t *testing.T) {
+	defer func(v string) { version.Version = v }(version.Version)
+
+	version.Version = "1.0.0"
+	if got := Version(); got != "1.0.0" {
+		t.Errorf("Version() = %q, want %q", got, "1.0.0")
+	}
+}
@@ -4,57 +4,297 @@ package machineid
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"log/slog"
+	"net"
+	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
+// fastModeSkipped lists the components skipped by [Provider.WithFastMode] on Windows.
+var fastModeSkipped = map[string]bool{
+	ComponentMotherboard: true,
+	ComponentDisk:        true,
+	ComponentTPM:         true,
+	ComponentGPU:         true,
+	ComponentMemory:      true,
+}
+
+// supportedComponents lists the components with a collector on Windows.
+var supportedComponents = []string{
+	ComponentCPU,
+	ComponentMotherboard,
+	ComponentSystemUUID,
+	ComponentMAC,
+	ComponentDisk,
+	ComponentTPM,
+	ComponentGPU,
+	ComponentMemory,
+}
+
+// defaultComponentTimeouts bounds each component on Windows. wmic and
+// PowerShell take seconds to start, and MAC classification uses PowerShell.
+var defaultComponentTimeouts = map[string]time.Duration{
+	ComponentCPU:         10 * time.Second,
+	ComponentMotherboard: 10 * time.Second,
+	ComponentSystemUUID:  10 * time.Second,
+	ComponentMAC:         10 * time.Second,
+	ComponentDisk:        15 * time.Second,
+	ComponentTPM:         15 * time.Second,
+	ComponentGPU:         10 * time.Second,
+	ComponentMemory:      10 * time.Second,
+}
+
 // collectIdentifiers gathers Windows-specific hardware identifiers based on provider config.
 func collectIdentifiers(ctx context.Context, p *Provider, diag *DiagnosticInfo) ([]string, error) {
 	var identifiers []string
 	logger := p.logger
+	executor := &wmicAwareExecutor{CommandExecutor: p.commandExecutor, diag: diag, logger: logger}
 
 	if p.includeCPU {
-		identifiers = appendIdentifierIfValid(identifiers, func() (string, error) {
-			return windowsCPUID(ctx, p.commandExecutor, logger)
-		}, "cpu:", diag, ComponentCPU, logger)
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			return windowsCPUID(ctx, executor, logger)
+		}, "cpu:", diag, ComponentCPU)
 	}
 
 	if p.includeMotherboard {
-		identifiers = appendIdentifierIfValid(identifiers, func() (string, error) {
-			return windowsMotherboardSerial(ctx, p.commandExecutor, logger)
-		}, "mb:", diag, ComponentMotherboard, logger)
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			return windowsMotherboardSerial(ctx, executor, logger)
+		}, "mb:", diag, ComponentMotherboard)
 	}
 
-	if p.includeSystemUUID {
-		identifiers = appendIdentifierIfValid(identifiers, func() (string, error) {
-			return windowsSystemUUID(ctx, p.commandExecutor, logger)
-		}, "uuid:", diag, ComponentSystemUUID, logger)
+	if p.includeSystemUUID && p.bestUUID {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			value, source, err := windowsBestUUID(ctx, executor, logger)
+			if err == nil {
+				setUUIDSource(diag, source)
+			}
+
+			return value, err
+		}, "uuid:", diag, ComponentSystemUUID)
+	} else if p.includeSystemUUID {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			return windowsSystemUUID(ctx, executor, logger)
+		}, "uuid:", diag, ComponentSystemUUID)
 	}
 
 	if p.includeMAC {
-		identifiers = appendIdentifiersIfValid(identifiers, func() ([]string, error) {
-			return collectMACAddresses(p.macFilter, logger)
-		}, "mac:", diag, ComponentMAC, logger)
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(ctx context.Context) ([]string, error) {
+			isVirtual, isWireless := macClassifiers(ctx, p, logger)
+
+			return p.macAddresses(isVirtual, isWireless, logger)
+		}, "mac:", diag, ComponentMAC)
 	}
 
 	if p.includeDisk {
-		identifiers = appendIdentifiersIfValid(identifiers, func() ([]string, error) {
-			return windowsDiskSerials(ctx, p.commandExecutor, logger)
-		}, "disk:", diag, ComponentDisk, logger)
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(ctx context.Context) ([]string, error) {
+			return windowsDiskSerials(ctx, executor, logger)
+		}, "disk:", diag, ComponentDisk)
+	}
+
+	if p.includeTPM {
+		identifiers = p.appendIdentifier(ctx, identifiers, func(ctx context.Context) (string, error) {
+			return windowsTPMEKHash(ctx, executor, logger)
+		}, "tpm:", diag, ComponentTPM)
+	}
+
+	if p.includeGPU {
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(ctx context.Context) ([]string, error) {
+			return windowsGPUIDs(ctx, executor, logger)
+		}, "gpu:", diag, ComponentGPU)
+	}
+
+	if p.includeMemory {
+		identifiers = p.appendIdentifiers(ctx, identifiers, func(ctx context.Context) ([]string, error) {
+			return windowsMemory(ctx, executor, logger)
+		}, "memory:", diag, ComponentMemory)
 	}
 
 	return identifiers, nil
 }
 
-// parseWmicValue extracts value from wmic output with given prefix.
+// canCollectUnprivileged reports whether component can be collected without
+// administrator rights: WMI and CIM queries can, the TPM cmdlets cannot.
+func canCollectUnprivileged(_ *Provider, component string) bool {
+	return component != ComponentTPM
+}
+
+// macClassifiers returns the virtual and wireless interface classifiers used
+// for the MAC component on Windows, where adapters are classified by Get-NetAdapter.
+func macClassifiers(ctx context.Context, p *Provider, logger *slog.Logger) (isVirtual, isWireless func(net.Interface) bool) {
+	return windowsAdapterClassifier(ctx, p.commandExecutor, logger), isWirelessNetInterface
+}
+
+// osMachineID returns the registry MachineGuid for [Provider.WithFallbackToMachineID].
+func osMachineID(ctx context.Context, p *Provider, logger *slog.Logger) (string, error) {
+	return windowsMachineGUID(ctx, p.commandExecutor, logger)
+}
+
+// platformOSVersion returns the Windows version reported by `ver`.
+func platformOSVersion(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "cmd", "/c", "ver")
+	if err != nil {
+		return "", err
+	}
+
+	return parseVerOutput(output)
+}
+
+// parseVerOutput extracts the version number from `ver` output such as
+// "Microsoft Windows [Version 10.0.22631.4317]".
+func parseVerOutput(output string) (string, error) {
+	_, rest, ok := strings.Cut(output, "[Version ")
+	if !ok {
+		return "", &ParseError{Source: "ver output", Err: ErrNotFound}
+	}
+
+	version, _, ok := strings.Cut(rest, "]")
+	if !ok || strings.TrimSpace(version) == "" {
+		return "", &ParseError{Source: "ver output", Err: ErrNotFound}
+	}
+
+	return strings.TrimSpace(version), nil
+}
+
+// wmicUnavailableNote is recorded in [DiagnosticInfo.Notes] when the wmic
+// executable is missing, as on Windows ARM and recent x64 images.
+const wmicUnavailableNote = "wmic unavailable, using PowerShell"
+
+// wmicAwareExecutor wraps a [CommandExecutor] and remembers when wmic is not
+// installed. After the first exec-not-found failure it records a single
+// diagnostic note and fails further wmic calls immediately, so collectors go
+// straight to their PowerShell fallbacks.
+type wmicAwareExecutor struct {
+	CommandExecutor
+	diag        *DiagnosticInfo
+	logger      *slog.Logger
+	mu          sync.Mutex
+	unavailable bool
+}
+
+// Execute implements [CommandExecutor].
+func (e *wmicAwareExecutor) Execute(ctx context.Context, name string, args ...string) (string, error) {
+	if name != "wmic" {
+		return executeCommand(ctx, e.CommandExecutor, nil, name, args...)
+	}
+
+	e.mu.Lock()
+	unavailable := e.unavailable
+	e.mu.Unlock()
+
+	if unavailable {
+		return "", &CommandError{Command: name, Err: exec.ErrNotFound}
+	}
+
+	output, err := executeCommand(ctx, e.CommandExecutor, nil, name, args...)
+	if err != nil && errors.Is(err, exec.ErrNotFound) {
+		e.mu.Lock()
+		if !e.unavailable {
+			e.unavailable = true
+			addNote(e.diag, wmicUnavailableNote)
+			if e.logger != nil {
+				e.logger.Info(wmicUnavailableNote)
+			}
+		}
+		e.mu.Unlock()
+	}
+
+	return output, err
+}
+
+// netAdapter is an entry of `Get-NetAdapter | ConvertTo-Json` output.
+type netAdapter struct {
+	Name              string `json:"Name"`
+	MacAddress        string `json:"MacAddress"`
+	Virtual           bool   `json:"Virtual"`
+	PhysicalMediaType string `json:"PhysicalMediaType"`
+}
+
+// windowsAdapterClassifier returns an interface classifier backed by
+// Get-NetAdapter, whose Virtual and PhysicalMediaType properties identify
+// Hyper-V, VPN, and other virtual adapters reliably regardless of their
+// display names. Interfaces it does not describe, or every interface when
+// PowerShell is unavailable, fall back to the name-based heuristic.
+func windowsAdapterClassifier(ctx context.Context, executor CommandExecutor, logger *slog.Logger) func(net.Interface) bool {
+	output, err := executeCommand(ctx, executor, logger, "powershell", "-Command",
+		"Get-NetAdapter -IncludeHidden | Select-Object Name,MacAddress,Virtual,PhysicalMediaType | ConvertTo-Json")
+	if err != nil {
+		if logger != nil {
+			logger.Info("Get-NetAdapter unavailable, classifying interfaces by name", "error", err)
+		}
+
+		return isVirtualNetInterface
+	}
+
+	virtualByMAC, err := parseNetAdapters(output)
+	if err != nil {
+		if logger != nil {
+			logger.Debug("Get-NetAdapter parsing failed, classifying interfaces by name", "error", err)
+		}
+
+		return isVirtualNetInterface
+	}
+
+	return func(i net.Interface) bool {
+		if virtual, ok := virtualByMAC[i.HardwareAddr.String()]; ok {
+			return virtual
+		}
+
+		return isVirtualNetInterface(i)
+	}
+}
+
+// parseNetAdapters parses `Get-NetAdapter | ConvertTo-Json` output, which is a
+// single object for one adapter and an array otherwise, into a map from
+// lowercase colon-separated MAC address to whether the adapter is virtual.
+func parseNetAdapters(output string) (map[string]bool, error) {
+	var adapters []netAdapter
+	output = strings.TrimSpace(output)
+	if strings.HasPrefix(output, "{") {
+		var adapter netAdapter
+		if err := json.Unmarshal([]byte(output), &adapter); err != nil {
+			return nil, &ParseError{Source: "Get-NetAdapter JSON", Err: err}
+		}
+		adapters = append(adapters, adapter)
+	} else if err := json.Unmarshal([]byte(output), &adapters); err != nil {
+		return nil, &ParseError{Source: "Get-NetAdapter JSON", Err: err}
+	}
+
+	virtualByMAC := make(map[string]bool, len(adapters))
+	for _, adapter := range adapters {
+		if adapter.MacAddress == "" {
+			continue
+		}
+
+		mac := strings.ToLower(strings.ReplaceAll(adapter.MacAddress, "-", ":"))
+		mediaType := strings.TrimSpace(adapter.PhysicalMediaType)
+		virtualByMAC[mac] = adapter.Virtual || mediaType == "" || strings.EqualFold(mediaType, "Unspecified")
+	}
+
+	return virtualByMAC, nil
+}
+
+// parseWmicValue extracts value from wmic output with given prefix. Output
+// holding only OEM placeholders fails with [ErrOEMPlaceholder].
 func parseWmicValue(output, prefix string) (string, error) {
 	lines := strings.SplitSeq(output, "\n")
+	placeholder := false
 
 	for line := range lines {
 		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, prefix) {
 			value := strings.TrimSpace(strings.TrimPrefix(line, prefix))
-			if value == "" || value == biosFirmwareMessage {
+			if value == "" {
+				continue
+			}
+
+			if isOEMPlaceholder(value) {
+				placeholder = true
+
 				continue
 			}
 
@@ -62,6 +302,10 @@ func parseWmicValue(output, prefix string) (string, error) {
 		}
 	}
 
+	if placeholder {
+		return "", &ParseError{Source: "wmic output", Err: ErrOEMPlaceholder}
+	}
+
 	return "", &ParseError{Source: "wmic output", Err: ErrNotFound}
 }
 
@@ -74,7 +318,7 @@ func parseWmicMultipleValues(output, prefix string) []string {
 		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, prefix) {
 			value := strings.TrimSpace(strings.TrimPrefix(line, prefix))
-			if value == "" || value == biosFirmwareMessage {
+			if value == "" || isOEMPlaceholder(value) {
 				continue
 			}
 			values = append(values, value)
@@ -169,15 +413,35 @@ func windowsMotherboardSerial(ctx context.Context, executor CommandExecutor, log
 		return "", parseErr
 	}
 
-	if value == biosFirmwareMessage {
+	if isOEMPlaceholder(value) {
 		return "", &ParseError{Source: "PowerShell output", Err: ErrOEMPlaceholder}
 	}
 
 	return value, nil
 }
 
-// windowsSystemUUID retrieves system UUID using wmic or PowerShell.
+// windowsSystemUUID retrieves system UUID using wmic or PowerShell, falling
+// back to the registry MachineGuid when neither can read the SMBIOS UUID.
 func windowsSystemUUID(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	uuid, err := windowsSMBIOSUUID(ctx, executor, logger)
+	if err == nil {
+		return uuid, nil
+	}
+
+	if logger != nil {
+		logger.Info("falling back to registry MachineGuid for system UUID", "error", err)
+	}
+
+	guid, guidErr := windowsMachineGUID(ctx, executor, logger)
+	if guidErr != nil {
+		return "", err
+	}
+
+	return guid, nil
+}
+
+// windowsSMBIOSUUID retrieves the SMBIOS system UUID using wmic or PowerShell.
+func windowsSMBIOSUUID(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
 	// Try wmic first
 	output, err := executeCommand(ctx, executor, logger, "wmic", "csproduct", "get", "UUID", "/value")
 	if err == nil {
@@ -207,6 +471,147 @@ func windowsSystemUUIDViaPowerShell(ctx context.Context, executor CommandExecuto
 	return parsePowerShellValue(output)
 }
 
+// windowsBestUUID returns the SMBIOS UUID when it is reliable, otherwise the
+// registry MachineGuid, together with the name of the chosen source.
+func windowsBestUUID(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, string, error) {
+	if uuid, err := windowsSMBIOSUUID(ctx, executor, logger); err == nil && isReliableUUID(uuid) {
+		return uuid, "smbios", nil
+	}
+
+	if logger != nil {
+		logger.Info("SMBIOS UUID unavailable or unreliable, using MachineGuid")
+	}
+
+	guid, err := windowsMachineGUID(ctx, executor, logger)
+	if err != nil {
+		return "", "", err
+	}
+
+	return guid, "MachineGuid", nil
+}
+
+// windowsMachineGUID reads the MachineGuid generated at Windows installation
+// time from the registry.
+func windowsMachineGUID(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "reg", "query",
+		`HKLM\SOFTWARE\Microsoft\Cryptography`, "/v", "MachineGuid")
+	if err != nil {
+		return "", err
+	}
+
+	return parseRegQueryValue(output, "MachineGuid")
+}
+
+// parseRegQueryValue extracts the data of the named value from `reg query` output,
+// whose value lines have the form "    <name>    <type>    <data>".
+func parseRegQueryValue(output, name string) (string, error) {
+	lines := strings.SplitSeq(output, "\n")
+
+	for line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && strings.EqualFold(fields[0], name) && strings.HasPrefix(fields[1], "REG_") {
+			return strings.Join(fields[2:], " "), nil
+		}
+	}
+
+	return "", &ParseError{Source: "reg query output", Err: ErrNotFound}
+}
+
+// windowsTPMEKHash retrieves the SHA-256 digest of the TPM Endorsement Key
+// public key. Machines without a TPM print nothing and fail with [ErrNotFound].
+func windowsTPMEKHash(ctx context.Context, executor CommandExecutor, logger *slog.Logger) (string, error) {
+	output, err := executeCommand(ctx, executor, logger, "powershell", "-Command",
+		"if ((Get-Tpm).TpmPresent) { (Get-TpmEndorsementKeyInfo -HashAlgorithm sha256).PublicKeyHash }")
+	if err != nil {
+		return "", err
+	}
+
+	hash := strings.ToLower(strings.TrimSpace(output))
+	if hash == "" {
+		return "", &ParseError{Source: "Get-TpmEndorsementKeyInfo output", Err: ErrNotFound}
+	}
+
+	return hash, nil
+}
+
+// windowsGPUIDs retrieves the PNPDeviceID of each PCI video controller using
+// wmic, with PowerShell fallback. Software adapters, such as the Microsoft
+// Basic Display Adapter or a remote desktop driver, are not on the PCI bus and
+// are skipped.
+func windowsGPUIDs(ctx context.Context, executor CommandExecutor, logger *slog.Logger) ([]string, error) {
+	output, err := executeCommand(ctx, executor, logger, "wmic", "path", "win32_VideoController", "get", "PNPDeviceID", "/value")
+	if err == nil {
+		if values := pciDeviceIDs(parseWmicMultipleValues(output, "PNPDeviceID=")); len(values) > 0 {
+			return values, nil
+		}
+	}
+
+	// Fallback to PowerShell Get-CimInstance
+	if logger != nil {
+		logger.Info("falling back to PowerShell for video controllers")
+	}
+
+	psOutput, psErr := executeCommand(ctx, executor, logger, "powershell", "-Command",
+		"Get-CimInstance -ClassName Win32_VideoController | Select-Object -ExpandProperty PNPDeviceID")
+	if psErr != nil {
+		return nil, ErrAllMethodsFailed
+	}
+
+	return pciDeviceIDs(parsePowerShellMultipleValues(psOutput)), nil
+}
+
+// windowsMemory retrieves the memory module serials and capacities from
+// Win32_PhysicalMemory using wmic, with PowerShell fallback printing the same
+// "Name=value" lines.
+func windowsMemory(ctx context.Context, executor CommandExecutor, logger *slog.Logger) ([]string, error) {
+	output, err := executeCommand(ctx, executor, logger, "wmic", "memorychip", "get", "SerialNumber,Capacity", "/value")
+	if err == nil {
+		if values, parseErr := parseWmicMemory(output); parseErr == nil {
+			return values, nil
+		} else if logger != nil {
+			logger.Debug("wmic memory parsing failed", "error", parseErr)
+		}
+	}
+
+	// Fallback to PowerShell Get-CimInstance
+	if logger != nil {
+		logger.Info("falling back to PowerShell for memory modules")
+	}
+
+	psOutput, psErr := executeCommand(ctx, executor, logger, "powershell", "-Command",
+		`Get-CimInstance -ClassName Win32_PhysicalMemory | ForEach-Object { "Capacity=$($_.Capacity)"; "SerialNumber=$($_.SerialNumber)" }`)
+	if psErr != nil {
+		return nil, ErrAllMethodsFailed
+	}
+
+	return parseWmicMemory(psOutput)
+}
+
+// parseWmicMemory parses the Capacity and SerialNumber lines of
+// Win32_PhysicalMemory into [ComponentMemory] values.
+func parseWmicMemory(output string) ([]string, error) {
+	var total uint64
+	for _, capacity := range parseWmicMultipleValues(output, "Capacity=") {
+		if n, err := strconv.ParseUint(capacity, 10, 64); err == nil {
+			total += n
+		}
+	}
+
+	return memoryValues(parseWmicMultipleValues(output, "SerialNumber="), total, "wmic memorychip output")
+}
+
+// pciDeviceIDs returns the PNP device IDs of ids that are on the PCI bus.
+func pciDeviceIDs(ids []string) []string {
+	var pci []string
+	for _, id := range ids {
+		if strings.HasPrefix(strings.ToUpper(id), `PCI\`) {
+			pci = append(pci, id)
+		}
+	}
+
+	return pci
+}
+
 // windowsDiskSerials retrieves disk serial numbers using wmic, with PowerShell fallback.
 func windowsDiskSerials(ctx context.Context, executor CommandExecutor, logger *slog.Logger) ([]string, error) {
 	output, err := executeCommand(ctx, executor, logger, "wmic", "diskdrive", "get", "SerialNumber", "/value")
@@ -0,0 +1,276 @@
+//go:build windows
+
+package machineid
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os/exec"
+	"slices"
+	"testing"
+)
+
+// TestParseRegQueryValue tests extraction of a value from reg query output.
+func TestParseRegQueryValue(t *testing.T) {
+	output := "\r\nHKEY_LOCAL_MACHINE\\SOFTWARE\\Microsoft\\Cryptography\r\n" +
+		"    MachineGuid    REG_SZ    6f1d3c2a-8b7e-4f5a-9c0d-1e2f3a4b5c6d\r\n\r\n"
+
+	got, err := parseRegQueryValue(output, "MachineGuid")
+	if err != nil {
+		t.Fatalf("parseRegQueryValue() error = %v", err)
+	}
+	if got != "6f1d3c2a-8b7e-4f5a-9c0d-1e2f3a4b5c6d" {
+		t.Errorf("parseRegQueryValue() = %q", got)
+	}
+
+	if _, err := parseRegQueryValue("ERROR: not found", "MachineGuid"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("parseRegQueryValue() error = %v, want ErrNotFound", err)
+	}
+}
+
+// TestWindowsBestUUIDFallback tests falling back to MachineGuid for an unset SMBIOS UUID.
+func TestWindowsBestUUIDFallback(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutput("powershell", "FFFFFFFF-FFFF-FFFF-FFFF-FFFFFFFFFFFF")
+	mock.setError("wmic", errors.New("wmic not available"))
+	mock.setOutput("reg", "    MachineGuid    REG_SZ    6f1d3c2a-8b7e-4f5a-9c0d-1e2f3a4b5c6d")
+
+	value, source, err := windowsBestUUID(context.Background(), mock, nil)
+	if err != nil {
+		t.Fatalf("windowsBestUUID() error = %v", err)
+	}
+	if value != "6f1d3c2a-8b7e-4f5a-9c0d-1e2f3a4b5c6d" || source != "MachineGuid" {
+		t.Errorf("windowsBestUUID() = %q, %q; want MachineGuid fallback", value, source)
+	}
+}
+
+// TestWindowsSystemUUIDRegistryFallback tests falling back to the registry
+// MachineGuid when neither wmic nor PowerShell return the SMBIOS UUID.
+func TestWindowsSystemUUIDRegistryFallback(t *testing.T) {
+	psErr := errors.New("powershell not available")
+	mock := newMockExecutor()
+	mock.setError("wmic", errors.New("wmic not available"))
+	mock.setError("powershell", psErr)
+	mock.setOutput("reg", "\r\nHKEY_LOCAL_MACHINE\\SOFTWARE\\Microsoft\\Cryptography\r\n"+
+		"    MachineGuid    REG_SZ    6f1d3c2a-8b7e-4f5a-9c0d-1e2f3a4b5c6d\r\n\r\n")
+
+	uuid, err := windowsSystemUUID(context.Background(), mock, nil)
+	if err != nil {
+		t.Fatalf("windowsSystemUUID() error = %v", err)
+	}
+	if uuid != "6f1d3c2a-8b7e-4f5a-9c0d-1e2f3a4b5c6d" {
+		t.Errorf("windowsSystemUUID() = %q, want MachineGuid", uuid)
+	}
+
+	mock.setOutput("reg", "ERROR: The system was unable to find the specified registry key or value.")
+	if _, err := windowsSystemUUID(context.Background(), mock, nil); !errors.Is(err, psErr) {
+		t.Errorf("windowsSystemUUID() error = %v, want the PowerShell error", err)
+	}
+}
+
+// TestWindowsTPMEKHash tests reading the Endorsement Key digest, and that a
+// machine without a TPM fails with ErrNotFound.
+func TestWindowsTPMEKHash(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutput("powershell", "8F3A5C1E9B2D4F60A7C8E1B3D5F7092A4C6E8B0D2F4A6C8E0B2D4F6A8C0E2B4D\r\n")
+
+	hash, err := windowsTPMEKHash(context.Background(), mock, nil)
+	if err != nil {
+		t.Fatalf("windowsTPMEKHash() error = %v", err)
+	}
+	if hash != "8f3a5c1e9b2d4f60a7c8e1b3d5f7092a4c6e8b0d2f4a6c8e0b2d4f6a8c0e2b4d" {
+		t.Errorf("windowsTPMEKHash() = %q, want the lowercased PublicKeyHash", hash)
+	}
+
+	mock.setOutput("powershell", "\r\n")
+	if _, err := windowsTPMEKHash(context.Background(), mock, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("windowsTPMEKHash() without TPM error = %v, want ErrNotFound", err)
+	}
+}
+
+// TestWindowsGPUIDs tests that only PCI video controllers are reported, from
+// wmic or, failing that, PowerShell.
+func TestWindowsGPUIDs(t *testing.T) {
+	const nvidia = `PCI\VEN_10DE&DEV_2484&SUBSYS_146B10DE&REV_A1\4&2A1B3C4D&0&0008`
+
+	mock := newMockExecutor()
+	mock.setOutput("wmic", "\r\nPNPDeviceID="+nvidia+"\r\n\r\nPNPDeviceID=ROOT\\BasicDisplay\\0000\r\n")
+
+	got, err := windowsGPUIDs(context.Background(), mock, nil)
+	if err != nil {
+		t.Fatalf("windowsGPUIDs() error = %v", err)
+	}
+	if !slices.Equal(got, []string{nvidia}) {
+		t.Errorf("windowsGPUIDs() = %v, want [%s]", got, nvidia)
+	}
+
+	mock.setError("wmic", &exec.Error{Name: "wmic", Err: exec.ErrNotFound})
+	mock.setOutput("powershell", nvidia+"\r\n")
+	got, err = windowsGPUIDs(context.Background(), mock, nil)
+	if err != nil || !slices.Equal(got, []string{nvidia}) {
+		t.Errorf("windowsGPUIDs() via PowerShell = %v, %v; want [%s]", got, err, nvidia)
+	}
+}
+
+// TestWindowsMemory tests the module serials from wmic, and the total
+// capacity from PowerShell when wmic is unavailable and no serial is usable.
+func TestWindowsMemory(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setOutput("wmic", "\r\nCapacity=17179869184\r\nSerialNumber=1A2B3C4D\r\n\r\nCapacity=17179869184\r\nSerialNumber=5E6F7A8B\r\n")
+
+	got, err := windowsMemory(context.Background(), mock, nil)
+	if err != nil {
+		t.Fatalf("windowsMemory() error = %v", err)
+	}
+	if want := []string{"1A2B3C4D", "5E6F7A8B"}; !slices.Equal(got, want) {
+		t.Errorf("windowsMemory() = %v, want %v", got, want)
+	}
+
+	mock.setError("wmic", &exec.Error{Name: "wmic", Err: exec.ErrNotFound})
+	mock.setOutput("powershell", "Capacity=8589934592\r\nSerialNumber=00000000\r\nCapacity=8589934592\r\nSerialNumber=\r\n")
+	got, err = windowsMemory(context.Background(), mock, nil)
+	if err != nil {
+		t.Fatalf("windowsMemory() via PowerShell error = %v", err)
+	}
+	if want := []string{"total:17179869184"}; !slices.Equal(got, want) {
+		t.Errorf("windowsMemory() via PowerShell = %v, want %v", got, want)
+	}
+}
+
+// TestWmicUnavailableNote tests that a missing wmic executable is reported once
+// as a note while PowerShell fallbacks succeed.
+func TestWmicUnavailableNote(t *testing.T) {
+	mock := newMockExecutor()
+	mock.setError("wmic", &exec.Error{Name: "wmic", Err: exec.ErrNotFound})
+	mock.setOutput("powershell", "4C4C4544-0042-3510-8052-B4C04F384833")
+
+	p := New().WithCPU().WithSystemUUID().WithExecutor(mock)
+	if _, err := p.ID(context.Background()); err != nil {
+		t.Fatalf("ID() error = %v", err)
+	}
+
+	diag := p.Diagnostics()
+	if len(diag.Errors) != 0 {
+		t.Errorf("expected no component errors, got %v", diag.Errors)
+	}
+	if !slices.Equal(diag.Notes, []string{wmicUnavailableNote}) {
+		t.Errorf("Notes = %v, want [%q]", diag.Notes, wmicUnavailableNote)
+	}
+	if mock.callCount["wmic"] != 1 {
+		t.Errorf("wmic called %d times, want 1", mock.callCount["wmic"])
+	}
+}
+
+// TestParseWmicValuePlaceholder tests that OEM placeholders are skipped and
+// reported as ErrOEMPlaceholder when no other value is present.
+func TestParseWmicValuePlaceholder(t *testing.T) {
+	if _, err := parseWmicValue("\r\nSerialNumber=Default string\r\n", "SerialNumber="); !errors.Is(err, ErrOEMPlaceholder) {
+		t.Errorf("parseWmicValue() error = %v, want ErrOEMPlaceholder", err)
+	}
+
+	if _, err := parseWmicValue("\r\nSerialNumber=\r\n", "SerialNumber="); !errors.Is(err, ErrNotFound) {
+		t.Errorf("parseWmicValue() error = %v, want ErrNotFound", err)
+	}
+
+	got := parseWmicMultipleValues("SerialNumber=None\nSerialNumber=S4EWNX0R123456\n", "SerialNumber=")
+	if !slices.Equal(got, []string{"S4EWNX0R123456"}) {
+		t.Errorf("parseWmicMultipleValues() = %v, want [S4EWNX0R123456]", got)
+	}
+}
+
+// getNetAdapterOutput is captured `Get-NetAdapter | ConvertTo-Json` output from
+// a Hyper-V host with a physical NIC, Wi-Fi, and a vEthernet switch adapter.
+const getNetAdapterOutput = `[
+    {
+        "Name":  "Ethernet",
+        "MacAddress":  "3C-7C-3F-1A-2B-3C",
+        "Virtual":  false,
+        "PhysicalMediaType":  "802.3"
+    },
+    {
+        "Name":  "Wi-Fi",
+        "MacAddress":  "A4-C3-F0-11-22-33",
+        "Virtual":  false,
+        "PhysicalMediaType":  "Native 802.11"
+    },
+    {
+        "Name":  "vEthernet (Default Switch)",
+        "MacAddress":  "00-15-5D-01-02-03",
+        "Virtual":  true,
+        "PhysicalMediaType":  "Unspecified"
+    }
+]`
+
+// TestParseNetAdapters tests classification of captured Get-NetAdapter output.
+func TestParseNetAdapters(t *testing.T) {
+	virtualByMAC, err := parseNetAdapters(getNetAdapterOutput)
+	if err != nil {
+		t.Fatalf("parseNetAdapters() error = %v", err)
+	}
+
+	want := map[string]bool{
+		"3c:7c:3f:1a:2b:3c": false,
+		"a4:c3:f0:11:22:33": false,
+		"00:15:5d:01:02:03": true,
+	}
+	for mac, virtual := range want {
+		if got, ok := virtualByMAC[mac]; !ok || got != virtual {
+			t.Errorf("virtualByMAC[%q] = %v, %v; want %v", mac, got, ok, virtual)
+		}
+	}
+
+	single := `{"Name": "Ethernet", "MacAddress": "3C-7C-3F-1A-2B-3C", "Virtual": false, "PhysicalMediaType": "802.3"}`
+	virtualByMAC, err = parseNetAdapters(single)
+	if err != nil {
+		t.Fatalf("parseNetAdapters() single object error = %v", err)
+	}
+	if virtual, ok := virtualByMAC["3c:7c:3f:1a:2b:3c"]; !ok || virtual {
+		t.Error("single physical adapter should be classified as physical")
+	}
+}
+
+// TestWindowsAdapterClassifier tests Get-NetAdapter classification and the
+// name-based fallback when PowerShell is unavailable.
+func TestWindowsAdapterClassifier(t *testing.T) {
+	vEthernet := net.Interface{Name: "vEthernet (Default Switch)", HardwareAddr: net.HardwareAddr{0x00, 0x15, 0x5d, 0x01, 0x02, 0x03}}
+	ethernet := net.Interface{Name: "Ethernet", HardwareAddr: net.HardwareAddr{0x3c, 0x7c, 0x3f, 0x1a, 0x2b, 0x3c}}
+	unknown := net.Interface{Name: "docker0", HardwareAddr: net.HardwareAddr{0x02, 0x42, 0xac, 0x11, 0x00, 0x02}}
+
+	mock := newMockExecutor()
+	mock.setOutput("powershell", getNetAdapterOutput)
+	isVirtual := windowsAdapterClassifier(context.Background(), mock, nil)
+
+	if !isVirtual(vEthernet) {
+		t.Error("vEthernet adapter should be classified as virtual")
+	}
+	if isVirtual(ethernet) {
+		t.Error("physical Ethernet adapter should be classified as physical")
+	}
+	if !isVirtual(unknown) {
+		t.Error("adapter missing from Get-NetAdapter should fall back to name heuristic")
+	}
+
+	failing := newMockExecutor()
+	failing.setError("powershell", errors.New("powershell not available"))
+	isVirtual = windowsAdapterClassifier(context.Background(), failing, nil)
+
+	if isVirtual(ethernet) != isVirtualInterface(ethernet.Name) {
+		t.Error("classifier should fall back to the name heuristic without PowerShell")
+	}
+}
+
+// TestParseVerOutput tests extraction of the Windows version from ver output.
+func TestParseVerOutput(t *testing.T) {
+	got, err := parseVerOutput("\r\nMicrosoft Windows [Version 10.0.22631.4317]\r\n")
+	if err != nil {
+		t.Fatalf("parseVerOutput() error = %v", err)
+	}
+	if got != "10.0.22631.4317" {
+		t.Errorf("parseVerOutput() = %q, want %q", got, "10.0.22631.4317")
+	}
+
+	if _, err := parseVerOutput("garbage"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("parseVerOutput() error = %v, want ErrNotFound", err)
+	}
+}